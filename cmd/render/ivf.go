@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/binary"
+	"io"
+	"os"
+)
+
+// ivfWriter writes VP8/VP9/AV1 frames into an IVF container.
+// See: https://wiki.multimedia.cx/index.php/IVF.
+type ivfWriter struct {
+	f     *os.File
+	count uint32
+}
+
+func newIvfWriter(path string, fourcc string, w, h int, fps int) (*ivfWriter, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	var hdr [32]byte
+	copy(hdr[0:], "DKIF")
+	binary.LittleEndian.PutUint16(hdr[4:], 0)  // version
+	binary.LittleEndian.PutUint16(hdr[6:], 32) // header size
+	copy(hdr[8:], fourcc)
+	binary.LittleEndian.PutUint16(hdr[12:], uint16(w))
+	binary.LittleEndian.PutUint16(hdr[14:], uint16(h))
+	binary.LittleEndian.PutUint32(hdr[16:], uint32(fps)) // time base denominator
+	binary.LittleEndian.PutUint32(hdr[20:], 1)           // time base numerator
+	if _, err := f.Write(hdr[:]); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return &ivfWriter{f: f}, nil
+}
+
+func (w *ivfWriter) Write(frame []byte) error {
+	var hdr [12]byte
+	binary.LittleEndian.PutUint32(hdr[0:], uint32(len(frame)))
+	binary.LittleEndian.PutUint64(hdr[4:], uint64(w.count)) // pts
+	if _, err := w.f.Write(hdr[:]); err != nil {
+		return err
+	}
+	if _, err := w.f.Write(frame); err != nil {
+		return err
+	}
+	w.count++
+	return nil
+}
+
+// Close updates the number of frames in the header and closes the file.
+func (w *ivfWriter) Close() error {
+	var n [4]byte
+	binary.LittleEndian.PutUint32(n[:], w.count)
+	if _, err := w.f.Seek(24, io.SeekStart); err == nil {
+		_, _ = w.f.Write(n[:])
+	}
+	return w.f.Close()
+}
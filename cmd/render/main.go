@@ -0,0 +1,283 @@
+// Render is a headless tool that runs games without any frame pacing
+// and saves the output as video files, optionally replaying input movies.
+//
+// Usage:
+//
+//	render -rom games/nes/game.nes -system nes -movie input.cgm -format ivf -out ./render
+//
+// Only software-rendered (not OpenGL) cores are supported.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	stdos "os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/giongto35/cloud-game/v3/pkg/config"
+	"github.com/giongto35/cloud-game/v3/pkg/encoder"
+	"github.com/giongto35/cloud-game/v3/pkg/logger"
+	"github.com/giongto35/cloud-game/v3/pkg/os"
+	"github.com/giongto35/cloud-game/v3/pkg/worker/caged/app"
+	"github.com/giongto35/cloud-game/v3/pkg/worker/caged/libretro"
+	"github.com/giongto35/cloud-game/v3/pkg/worker/caged/libretro/manager"
+	"github.com/giongto35/cloud-game/v3/pkg/worker/movie"
+	"github.com/giongto35/cloud-game/v3/pkg/worker/recorder"
+	"github.com/giongto35/cloud-game/v3/pkg/worker/thread"
+)
+
+var Version = "?"
+
+// Output formats.
+const (
+	formatRec  = "rec"  // recorder: WAV + raw frames + ffmpeg concat file
+	formatIvf  = "ivf"  // VP8/VP9/AV1 in IVF
+	formatH264 = "h264" // H.264 Annex-B elementary stream
+)
+
+type options struct {
+	rom    string
+	system string
+	movie  string
+	frames int
+	out    string
+	format string
+	codec  string
+	debug  bool
+}
+
+// output is a sink for the rendered audio and video.
+type output interface {
+	Video(v app.Video)
+	Audio(a app.Audio)
+	Close() error
+}
+
+func run() {
+	conf, paths := config.NewWorkerConfig()
+
+	var opts options
+	flag.StringVar(&opts.rom, "rom", "", "Game ROM file path")
+	flag.StringVar(&opts.system, "system", "", "Emulator system (core) name from the config, i.e. nes (default from the movie)")
+	flag.StringVar(&opts.movie, "movie", "", "Input movie file to replay")
+	flag.IntVar(&opts.frames, "frames", 0, "The number of frames to render (0 - until the end of the movie)")
+	flag.StringVar(&opts.out, "out", "./render", "Output directory")
+	flag.StringVar(&opts.format, "format", formatRec, "Output format: rec (WAV + raw frames), ivf (video only), h264 (video only)")
+	flag.StringVar(&opts.codec, "codec", "vp8", "IVF video codec: vp8, vp9, av1")
+	flag.BoolVar(&opts.debug, "debug", false, "Debug output")
+	flag.Parse()
+
+	log := logger.NewConsole(opts.debug, "r", false)
+	log.Info().Msgf("version %s", Version)
+	log.Debug().Msgf("conf: v%v, loaded: %v", conf.Version, paths)
+
+	if err := render(conf, opts, log); err != nil {
+		log.Error().Err(err).Msg("render fail")
+		stdos.Exit(1)
+	}
+}
+
+func render(conf config.WorkerConfig, opts options, log *logger.Logger) error {
+	if opts.rom == "" {
+		return errors.New("no ROM")
+	}
+	if opts.movie != "" && opts.system == "" {
+		mr, err := movie.Open(opts.movie)
+		if err != nil {
+			return err
+		}
+		opts.system = mr.Header.System
+		_ = mr.Close()
+	}
+	if opts.system == "" {
+		return errors.New("no system")
+	}
+	if opts.movie == "" && opts.frames <= 0 {
+		return errors.New("nothing to render, set the number of frames or a movie")
+	}
+	if conf.Emulator.GetLibretroCoreConfig(opts.system).IsGlAllowed {
+		return fmt.Errorf("the %v core needs OpenGL, only software-rendered cores are supported", opts.system)
+	}
+	if err := os.CheckCreateDir(opts.out); err != nil {
+		return err
+	}
+
+	// no need for saves
+	conf.Emulator.AutosaveSec = 0
+	conf.Emulator.Netplay.Enabled = false
+
+	if err := manager.CheckCores(conf.Emulator, log); err != nil {
+		log.Warn().Err(err).Msgf("a Libretro cores sync fail")
+	}
+
+	fe, err := libretro.NewFrontend(conf.Emulator, log)
+	if err != nil {
+		return err
+	}
+	fe.LoadCore(opts.system)
+	if err := fe.LoadGame(opts.rom); err != nil {
+		return err
+	}
+	fe.ViewportRecalculate()
+	defer func() {
+		fe.Close()
+		fe.Shutdown()
+	}()
+
+	game := strings.TrimSuffix(filepath.Base(opts.rom), filepath.Ext(opts.rom))
+	out, err := newOutput(fe, game, conf, opts, log)
+	if err != nil {
+		return err
+	}
+	fe.SetVideoCb(out.Video)
+	fe.SetAudioCb(out.Audio)
+
+	if opts.movie != "" {
+		if err := fe.ReplayMovie(opts.movie); err != nil {
+			return err
+		}
+	}
+
+	// as fast as possible
+	start := time.Now()
+	n := 0
+	for ; opts.frames > 0 && n < opts.frames || opts.frames <= 0 && fe.IsReplaying(); n++ {
+		fe.Tick()
+	}
+	elapsed := time.Since(start)
+	log.Info().Msgf("rendered %v frames in %v (%.1f fps)", n, elapsed, float64(n)/elapsed.Seconds())
+
+	return out.Close()
+}
+
+func newOutput(fe *libretro.Frontend, game string, conf config.WorkerConfig, opts options, log *logger.Logger) (output, error) {
+	switch opts.format {
+	case formatRec:
+		rec := recorder.NewRecording(recorder.Meta{UserName: "render"}, log, recorder.Options{
+			Dir:       opts.out,
+			Fps:       float64(fe.FPS()),
+			Flip:      fe.Flipped(),
+			Frequency: fe.AudioSampleRate(),
+			Game:      game,
+			Name:      conf.Recording.Name,
+			Vsync:     true,
+		})
+		rec.SetPixFormat(fe.PixFormat())
+		rec.Set(true, "render")
+		return &recOutput{rec: rec, hz: fe.AudioSampleRate()}, nil
+	case formatIvf, formatH264:
+		vc := conf.Encoder.Video
+		vc.Codec = opts.codec
+		if opts.format == formatH264 {
+			vc.Codec = string(encoder.H264)
+		}
+		w, h := fe.ViewportSize()
+		enc, err := encoder.NewVideoEncoder(w, h, w, h, 1, vc, log)
+		if err != nil {
+			return nil, err
+		}
+		enc.SetPixFormat(fe.PixFormat())
+		enc.SetRot(fe.Rotation())
+
+		var es esWriter
+		path := filepath.Join(opts.out, game)
+		if opts.format == formatH264 {
+			es, err = newH264Writer(path + ".h264")
+		} else {
+			fourcc := "VP80"
+			switch encoder.VideoCodec(vc.Codec) {
+			case encoder.VP9:
+				fourcc = "VP90"
+			case encoder.AV1:
+				fourcc = "AV01"
+			}
+			es, err = newIvfWriter(path+".ivf", fourcc, w, h, fe.FPS())
+		}
+		if err != nil {
+			enc.Stop()
+			return nil, err
+		}
+		log.Info().Msgf("encoder: %v", enc.Info())
+		return &encOutput{enc: enc, es: es, log: log}, nil
+	default:
+		return nil, fmt.Errorf("unknown format: %v", opts.format)
+	}
+}
+
+// recOutput saves raw media with the recorder.
+type recOutput struct {
+	rec *recorder.Recording
+	hz  int
+}
+
+func (o *recOutput) Video(v app.Video) {
+	// the frames are saved asynchronously
+	o.rec.WriteVideo(recorder.Video{
+		Frame: recorder.Frame{
+			Data:   bytes.Clone(v.Frame.Data),
+			Stride: v.Frame.Stride,
+			W:      v.Frame.W,
+			H:      v.Frame.H,
+		},
+		Duration: time.Duration(v.Duration),
+	})
+}
+
+func (o *recOutput) Audio(a app.Audio) {
+	dur := time.Duration(float64(len(a.Data)) / float64(o.hz<<1) * float64(time.Second))
+	o.rec.WriteAudio(recorder.Audio{Samples: a.Data, Duration: dur})
+}
+
+func (o *recOutput) Close() error { o.rec.Set(false, ""); return nil }
+
+// esWriter writes encoded video frames.
+type esWriter interface {
+	Write(frame []byte) error
+	Close() error
+}
+
+// h264Writer writes H.264 Annex-B frames as is.
+type h264Writer struct{ f *stdos.File }
+
+func newH264Writer(path string) (*h264Writer, error) {
+	f, err := stdos.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return &h264Writer{f: f}, nil
+}
+
+func (w *h264Writer) Write(frame []byte) error { _, err := w.f.Write(frame); return err }
+func (w *h264Writer) Close() error             { return w.f.Close() }
+
+// encOutput encodes video into an elementary stream, the audio is skipped.
+type encOutput struct {
+	enc *encoder.Video
+	es  esWriter
+	err error
+	log *logger.Logger
+}
+
+func (o *encOutput) Video(v app.Video) {
+	if o.err != nil {
+		return
+	}
+	if frame := o.enc.Encode(encoder.InFrame(v.Frame)); len(frame) > 0 {
+		if o.err = o.es.Write(frame); o.err != nil {
+			o.log.Error().Err(o.err).Msg("write fail")
+		}
+	}
+}
+
+func (o *encOutput) Audio(app.Audio) {}
+
+func (o *encOutput) Close() error {
+	o.enc.Stop()
+	return errors.Join(o.err, o.es.Close())
+}
+
+func main() { thread.Wrap(run) }
@@ -7,6 +7,7 @@ import (
 	"github.com/giongto35/cloud-game/v3/pkg/logger"
 	"github.com/giongto35/cloud-game/v3/pkg/os"
 	"github.com/giongto35/cloud-game/v3/pkg/worker"
+	"github.com/giongto35/cloud-game/v3/pkg/worker/caged/libretro/sandbox"
 	"github.com/giongto35/cloud-game/v3/pkg/worker/thread"
 )
 
@@ -37,4 +38,10 @@ func run() {
 	}
 }
 
-func main() { thread.Wrap(run) }
+func main() {
+	if sandbox.IsChild() {
+		thread.Wrap(sandbox.Run)
+		return
+	}
+	thread.Wrap(run)
+}
@@ -80,12 +80,45 @@ const (
 	GetWorkerList    PT = 111
 	ErrNoFreeSlots   PT = 112
 	ResetGame        PT = 113
+	RoomUsers        PT = 114
+	ErrRoomFull      PT = 115
+	KickUser         PT = 116
+	TransferHost     PT = 117
+	LockRoom         PT = 118
+	HostChanged      PT = 119
+	Kicked           PT = 120
+	ListSlots        PT = 121
+	SaveSlot         PT = 122
+	LoadSlot         PT = 123
+	VideoLayer       PT = 124
+	WhepToken        PT = 125
+	RoomAudio        PT = 126
+	VideoFilter      PT = 127
+	Cheats           PT = 128
+	SetCheat         PT = 129
+	Disks            PT = 130
+	EjectDisk        PT = 131
+	SetDisk          PT = 132
+	Speed            PT = 133
+	FrameAdvance     PT = 134
+	Rewind           PT = 135
+	CoreOptions      PT = 136
+	SetCoreOption    PT = 137
 	RegisterRoom     PT = 201
 	CloseRoom        PT = 202
 	TerminateSession PT = 204
 	AppVideoChange   PT = 150
+	AppCrash         PT = 151
+	AppFrame         PT = 152
+	AppSpeed         PT = 153
 	LibNewGameList   PT = 205
 	PrevSessions     PT = 206
+	StorageSave      PT = 207
+	StorageLoad      PT = 208
+	StorageHas       PT = 209
+	StorageDelete    PT = 210
+	StorageList      PT = 211
+	StorageRoom      PT = 212
 )
 
 func (p PT) String() string {
@@ -116,6 +149,54 @@ func (p PT) String() string {
 		return "NoFreeSlots"
 	case ResetGame:
 		return "ResetGame"
+	case RoomUsers:
+		return "RoomUsers"
+	case ErrRoomFull:
+		return "RoomFull"
+	case KickUser:
+		return "KickUser"
+	case TransferHost:
+		return "TransferHost"
+	case LockRoom:
+		return "LockRoom"
+	case HostChanged:
+		return "HostChanged"
+	case Kicked:
+		return "Kicked"
+	case ListSlots:
+		return "ListSlots"
+	case SaveSlot:
+		return "SaveSlot"
+	case LoadSlot:
+		return "LoadSlot"
+	case VideoLayer:
+		return "VideoLayer"
+	case WhepToken:
+		return "WhepToken"
+	case RoomAudio:
+		return "RoomAudio"
+	case VideoFilter:
+		return "VideoFilter"
+	case Cheats:
+		return "Cheats"
+	case SetCheat:
+		return "SetCheat"
+	case Disks:
+		return "Disks"
+	case EjectDisk:
+		return "EjectDisk"
+	case SetDisk:
+		return "SetDisk"
+	case Speed:
+		return "Speed"
+	case FrameAdvance:
+		return "FrameAdvance"
+	case Rewind:
+		return "Rewind"
+	case CoreOptions:
+		return "CoreOptions"
+	case SetCoreOption:
+		return "SetCoreOption"
 	case RegisterRoom:
 		return "RegisterRoom"
 	case CloseRoom:
@@ -124,10 +205,28 @@ func (p PT) String() string {
 		return "TerminateSession"
 	case AppVideoChange:
 		return "AppVideoChange"
+	case AppCrash:
+		return "AppCrash"
+	case AppFrame:
+		return "AppFrame"
+	case AppSpeed:
+		return "AppSpeed"
 	case LibNewGameList:
 		return "LibNewGameList"
 	case PrevSessions:
 		return "PrevSessions"
+	case StorageSave:
+		return "StorageSave"
+	case StorageLoad:
+		return "StorageLoad"
+	case StorageHas:
+		return "StorageHas"
+	case StorageDelete:
+		return "StorageDelete"
+	case StorageList:
+		return "StorageList"
+	case StorageRoom:
+		return "StorageRoom"
 	default:
 		return "Unknown"
 	}
@@ -139,6 +238,14 @@ const (
 	OK    = "ok"
 )
 
+// Role is a role of the user in a room.
+type Role string
+
+const (
+	RolePlayer    Role = "player"
+	RoleSpectator Role = "spectator"
+)
+
 var (
 	ErrForbidden = fmt.Errorf("forbidden")
 	ErrMalformed = fmt.Errorf("malformed")
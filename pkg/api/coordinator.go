@@ -8,6 +8,7 @@ type (
 		IsHTTPS bool   `json:"is_https,omitempty"`
 		PingURL string `json:"ping_url,omitempty"`
 		Port    string `json:"port,omitempty"`
+		Slots   int    `json:"slots,omitempty"`
 		Tag     string `json:"tag,omitempty"`
 		Zone    string `json:"zone,omitempty"`
 	}
@@ -15,6 +16,26 @@ type (
 		Servers []Server `json:"servers"`
 	}
 	RegisterRoomRequest string
+
+	// The coordinator storage requests of workers.
+	StorageSaveRequest struct {
+		Name string            `json:"name"`
+		Data []byte            `json:"data"`
+		Tags map[string]string `json:"tags,omitempty"`
+	}
+	StorageLoadRequest   string
+	StorageHasRequest    string
+	StorageDeleteRequest string
+	StorageListRequest   string // name prefix
+	StorageRoomRequest   string // the room which files the worker may use
+	// StorageResponse is a response to all the storage requests,
+	// Err is not empty if the request has failed.
+	StorageResponse struct {
+		Data  []byte   `json:"data,omitempty"`
+		Has   bool     `json:"has,omitempty"`
+		Names []string `json:"names,omitempty"`
+		Err   string   `json:"err,omitempty"`
+	}
 )
 
 const (
@@ -22,21 +43,24 @@ const (
 	RoomIdQueryParam = "room_id"
 	ZoneQueryParam   = "zone"
 	WorkerIdParam    = "wid"
+	ResumeParam      = "resume"
 )
 
 // Server contains a list of server groups.
 // Server is a separate machine that may contain
 // multiple sub-processes.
 type Server struct {
-	Addr     string `json:"addr,omitempty"`
-	Id       Id     `json:"id,omitempty"`
-	IsBusy   bool   `json:"is_busy,omitempty"`
-	InGroup  bool   `json:"in_group,omitempty"`
-	Machine  string `json:"machine,omitempty"`
-	PingURL  string `json:"ping_url"`
-	Port     string `json:"port,omitempty"`
-	Replicas uint32 `json:"replicas,omitempty"`
-	Room     string `json:"room,omitempty"`
-	Tag      string `json:"tag,omitempty"`
-	Zone     string `json:"zone,omitempty"`
+	Addr      string   `json:"addr,omitempty"`
+	Id        Id       `json:"id,omitempty"`
+	IsBusy    bool     `json:"is_busy,omitempty"`
+	InGroup   bool     `json:"in_group,omitempty"`
+	Machine   string   `json:"machine,omitempty"`
+	PingURL   string   `json:"ping_url"`
+	Port      string   `json:"port,omitempty"`
+	Replicas  uint32   `json:"replicas,omitempty"`
+	Rooms     []string `json:"rooms,omitempty"`
+	FreeSlots int      `json:"free_slots"`
+	UsedSlots int      `json:"used_slots"`
+	Tag       string   `json:"tag,omitempty"`
+	Zone      string   `json:"zone,omitempty"`
 }
@@ -10,11 +10,15 @@ type (
 		Record      bool   `json:"record,omitempty"`
 		RecordUser  string `json:"record_user,omitempty"`
 		PlayerIndex int    `json:"player_index"`
+		Spectator   bool   `json:"spectator,omitempty"`
 	}
 	GameStartUserResponse struct {
-		RoomId  string        `json:"roomId"`
-		Av      *AppVideoInfo `json:"av"`
-		KbMouse bool          `json:"kb_mouse"`
+		RoomId    string        `json:"roomId"`
+		Av        *AppVideoInfo `json:"av"`
+		KbMouse   bool          `json:"kb_mouse"`
+		Spectator bool          `json:"spectator,omitempty"`
+		Host      bool          `json:"host,omitempty"`
+		Layers    int           `json:"layers,omitempty"`
 	}
 	IceServer struct {
 		Urls       string `json:"urls,omitempty"`
@@ -22,9 +26,10 @@ type (
 		Credential string `json:"credential,omitempty"`
 	}
 	InitSessionUserResponse struct {
-		Ice   []IceServer `json:"ice"`
-		Games []AppMeta   `json:"games"`
-		Wid   string      `json:"wid"`
+		Ice    []IceServer `json:"ice"`
+		Games  []AppMeta   `json:"games"`
+		Wid    string      `json:"wid"`
+		Resume string      `json:"resume,omitempty"` // the token to resume the session after a disconnect
 	}
 	AppMeta struct {
 		Alias  string `json:"alias,omitempty"`
@@ -32,11 +37,51 @@ type (
 		System string `json:"system"`
 	}
 	WebrtcSignalUser struct {
-		Ice *string `json:"ice,omitempty"`
-		Sdp *string `json:"sdp,omitempty"`
+		Ice     *string `json:"ice,omitempty"`
+		Sdp     *string `json:"sdp,omitempty"`
+		Restart bool    `json:"restart,omitempty"` // ICE restart request
 	}
 	InitUserWebrtcStreamRequest struct {
-		Initiator bool   `json:"initiator"`
-		Sdp       string `json:"sdp,omitempty"`
+		Initiator bool     `json:"initiator"`
+		Sdp       string   `json:"sdp,omitempty"`
+		Codecs    []string `json:"codecs,omitempty"` // the supported video mime types
+	}
+
+	// KickUserUserRequest contains the id of a user to kick out of the room.
+	KickUserUserRequest string
+	// TransferHostUserRequest contains the id of a user to become the new host.
+	TransferHostUserRequest string
+	LockRoomUserRequest     bool
+	SaveSlotUserRequest     string
+	LoadSlotUserRequest     string
+	// VideoLayerUserRequest is the video layer to watch, -1 for auto.
+	VideoLayerUserRequest int
+	// RoomAudioUserRequest changes the audio of the room,
+	// the empty fields keep their values.
+	RoomAudioUserRequest struct {
+		// Gain is the audio gain in dB.
+		Gain      *float64 `json:"gain,omitempty"`
+		Mute      *bool    `json:"mute,omitempty"`
+		Normalize *bool    `json:"normalize,omitempty"`
+	}
+	VideoFilterUserRequest VideoFilterInfo
+	// SetCheatUserRequest enables or disables the cheat with the Index.
+	SetCheatUserRequest struct {
+		Index   int  `json:"index"`
+		Enabled bool `json:"enabled"`
+	}
+	// EjectDiskUserRequest opens (true) or closes (false) the disk tray.
+	EjectDiskUserRequest bool
+	// SetDiskUserRequest is the index of the disk to insert.
+	SetDiskUserRequest int
+	// SpeedUserRequest is the speed of the game: 0 (pause), 0.5, 1, 2 or 4.
+	SpeedUserRequest float64
+	// RewindUserRequest is the number of seconds to go back in time,
+	// 0 stops the rewind.
+	RewindUserRequest float64
+	// SetCoreOptionUserRequest changes the core option with the Key.
+	SetCoreOptionUserRequest struct {
+		Key   string `json:"key"`
+		Value string `json:"value"`
 	}
 )
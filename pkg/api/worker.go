@@ -19,6 +19,7 @@ type (
 		RecordUser  string
 		Game        string `json:"game"`
 		PlayerIndex int    `json:"player_index"`
+		Spectator   bool   `json:"spectator,omitempty"`
 	}
 	GameInfo struct {
 		Alias  string `json:"alias"`
@@ -30,9 +31,16 @@ type (
 	}
 	StartGameResponse struct {
 		Room
-		AV      *AppVideoInfo `json:"av"`
-		Record  bool          `json:"record"`
-		KbMouse bool          `json:"kb_mouse"`
+		AV        *AppVideoInfo `json:"av"`
+		Record    bool          `json:"record"`
+		KbMouse   bool          `json:"kb_mouse"`
+		Spectator bool          `json:"spectator,omitempty"`
+		Host      bool          `json:"host,omitempty"`
+		// Layers is the number of the video layers if there are more than one.
+		Layers int `json:"layers,omitempty"`
+		// Full is set when the room has no more places for the user role
+		// or it's locked by the host.
+		Full bool `json:"full,omitempty"`
 	}
 	RecordGameRequest struct {
 		StatefulRoom
@@ -43,16 +51,44 @@ type (
 	TerminateSessionRequest Stateful
 	WebrtcSignalRequest     struct {
 		Stateful
-		Sdp *string `json:"sdp,omitempty"`
-		Ice *string `json:"ice,omitempty"`
+		Sdp     *string `json:"sdp,omitempty"`
+		Ice     *string `json:"ice,omitempty"`
+		Restart bool    `json:"restart,omitempty"`
 	}
 	InitWebrtcStreamRequest struct {
 		// Stateful
-		Id        string `json:"id"`
-		Initiator bool   `json:"initiator"`
-		Sdp       string `json:"sdp,omitempty"`
+		Id        string   `json:"id"`
+		Initiator bool     `json:"initiator"`
+		Sdp       string   `json:"sdp,omitempty"`
+		Codecs    []string `json:"codecs,omitempty"` // the video mime types of the user
 	}
 	InitWebrtcStreamResponse string
+	RoomUsersRequest         StatefulRoom
+	RoomUsersResponse        []RoomUser
+	RoomUser                 struct {
+		Id    string `json:"id"`
+		Index int    `json:"index"`
+		Role  Role   `json:"role"`
+	}
+
+	// AppCrashInfo tells users that the app has crashed.
+	AppCrashInfo struct {
+		// Restarting is true when the app will be restarted from the last save.
+		Restarting bool `json:"restarting"`
+	}
+
+	// AppFrameInfo tells users the current frame of the app (netplay),
+	// so they could stamp their input with the frame numbers.
+	AppFrameInfo struct {
+		Frame uint64 `json:"frame"`
+		Fps   int    `json:"fps"`
+		Delay int    `json:"delay"`
+	}
+
+	// AppSpeedInfo tells users the new speed of the app, 0 is pause.
+	AppSpeedInfo struct {
+		Speed float64 `json:"speed"`
+	}
 
 	AppVideoInfo struct {
 		W    int     `json:"w"`
@@ -70,4 +106,169 @@ type (
 	PrevSessionInfo struct {
 		List []string
 	}
+
+	// KickUserRequest is a request of the host (Id) to remove the Target user from the room.
+	KickUserRequest struct {
+		StatefulRoom
+		Target string `json:"target"`
+	}
+	KickUserResponse string
+	// TransferHostRequest is a request of the host (Id) to pass the role to the Target user.
+	TransferHostRequest struct {
+		StatefulRoom
+		Target string `json:"target"`
+	}
+	TransferHostResponse string
+	LockRoomRequest      struct {
+		StatefulRoom
+		Locked bool `json:"locked"`
+	}
+	LockRoomResponse string
+	// HostChangedRequest tells that the user (Id) is the new host of the room.
+	HostChangedRequest StatefulRoom
+	// KickedRequest tells that the user (Id) has been kicked out of the room.
+	KickedRequest StatefulRoom
+	// SaveSlotRequest is a request to save the game into the Slot,
+	// the empty slot is the main save.
+	SaveSlotRequest struct {
+		StatefulRoom
+		Slot string `json:"slot"`
+	}
+	SaveSlotResponse string
+	LoadSlotRequest  struct {
+		StatefulRoom
+		Slot string `json:"slot"`
+	}
+	LoadSlotResponse  string
+	ListSlotsRequest  StatefulRoom
+	ListSlotsResponse []SlotInfo
+	// VideoLayerRequest is a request to watch the video Layer,
+	// -1 is for the automatic selection.
+	VideoLayerRequest struct {
+		StatefulRoom
+		Layer int `json:"layer"`
+	}
+	VideoLayerResponse int
+	// WhepTokenRequest is a request of the host to watch the room over WHEP.
+	WhepTokenRequest  StatefulRoom
+	WhepTokenResponse struct {
+		// Url is the WHEP endpoint of the room.
+		Url string `json:"url"`
+		// Token is the bearer token of the room viewers.
+		Token string `json:"token"`
+	}
+	// RoomAudioRequest is a request of the host to change the audio processing of the room.
+	RoomAudioRequest struct {
+		StatefulRoom
+		RoomAudioUserRequest
+	}
+	// VideoFilterRequest is a request of the host to change the video filter of the room.
+	VideoFilterRequest struct {
+		StatefulRoom
+		Filter VideoFilterInfo `json:"filter"`
+	}
+	VideoFilterResponse VideoFilterInfo
+	// VideoFilterInfo is the post-processing of the video before the encoder.
+	VideoFilterInfo struct {
+		// Scale is the integer nearest-neighbour upscale.
+		Scale int `json:"scale,omitempty"`
+		// Scanlines is the darkness (0-1) of the scanlines.
+		Scanlines float64 `json:"scanlines,omitempty"`
+		// Sharpen is the strength (0-1) of the sharpening.
+		Sharpen float64 `json:"sharpen,omitempty"`
+		// Width and Height is the fixed output resolution with the letterboxing.
+		Width  int `json:"width,omitempty"`
+		Height int `json:"height,omitempty"`
+	}
+	RoomAudioResponse struct {
+		Gain      float64 `json:"gain"`
+		Mute      bool    `json:"mute"`
+		Normalize bool    `json:"normalize"`
+	}
+	// CheatsRequest is a request to list the cheats of the game.
+	CheatsRequest  StatefulRoom
+	CheatsResponse []CheatInfo
+	// SetCheatRequest is a request of the host to enable or disable the cheat,
+	// the response is the updated list of the cheats.
+	SetCheatRequest struct {
+		StatefulRoom
+		SetCheatUserRequest
+	}
+	SetCheatResponse []CheatInfo
+	// DisksRequest is a request to list the disks of multi-disc games.
+	DisksRequest  StatefulRoom
+	DisksResponse struct {
+		// Ejected tells if the disk tray is open.
+		Ejected bool `json:"ejected"`
+		// Index is the current disk.
+		Index int      `json:"index"`
+		Disks []string `json:"disks"`
+	}
+	// EjectDiskRequest is a request of the host to open or close the disk tray.
+	EjectDiskRequest struct {
+		StatefulRoom
+		Ejected bool `json:"ejected"`
+	}
+	EjectDiskResponse DisksResponse
+	// SetDiskRequest is a request of the host to change the disk.
+	SetDiskRequest struct {
+		StatefulRoom
+		Index int `json:"index"`
+	}
+	SetDiskResponse DisksResponse
+	// SpeedRequest is a request of the host to pause, slow down
+	// or speed up the game, the response is the new speed.
+	SpeedRequest struct {
+		StatefulRoom
+		Speed float64 `json:"speed"`
+	}
+	SpeedResponse float64
+	// FrameAdvanceRequest is a request of the host to run one frame of the paused game.
+	FrameAdvanceRequest  StatefulRoom
+	FrameAdvanceResponse string
+	// RewindRequest is a request of the host to go back in time
+	// by the Seconds while the rewind button is held, 0 stops it.
+	RewindRequest struct {
+		StatefulRoom
+		Seconds float64 `json:"seconds"`
+	}
+	RewindResponse string
+	// CoreOptionsRequest is a request to list the options of the core.
+	CoreOptionsRequest  StatefulRoom
+	CoreOptionsResponse []CoreOptionInfo
+	// SetCoreOptionRequest is a request of the host to change the core option
+	// during the play, the response is the updated list of the options.
+	SetCoreOptionRequest struct {
+		StatefulRoom
+		SetCoreOptionUserRequest
+	}
+	SetCoreOptionResponse []CoreOptionInfo
 )
+
+// CoreOptionInfo is a setting of the core, the Value is one of the Values.
+type CoreOptionInfo struct {
+	Key     string   `json:"key"`
+	Desc    string   `json:"desc"`
+	Info    string   `json:"info,omitempty"`
+	Values  []string `json:"values"`
+	Default string   `json:"default"`
+	Value   string   `json:"value"`
+}
+
+// CheatInfo is a cheat code of the game, the Index is used to enable it.
+type CheatInfo struct {
+	Index   int    `json:"index"`
+	Desc    string `json:"desc"`
+	Code    string `json:"code"`
+	Enabled bool   `json:"enabled"`
+}
+
+// SlotInfo is a saved state of the game.
+type SlotInfo struct {
+	Slot string `json:"slot"`
+	Auto bool   `json:"auto,omitempty"`
+	// Time is the Unix time of the save in milliseconds.
+	Time int64 `json:"time"`
+	// Thumb is a screenshot of the game as PNG data URL.
+	Thumb string `json:"thumb,omitempty"`
+}
@@ -0,0 +1,167 @@
+package cloud
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+// Bundle is a set of all the save files of a game session
+// (the state, SRAM, the save directory of the core, etc.)
+// stored as a single file in the cloud.
+//
+// It's a ZIP archive of the files and a manifest.json file
+// with the version of the bundle format and the SHA-256 hashes
+// of the files, which are checked when the bundle is unpacked.
+type Bundle struct {
+	Created time.Time
+	Files   []BundleFile
+}
+
+type BundleFile struct {
+	// Name is a relative slash-separated path of the file.
+	Name string
+	Data []byte
+}
+
+const (
+	BundleExt     = ".bundle"
+	BundleVersion = 1
+
+	manifestName  = "manifest.json"
+	filesDir      = "files/"
+	maxBundleSize = 512 << 20
+)
+
+var (
+	ErrBundleVersion   = errors.New("unsupported bundle version")
+	ErrBundleIntegrity = errors.New("bundle integrity check failed")
+)
+
+type manifest struct {
+	Version int             `json:"version"`
+	Created int64           `json:"created"`
+	Files   []manifestEntry `json:"files"`
+}
+
+type manifestEntry struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	Sha256 string `json:"sha256"`
+}
+
+// Pack returns the bundle as a ZIP archive.
+func (b *Bundle) Pack() ([]byte, error) {
+	m := manifest{Version: BundleVersion, Created: b.Created.UnixMilli()}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range b.Files {
+		if !isValidPath(f.Name) {
+			return nil, fmt.Errorf("bad bundle file name: %v", f.Name)
+		}
+		w, err := zw.Create(filesDir + f.Name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(f.Data); err != nil {
+			return nil, err
+		}
+		m.Files = append(m.Files, manifestEntry{Name: f.Name, Size: int64(len(f.Data)), Sha256: hash(f.Data)})
+	}
+
+	w, err := zw.Create(manifestName)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.NewEncoder(w).Encode(m); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnpackBundle reads the bundle and checks the integrity of its files.
+func UnpackBundle(data []byte) (*Bundle, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	mf, ok := files[manifestName]
+	if !ok {
+		return nil, fmt.Errorf("%w: no manifest", ErrBundleIntegrity)
+	}
+	raw, err := readZipFile(mf, maxBundleSize)
+	if err != nil {
+		return nil, err
+	}
+	var m manifest
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrBundleIntegrity, err)
+	}
+	if m.Version < 1 || m.Version > BundleVersion {
+		return nil, fmt.Errorf("%w: %v", ErrBundleVersion, m.Version)
+	}
+
+	b := Bundle{Created: time.UnixMilli(m.Created)}
+	var total int64
+	for _, e := range m.Files {
+		if !isValidPath(e.Name) {
+			return nil, fmt.Errorf("%w: bad file name %v", ErrBundleIntegrity, e.Name)
+		}
+		f, ok := files[filesDir+e.Name]
+		if !ok {
+			return nil, fmt.Errorf("%w: no file %v", ErrBundleIntegrity, e.Name)
+		}
+		if total += e.Size; e.Size < 0 || total > maxBundleSize {
+			return nil, fmt.Errorf("%w: too big", ErrBundleIntegrity)
+		}
+		dat, err := readZipFile(f, e.Size)
+		if err != nil {
+			return nil, err
+		}
+		if int64(len(dat)) != e.Size || hash(dat) != e.Sha256 {
+			return nil, fmt.Errorf("%w: file %v", ErrBundleIntegrity, e.Name)
+		}
+		b.Files = append(b.Files, BundleFile{Name: e.Name, Data: dat})
+	}
+	return &b, nil
+}
+
+// readZipFile reads no more than limit+1 bytes of the file,
+// so bigger files are detected without reading them whole.
+func readZipFile(f *zip.File, limit int64) ([]byte, error) {
+	r, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = r.Close() }()
+	return io.ReadAll(io.LimitReader(r, limit+1))
+}
+
+func hash(data []byte) string { h := sha256.Sum256(data); return hex.EncodeToString(h[:]) }
+
+// isValidPath checks that the relative path doesn't point outside its root.
+func isValidPath(name string) bool {
+	return name != "" &&
+		!strings.Contains(name, `\`) &&
+		!path.IsAbs(name) &&
+		path.Clean(name) == name &&
+		name != "." && name != ".." && !strings.HasPrefix(name, "../")
+}
@@ -0,0 +1,108 @@
+package cloud
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBundle(t *testing.T) {
+	b := Bundle{
+		Created: time.UnixMilli(1700000000000),
+		Files: []BundleFile{
+			{Name: "state", Data: []byte{1, 2, 3}},
+			{Name: "sram", Data: []byte{}},
+			{Name: "dir/C/GAME.SAV", Data: bytes.Repeat([]byte{7}, 1000)},
+		},
+	}
+	data, err := b.Pack()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := UnpackBundle(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Created.Equal(b.Created) || len(got.Files) != len(b.Files) {
+		t.Fatalf("wrong bundle: %+v", got)
+	}
+	for i, f := range got.Files {
+		if f.Name != b.Files[i].Name || !bytes.Equal(f.Data, b.Files[i].Data) {
+			t.Errorf("wrong file: %v != %v", f.Name, b.Files[i].Name)
+		}
+	}
+}
+
+func TestBundleBadName(t *testing.T) {
+	for _, name := range []string{"", ".", "..", "../x", "/x", "a/../../x", `a\b`, "a//b"} {
+		b := Bundle{Files: []BundleFile{{Name: name}}}
+		if _, err := b.Pack(); err == nil {
+			t.Errorf("no error for %q", name)
+		}
+	}
+}
+
+func TestBundleIntegrity(t *testing.T) {
+	// a file with the wrong content
+	data := rawBundle(t, map[string]string{
+		manifestName:  `{"version":1,"files":[{"name":"state","size":1,"sha256":"00"}]}`,
+		"files/state": "x",
+	})
+	if _, err := UnpackBundle(data); !errors.Is(err, ErrBundleIntegrity) {
+		t.Errorf("wrong error: %v", err)
+	}
+
+	// a missing file
+	data = rawBundle(t, map[string]string{
+		manifestName: `{"version":1,"files":[{"name":"state","size":1,"sha256":"00"}]}`,
+	})
+	if _, err := UnpackBundle(data); !errors.Is(err, ErrBundleIntegrity) {
+		t.Errorf("wrong error: %v", err)
+	}
+
+	// no manifest
+	data = rawBundle(t, map[string]string{"files/state": "x"})
+	if _, err := UnpackBundle(data); !errors.Is(err, ErrBundleIntegrity) {
+		t.Errorf("wrong error: %v", err)
+	}
+
+	// a newer version
+	data = rawBundle(t, map[string]string{manifestName: `{"version":100}`})
+	if _, err := UnpackBundle(data); !errors.Is(err, ErrBundleVersion) {
+		t.Errorf("wrong error: %v", err)
+	}
+}
+
+func TestBundleEmpty(t *testing.T) {
+	data, err := (&Bundle{}).Pack()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := UnpackBundle(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(b.Files, []BundleFile(nil)) {
+		t.Errorf("wrong files: %v", b.Files)
+	}
+}
+
+func rawBundle(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, data := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = w.Write([]byte(data))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,67 @@
+package cloud
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/giongto35/cloud-game/v3/pkg/api"
+)
+
+// SendFn makes a blocking call to the coordinator.
+type SendFn func(t api.PT, data any) ([]byte, error)
+
+// CoordinatorStorage keeps files on the coordinator,
+// which stores them with its own storage provider.
+type CoordinatorStorage struct {
+	send SendFn
+}
+
+func NewCoordinatorStorage(send SendFn) *CoordinatorStorage { return &CoordinatorStorage{send: send} }
+
+func (c *CoordinatorStorage) Save(name string, data []byte, tags map[string]string) error {
+	_, err := c.call(api.StorageSave, api.StorageSaveRequest{Name: name, Data: data, Tags: tags})
+	return err
+}
+
+func (c *CoordinatorStorage) Load(name string) ([]byte, error) {
+	rs, err := c.call(api.StorageLoad, api.StorageLoadRequest(name))
+	if err != nil {
+		return nil, err
+	}
+	return rs.Data, nil
+}
+
+func (c *CoordinatorStorage) Has(name string) bool {
+	rs, err := c.call(api.StorageHas, api.StorageHasRequest(name))
+	return err == nil && rs.Has
+}
+
+func (c *CoordinatorStorage) Delete(name string) error {
+	_, err := c.call(api.StorageDelete, api.StorageDeleteRequest(name))
+	return err
+}
+
+func (c *CoordinatorStorage) List(prefix string) ([]string, error) {
+	rs, err := c.call(api.StorageList, api.StorageListRequest(prefix))
+	if err != nil {
+		return nil, err
+	}
+	return rs.Names, nil
+}
+
+func (c *CoordinatorStorage) call(t api.PT, rq any) (*api.StorageResponse, error) {
+	rs, err := api.UnwrapChecked[api.StorageResponse](c.send(t, rq))
+	if err != nil {
+		return nil, err
+	}
+	if rs == nil {
+		return nil, fmt.Errorf("bad %v response", t)
+	}
+	if rs.Err != "" {
+		if rs.Err == ErrNotFound.Error() {
+			return nil, ErrNotFound
+		}
+		return nil, errors.New(rs.Err)
+	}
+	return rs, nil
+}
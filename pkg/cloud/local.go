@@ -0,0 +1,98 @@
+package cloud
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LocalStorage keeps files in a local directory,
+// which may be a mounted network share (NFS, SMB) as well.
+// The tags of the files are not kept.
+type LocalStorage struct {
+	dir string
+}
+
+func NewLocalStorage(dir string) (*LocalStorage, error) {
+	if dir == "" {
+		return nil, errors.New("no storage path")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &LocalStorage{dir: dir}, nil
+}
+
+func (l *LocalStorage) Save(name string, data []byte, _ map[string]string) error {
+	path, err := l.path(name)
+	if err != nil {
+		return err
+	}
+	// write the whole file or nothing
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func (l *LocalStorage) Load(name string) ([]byte, error) {
+	path, err := l.path(name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+func (l *LocalStorage) Has(name string) bool {
+	path, err := l.path(name)
+	if err != nil {
+		return false
+	}
+	fi, err := os.Stat(path)
+	return err == nil && fi.Mode().IsRegular()
+}
+
+func (l *LocalStorage) Delete(name string) error {
+	path, err := l.path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (l *LocalStorage) List(prefix string) ([]string, error) {
+	entries, err := os.ReadDir(l.dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.Type().IsRegular() && strings.HasPrefix(name, prefix) && !strings.HasSuffix(name, ".tmp") {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// path returns the path of the file with the name
+// that shouldn't point outside the storage directory.
+func (l *LocalStorage) path(name string) (string, error) {
+	if !isValidName(name) {
+		return "", errors.New("bad file name: " + name)
+	}
+	return filepath.Join(l.dir, name), nil
+}
+
+func isValidName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, `/\`)
+}
@@ -89,3 +89,24 @@ func (s *S3Client) Has(name string) bool {
 	_, err := s.c.StatObject(context.Background(), s.bucket, name, minio.GetObjectOptions{})
 	return err == nil
 }
+
+func (s *S3Client) Delete(name string) error {
+	if s == nil || s.c == nil {
+		return errors.New("s3 client was not initialised")
+	}
+	return s.c.RemoveObject(context.Background(), s.bucket, name, minio.RemoveObjectOptions{})
+}
+
+func (s *S3Client) List(prefix string) ([]string, error) {
+	if s == nil || s.c == nil {
+		return nil, errors.New("s3 client was not initialised")
+	}
+	var names []string
+	for obj := range s.c.ListObjects(context.Background(), s.bucket, minio.ListObjectsOptions{Prefix: prefix}) {
+		if obj.Err != nil {
+			return nil, obj.Err
+		}
+		names = append(names, obj.Key)
+	}
+	return names, nil
+}
@@ -0,0 +1,42 @@
+package cloud
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/giongto35/cloud-game/v3/pkg/config"
+	"github.com/giongto35/cloud-game/v3/pkg/logger"
+)
+
+type Storage interface {
+	Save(name string, data []byte, tags map[string]string) (err error)
+	Load(name string) (data []byte, err error)
+	Has(name string) bool
+	Delete(name string) error
+	// List returns the names of all the files that start with the prefix.
+	List(prefix string) ([]string, error)
+}
+
+var ErrNotFound = errors.New("not found")
+
+// Store returns the storage of the provider from the config.
+// The send function is needed for the coordinator provider, it can be nil otherwise.
+func Store(conf config.Storage, send SendFn, log *logger.Logger) (Storage, error) {
+	var st Storage
+	var err error
+	switch conf.Provider {
+	case "s3":
+		st, err = NewS3Client(conf.S3Endpoint, conf.S3BucketName, conf.S3AccessKeyId, conf.S3SecretAccessKey, log)
+	case "local":
+		st, err = NewLocalStorage(conf.Path)
+	case "webdav":
+		st, err = NewWebDavClient(conf.WebDavUrl, conf.WebDavUser, conf.WebDavPassword)
+	case "coordinator":
+		if send == nil {
+			return nil, fmt.Errorf("no coordinator connection")
+		}
+		st = NewCoordinatorStorage(send)
+	default:
+	}
+	return st, err
+}
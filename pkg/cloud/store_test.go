@@ -0,0 +1,194 @@
+package cloud
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/giongto35/cloud-game/v3/pkg/api"
+)
+
+func TestLocalStorage(t *testing.T) {
+	st, err := NewLocalStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	testStorage(t, st)
+
+	if err := st.Save("../escape", []byte{1}, nil); err == nil {
+		t.Errorf("no error for a file outside the storage")
+	}
+}
+
+func TestWebDavStorage(t *testing.T) {
+	dav := newDavStandIn()
+	srv := httptest.NewServer(dav)
+	defer srv.Close()
+
+	st, err := NewWebDavClient(srv.URL+"/saves", "user", "pass")
+	if err != nil {
+		t.Fatal(err)
+	}
+	testStorage(t, st)
+
+	st.password = "wrong"
+	if st.Has("a.dat") {
+		t.Errorf("no auth check")
+	}
+}
+
+func TestCoordinatorStorage(t *testing.T) {
+	backend, err := NewLocalStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := NewCoordinatorStorage(coordinatorStandIn(backend))
+	testStorage(t, st)
+}
+
+func testStorage(t *testing.T, st Storage) {
+	t.Helper()
+
+	files := map[string][]byte{
+		"a.dat":  {1, 2, 3},
+		"a.srm":  {4, 5},
+		"b.dat":  {6},
+		"empty0": {},
+	}
+	for name, data := range files {
+		if err := st.Save(name, data, map[string]string{"uid": "test"}); err != nil {
+			t.Fatalf("save %v: %v", name, err)
+		}
+	}
+
+	for name, data := range files {
+		if !st.Has(name) {
+			t.Errorf("no %v", name)
+		}
+		d, err := st.Load(name)
+		if err != nil {
+			t.Fatalf("load %v: %v", name, err)
+		}
+		if !bytes.Equal(d, data) {
+			t.Errorf("wrong %v data: %v != %v", name, d, data)
+		}
+	}
+
+	if st.Has("c.dat") {
+		t.Errorf("has a non-existent file")
+	}
+	if _, err := st.Load("c.dat"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("wrong error of a non-existent file: %v", err)
+	}
+
+	names, err := st.List("a.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(names)
+	if !slices.Equal(names, []string{"a.dat", "a.srm"}) {
+		t.Errorf("wrong list: %v", names)
+	}
+
+	if err := st.Delete("a.dat"); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.Delete("a.dat"); err != nil {
+		t.Errorf("no idempotent delete: %v", err)
+	}
+	if st.Has("a.dat") {
+		t.Errorf("the file hasn't been deleted")
+	}
+	names, _ = st.List("")
+	if len(names) != len(files)-1 {
+		t.Errorf("wrong list after delete: %v", names)
+	}
+}
+
+// davStandIn is a minimal WebDAV server of a single collection.
+type davStandIn struct {
+	mu    sync.Mutex
+	files map[string][]byte
+}
+
+func newDavStandIn() *davStandIn { return &davStandIn{files: make(map[string][]byte)} }
+
+func (d *davStandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if u, p, ok := r.BasicAuth(); !ok || u != "user" || p != "pass" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	name := strings.TrimPrefix(r.URL.Path, "/saves/")
+	switch r.Method {
+	case http.MethodPut:
+		data, _ := io.ReadAll(r.Body)
+		d.files[name] = data
+		w.WriteHeader(http.StatusCreated)
+	case http.MethodGet, http.MethodHead:
+		data, ok := d.files[name]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(data)
+	case http.MethodDelete:
+		if _, ok := d.files[name]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		delete(d.files, name)
+		w.WriteHeader(http.StatusNoContent)
+	case "PROPFIND":
+		var b strings.Builder
+		b.WriteString(`<?xml version="1.0" encoding="utf-8"?><D:multistatus xmlns:D="DAV:">`)
+		b.WriteString(`<D:response><D:href>/saves/</D:href><D:propstat><D:prop><D:resourcetype><D:collection/></D:resourcetype></D:prop></D:propstat></D:response>`)
+		for name := range d.files {
+			fmt.Fprintf(&b, `<D:response><D:href>/saves/%v</D:href><D:propstat><D:prop><D:resourcetype/></D:prop></D:propstat></D:response>`, name)
+		}
+		b.WriteString(`</D:multistatus>`)
+		w.WriteHeader(http.StatusMultiStatus)
+		_, _ = w.Write([]byte(b.String()))
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// coordinatorStandIn serves the storage requests like the coordinator does.
+func coordinatorStandIn(st Storage) SendFn {
+	return func(t api.PT, data any) ([]byte, error) {
+		// the same JSON round trip as over the network
+		raw, err := json.Marshal(data)
+		if err != nil {
+			return nil, err
+		}
+		var rs api.StorageResponse
+		switch t {
+		case api.StorageSave:
+			rq := api.Unwrap[api.StorageSaveRequest](raw)
+			err = st.Save(rq.Name, rq.Data, rq.Tags)
+		case api.StorageLoad:
+			rs.Data, err = st.Load(string(*api.Unwrap[api.StorageLoadRequest](raw)))
+		case api.StorageHas:
+			rs.Has = st.Has(string(*api.Unwrap[api.StorageHasRequest](raw)))
+		case api.StorageDelete:
+			err = st.Delete(string(*api.Unwrap[api.StorageDeleteRequest](raw)))
+		case api.StorageList:
+			rs.Names, err = st.List(string(*api.Unwrap[api.StorageListRequest](raw)))
+		}
+		if err != nil {
+			rs.Err = err.Error()
+		}
+		return json.Marshal(rs)
+	}
+}
@@ -0,0 +1,152 @@
+package cloud
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// WebDavClient keeps files in a collection of a WebDAV server.
+// The tags of the files are not kept.
+type WebDavClient struct {
+	url      *url.URL
+	user     string
+	password string
+	c        *http.Client
+}
+
+const propfindBody = `<?xml version="1.0" encoding="utf-8"?><propfind xmlns="DAV:"><prop><resourcetype/></prop></propfind>`
+
+func NewWebDavClient(addr, user, password string) (*WebDavClient, error) {
+	u, err := url.Parse(addr)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("bad WebDAV url: %v", addr)
+	}
+	if !strings.HasSuffix(u.Path, "/") {
+		u.Path += "/"
+	}
+	return &WebDavClient{url: u, user: user, password: password, c: &http.Client{Timeout: 30 * time.Second}}, nil
+}
+
+func (w *WebDavClient) Save(name string, data []byte, _ map[string]string) error {
+	rs, err := w.do(http.MethodPut, name, bytes.NewReader(data), nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rs.Body.Close() }()
+	switch rs.StatusCode {
+	case http.StatusOK, http.StatusCreated, http.StatusNoContent:
+		return nil
+	default:
+		return fmt.Errorf("webdav save: %v", rs.Status)
+	}
+}
+
+func (w *WebDavClient) Load(name string) ([]byte, error) {
+	rs, err := w.do(http.MethodGet, name, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rs.Body.Close() }()
+	switch rs.StatusCode {
+	case http.StatusOK:
+		return io.ReadAll(rs.Body)
+	case http.StatusNotFound:
+		return nil, ErrNotFound
+	default:
+		return nil, fmt.Errorf("webdav load: %v", rs.Status)
+	}
+}
+
+func (w *WebDavClient) Has(name string) bool {
+	rs, err := w.do(http.MethodHead, name, nil, nil)
+	if err != nil {
+		return false
+	}
+	_ = rs.Body.Close()
+	return rs.StatusCode == http.StatusOK
+}
+
+func (w *WebDavClient) Delete(name string) error {
+	rs, err := w.do(http.MethodDelete, name, nil, nil)
+	if err != nil {
+		return err
+	}
+	_ = rs.Body.Close()
+	switch rs.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		return nil
+	default:
+		return fmt.Errorf("webdav delete: %v", rs.Status)
+	}
+}
+
+func (w *WebDavClient) List(prefix string) ([]string, error) {
+	rs, err := w.do("PROPFIND", "", strings.NewReader(propfindBody), map[string]string{
+		"Depth":        "1",
+		"Content-Type": "application/xml",
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rs.Body.Close() }()
+	if rs.StatusCode != http.StatusMultiStatus {
+		return nil, fmt.Errorf("webdav list: %v", rs.Status)
+	}
+
+	var ms struct {
+		Responses []struct {
+			Href       string    `xml:"href"`
+			Collection *struct{} `xml:"propstat>prop>resourcetype>collection"`
+		} `xml:"response"`
+	}
+	if err := xml.NewDecoder(rs.Body).Decode(&ms); err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, r := range ms.Responses {
+		if r.Collection != nil {
+			continue
+		}
+		href, err := url.PathUnescape(r.Href)
+		if err != nil {
+			continue
+		}
+		if name := path.Base(href); strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+func (w *WebDavClient) do(method string, name string, body io.Reader, headers map[string]string) (*http.Response, error) {
+	if name != "" && !isValidName(name) {
+		return nil, errors.New("bad file name: " + name)
+	}
+	u := w.url
+	if name != "" {
+		u = w.url.JoinPath(name)
+	}
+	rq, err := http.NewRequest(method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	if w.user != "" {
+		rq.SetBasicAuth(w.user, w.password)
+	}
+	for k, v := range headers {
+		rq.Header.Set(k, v)
+	}
+	return w.c.Do(rq)
+}
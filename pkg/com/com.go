@@ -19,6 +19,7 @@ func NewNetMap[K stringer, T NetClient[K]]() NetMap[K, T] {
 }
 
 func (m *NetMap[K, T]) Add(client T) bool         { return m.Put(client.Id(), client) }
+func (m *NetMap[K, T]) Contains(client T) bool    { return m.Map.Has(client.Id()) }
 func (m *NetMap[K, T]) Empty() bool               { return m.Map.Len() == 0 }
 func (m *NetMap[K, T]) Remove(client T)           { m.Map.Remove(client.Id()) }
 func (m *NetMap[K, T]) RemoveL(client T) int      { return m.Map.RemoveL(client.Id()) }
@@ -56,14 +57,19 @@ func NewConnection[T ~uint8, P Packet[T], X any, P2 Packet2[X]](conn *Connection
 
 func (c *SocketClient[T, P, _, _]) ProcessPackets(fn func(in P) error) chan struct{} {
 	c.rpc = NewRPC[T, P]()
-	c.rpc.Handler = func(p P) {
+	// the handlers run apart from the reader of the connection,
+	// so they could wait for the responses of their own calls
+	in := newQueue[P]()
+	c.rpc.Handler = in.push
+	c.sock.conn.SetMessageHandler(c.handleMessage) // 1st handler
+	done := c.sock.conn.Listen()
+	go in.run(done, func(p P) {
 		c.log.Debug().Str(logger.DirectionField, logger.MarkIn).Msgf("%v", p.GetType())
 		if err := fn(p); err != nil { // 3rd handler
 			c.log.Error().Err(err).Send()
 		}
-	}
-	c.sock.conn.SetMessageHandler(c.handleMessage) // 1st handler
-	return c.sock.conn.Listen()
+	})
+	return done
 }
 
 func (c *SocketClient[T, P, X, P2]) SetErrorHandler(h func(error)) { c.sock.conn.SetErrorHandler(h) }
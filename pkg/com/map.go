@@ -125,3 +125,20 @@ func (m *Map[K, V]) Values() iter.Seq[V] {
 		}
 	}
 }
+
+// Keys returns an iterator for keys only.
+//
+// Warning: This holds a Read Lock (RLock) during iteration.
+// Do not call Put/Remove on this map inside the loop (Deadlock).
+func (m *Map[K, V]) Keys() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		m.mu.RLock()
+		defer m.mu.RUnlock()
+
+		for k := range m.m {
+			if !yield(k) {
+				return
+			}
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"math/rand/v2"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"sync"
 	"testing"
@@ -223,3 +224,73 @@ func getFreePort() (port int, err error) {
 	}
 	return
 }
+
+func TestCallFromHandler(t *testing.T) {
+	type client = SocketClient[uint8, TestIn, TestOut, *TestOut]
+
+	conns := make(chan *Connection, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := (&Server{}).Connect(w, r)
+		if err != nil {
+			t.Errorf("no server connection, %v", err)
+			return
+		}
+		conns <- conn
+	}))
+	defer srv.Close()
+
+	conn, err := (&Client{}).Connect(url.URL{Scheme: "ws", Host: srv.Listener.Addr().String()})
+	if err != nil {
+		t.Fatalf("no client connection, %v", err)
+	}
+	cl := NewConnection[uint8, TestIn, TestOut, *TestOut](conn, NilUid, logger.Default())
+	sv := NewConnection[uint8, TestIn, TestOut, *TestOut](<-conns, NilUid, logger.Default())
+
+	// the server handler calls the client back before the answer
+	svDone := sv.ProcessPackets(func(in TestIn) error {
+		v, err := sv.Send(2, "ping")
+		if err != nil {
+			return err
+		}
+		sv.Route(in, &TestOut{Payload: json.RawMessage(v)})
+		return nil
+	})
+	clDone := cl.ProcessPackets(func(in TestIn) error {
+		cl.Route(in, &TestOut{Payload: "pong"})
+		return nil
+	})
+	cl.rpc.CallTimeout = time.Second
+
+	v, err := cl.Send(1, "call")
+	if err = checkCall(v, err, "pong"); err != nil {
+		t.Errorf("wrong call, %v", err)
+	}
+
+	cl.Disconnect()
+	<-clDone
+	<-svDone
+}
+
+func TestQueue(t *testing.T) {
+	q := newQueue[int]()
+	done := make(chan struct{})
+	var out []int
+	ran := make(chan struct{})
+	go func() {
+		q.run(done, func(v int) { out = append(out, v) })
+		close(ran)
+	}()
+	for i := range 100 {
+		q.push(i)
+	}
+	close(done)
+	<-ran
+	if len(out) != 100 {
+		t.Fatalf("lost values: %v", len(out))
+	}
+	for i, v := range out {
+		if v != i {
+			t.Fatalf("wrong order: %v at %v", v, i)
+		}
+	}
+}
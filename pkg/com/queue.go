@@ -0,0 +1,56 @@
+package com
+
+import "sync"
+
+// queue keeps the incoming packets in order for a separate handler goroutine,
+// so the handlers could make blocking calls over the same connection
+// while the reader goroutine delivers the responses of these calls.
+type queue[T any] struct {
+	items []T
+	mu    sync.Mutex
+	ready chan struct{}
+}
+
+func newQueue[T any]() *queue[T] { return &queue[T]{ready: make(chan struct{}, 1)} }
+
+func (q *queue[T]) push(v T) {
+	q.mu.Lock()
+	q.items = append(q.items, v)
+	q.mu.Unlock()
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
+
+func (q *queue[T]) pop() (v T, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.items) == 0 {
+		return v, false
+	}
+	v = q.items[0]
+	var zero T
+	q.items[0] = zero
+	q.items = q.items[1:]
+	return v, true
+}
+
+// run calls fn for each pushed value until done,
+// the values pushed before done are handled as well.
+func (q *queue[T]) run(done <-chan struct{}, fn func(T)) {
+	for {
+		stop := false
+		select {
+		case <-q.ready:
+		case <-done:
+			stop = true
+		}
+		for v, ok := q.pop(); ok; v, ok = q.pop() {
+			fn(v)
+		}
+		if stop {
+			return
+		}
+	}
+}
@@ -23,6 +23,9 @@ type Coordinator struct {
 	}
 	Selector string
 	Server   Server
+	// Storage is a shared storage of the workers
+	// with the coordinator storage provider.
+	Storage Storage
 }
 
 // Analytics is optional Google Analytics
@@ -15,8 +15,42 @@ type Emulator struct {
 	LocalPath        string
 	Libretro         LibretroConfig
 	AutosaveSec      int
+	AutosaveHistory  int
+	SaveSlots        int
 	SkipLateFrames   bool
 	LogDroppedFrames bool
+	Sandbox          Sandbox
+	Netplay          Netplay
+	Rewind           Rewind
+}
+
+// Sandbox contains params for running emulators
+// in separate supervised processes.
+type Sandbox struct {
+	Enabled     bool
+	Restart     bool
+	MaxRestarts int
+}
+
+// Netplay contains params of the frame-synchronized
+// input of remote players.
+type Netplay struct {
+	Enabled bool
+	// InputDelay is the number of frames all inputs are delayed by.
+	InputDelay int
+	// Rollback is the max number of frames the emulator
+	// may go back in time for late inputs (0 disables it).
+	Rollback int
+}
+
+// Rewind contains params of going back in time
+// with the in-memory save states of the last frames.
+type Rewind struct {
+	Enabled bool
+	// Interval is the number of frames between the saved states.
+	Interval int
+	// Memory is the max size of the saved states of a game in MB.
+	Memory int
 }
 
 type LibretroConfig struct {
@@ -73,6 +107,7 @@ type LibretroCoreConfig struct {
 	AltRepo         bool
 	AutoGlContext   bool // hack: keep it here to pass it down the emulator
 	CoreAspectRatio bool
+	Filter          VideoFilter
 	Folder          string
 	Hacks           []string
 	Height          int
@@ -83,6 +118,7 @@ type LibretroCoreConfig struct {
 	NonBlockingSave bool
 	Options         map[string]string
 	Options4rom     map[string]map[string]string // <(^_^)>
+	RewindMemory    int
 	Roms            []string
 	SaveStateFs     string
 	Scale           float64
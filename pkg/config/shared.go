@@ -49,6 +49,7 @@ type Recording struct {
 	Name    string
 	Folder  string
 	Zip     bool
+	Movie   bool
 }
 
 func (s *Server) WithFlags() {
@@ -1,6 +1,7 @@
 package config
 
 type Webrtc struct {
+	CongestionControl          bool
 	DisableDefaultInterceptors bool
 	DtlsRole                   byte
 	IceServers                 []IceServer
@@ -15,6 +16,7 @@ type Webrtc struct {
 	IncludeLoopbackCandidate bool
 	SinglePort               int
 	LogLevel                 int
+	ResumeTimeout            int
 }
 
 type IceServer struct {
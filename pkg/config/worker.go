@@ -1,6 +1,7 @@
 package config
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"net"
@@ -28,6 +29,10 @@ type Storage struct {
 	S3BucketName      string
 	S3AccessKeyId     string
 	S3SecretAccessKey string
+	Path              string
+	WebDavUrl         string
+	WebDavUser        string
+	WebDavPassword    string
 }
 
 type Worker struct {
@@ -41,8 +46,17 @@ type Worker struct {
 		Secure             bool
 		Zone               string
 	}
+	Room struct {
+		MaxPlayers    int
+		MaxSpectators int
+	}
 	Server Server
+	Slots  int
 	Tag    string
+	Whep   struct {
+		Enabled bool
+		Secret  string
+	}
 }
 
 type Encoder struct {
@@ -52,11 +66,25 @@ type Encoder struct {
 
 type Audio struct {
 	Frames    []float32
+	Gain      float64
+	Normalize struct {
+		Enabled bool
+		Target  float64
+	}
+	Opus struct {
+		Bitrate    int
+		Complexity int
+		DTX        bool
+		FEC        bool
+		PacketLoss int
+	}
 	Resampler int
 }
 
 type Video struct {
 	Codec            string
+	Codecs           []string
+	Filter           VideoFilter
 	KeyframeInterval int
 	Threads          int
 	H264             struct {
@@ -75,6 +103,19 @@ type Video struct {
 		TileColumns int
 		Tune        string
 	}
+	Av1 struct {
+		Bitrate     uint
+		CpuUsed     int
+		TileColumns int
+		Tune        string
+	}
+	Adaptive struct {
+		Enabled    bool
+		MinBitrate int
+		MaxBitrate int
+		Degrade    bool
+	}
+	Layers []float64
 }
 
 // allows custom config path
@@ -87,6 +128,9 @@ func NewWorkerConfig() (conf WorkerConfig, paths []string) {
 	}
 	conf.expandSpecialTags()
 	conf.fixValues()
+	if err := conf.validate(); err != nil {
+		panic(err)
+	}
 	return
 }
 
@@ -126,6 +170,24 @@ func (c *WorkerConfig) fixValues() {
 	}
 }
 
+// validate checks that the enabled features can work together.
+func (c *WorkerConfig) validate() error {
+	if c.Emulator.Netplay.Enabled {
+		// both change the emulated frames outside the frame-synchronized input
+		if c.Emulator.Rewind.Enabled {
+			return errors.New("emulator.rewind can't be used with emulator.netplay")
+		}
+		if c.Recording.Movie {
+			return errors.New("recording.movie can't be used with emulator.netplay")
+		}
+	}
+	// the sandboxed emulators have no connection to the coordinator
+	if c.Emulator.Sandbox.Enabled && c.Storage.Provider == "coordinator" {
+		return errors.New("the coordinator storage.provider can't be used with emulator.sandbox")
+	}
+	return nil
+}
+
 // GetAddr returns defined in the config server address.
 func (w *Worker) GetAddr() string { return w.Server.GetAddr() }
 
@@ -153,7 +215,28 @@ func (w *Worker) GetPingAddr(address string) url.URL {
 	return pingURL
 }
 
+// GetWhepAddr returns exposed to clients WHEP endpoint address of the room.
+func (w *Worker) GetWhepAddr(address string, rid string) url.URL {
+	u := w.GetPingAddr(address)
+	u.Path = "/whep/" + rid
+	return u
+}
+
 func (w *Worker) GetPort(address string) string {
 	_, port, _ := net.SplitHostPort(address)
 	return port
 }
+
+// VideoFilter is the post-processing of the video frames before the encoder.
+type VideoFilter struct {
+	// Scale is the integer nearest-neighbour upscale of the frames.
+	Scale int
+	// Scanlines is the darkness (0-1) of the CRT-style scanlines.
+	Scanlines float64
+	// Sharpen is the strength (0-1) of the sharpening.
+	Sharpen float64
+	// Width and Height is the fixed output resolution,
+	// the frames are letterboxed into it.
+	Width  int
+	Height int
+}
@@ -0,0 +1,27 @@
+package config
+
+import "testing"
+
+func TestWorkerConfigValidate(t *testing.T) {
+	var c WorkerConfig
+	if err := c.validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	c.Emulator.Netplay.Enabled = true
+	c.Emulator.Rewind.Enabled = true
+	if err := c.validate(); err == nil {
+		t.Errorf("no error of rewind with netplay")
+	}
+	c.Emulator.Rewind.Enabled = false
+	c.Recording.Movie = true
+	if err := c.validate(); err == nil {
+		t.Errorf("no error of movies with netplay")
+	}
+	c.Emulator.Netplay.Enabled = false
+	c.Recording.Movie = false
+	c.Emulator.Sandbox.Enabled = true
+	c.Storage.Provider = "coordinator"
+	if err := c.validate(); err == nil {
+		t.Errorf("no error of the coordinator storage with sandbox")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"net/url"
 
 	"github.com/giongto35/cloud-game/v3/pkg/api"
+	"github.com/giongto35/cloud-game/v3/pkg/cloud"
 	"github.com/giongto35/cloud-game/v3/pkg/com"
 	"github.com/giongto35/cloud-game/v3/pkg/config"
 	"github.com/giongto35/cloud-game/v3/pkg/logger"
@@ -19,18 +20,26 @@ type Connection interface {
 
 	Send(api.PT, any) ([]byte, error)
 	Notify(api.PT, any)
+	Route(api.In[com.Uid], *api.Out)
 }
 
 type Hub struct {
-	conf    config.CoordinatorConfig
-	log     *logger.Logger
-	users   com.NetMap[com.Uid, *User]
-	workers com.NetMap[com.Uid, *Worker]
+	conf     config.CoordinatorConfig
+	log      *logger.Logger
+	storage  cloud.Storage // a shared storage of the workers
+	users    com.NetMap[com.Uid, *User]
+	workers  com.NetMap[com.Uid, *Worker]
+	sessions com.Map[string, resumable] // the sessions of disconnected users by their resume tokens
 }
 
 func NewHub(conf config.CoordinatorConfig, log *logger.Logger) *Hub {
+	st, err := cloud.Store(conf.Coordinator.Storage, nil, log)
+	if err != nil {
+		log.Warn().Err(err).Msgf("worker storage fail, using no storage")
+	}
 	return &Hub{
 		conf:    conf,
+		storage: st,
 		users:   com.NewNetMap[com.Uid, *User](),
 		workers: com.NewNetMap[com.Uid, *Worker](),
 		log:     log,
@@ -56,12 +65,26 @@ func (h *Hub) handleUserConnection() http.HandlerFunc {
 			return
 		}
 
-		user := NewUser(conn, log)
-		defer h.users.RemoveDisconnect(user)
-		done := user.HandleRequests(h, h.conf)
 		params := r.URL.Query()
 
-		worker := h.findWorkerFor(user, params, h.log.Extend(h.log.With().Str("cid", user.Id().Short())))
+		// a reconnected user continues its old session on the same worker
+		id, worker := com.NilUid, (*Worker)(nil)
+		if s, ok := h.resumeSession(params.Get(api.ResumeParam)); ok {
+			if worker = h.workers.Find(s.wid.String()); worker != nil {
+				id = s.id
+			}
+		}
+
+		user := NewUser(conn, id, log)
+		defer func() {
+			h.users.RemoveDisconnect(user)
+			h.keepSession(user)
+		}()
+		done := user.HandleRequests(h, h.conf)
+
+		if worker == nil {
+			worker = h.findWorkerFor(user, params, h.log.Extend(h.log.With().Str("cid", user.Id().Short())))
+		}
 		if worker == nil {
 			user.Notify(api.ErrNoFreeSlots, "")
 			h.log.Info().Msg("no free workers")
@@ -70,7 +93,7 @@ func (h *Hub) handleUserConnection() http.HandlerFunc {
 
 		// Link the user to the selected worker. Slot reservation is handled later
 		// on game start; this keeps connections lightweight and lets deep-link
-		// joins share a worker without consuming its single game slot.
+		// joins share a worker room without consuming any of its game slots.
 		user.w = worker
 
 		h.users.Add(user)
@@ -81,6 +104,9 @@ func (h *Hub) handleUserConnection() http.HandlerFunc {
 			list[i] = api.AppMeta{Alias: apps[i].Alias, Title: apps[i].Name, System: apps[i].System}
 		}
 
+		if h.conf.Webrtc.ResumeTimeout > 0 {
+			user.resume = newResumeToken()
+		}
 		user.InitSession(worker.Id().String(), h.conf.Webrtc.IceServers, list)
 		log.Info().Str(logger.DirectionField, logger.MarkPlus).Msgf("user %s", user.Id())
 		<-done
@@ -141,7 +167,7 @@ func (h *Hub) handleWorkerConnection() http.HandlerFunc {
 
 		worker := NewWorker(conn, *handshake, log)
 		defer h.workers.RemoveDisconnect(worker)
-		done := worker.HandleRequests(&h.users)
+		done := worker.HandleRequests(&h.users, h.storage)
 		h.workers.Add(worker)
 		log.Info().
 			Str(logger.DirectionField, logger.MarkPlus).
@@ -153,18 +179,21 @@ func (h *Hub) handleWorkerConnection() http.HandlerFunc {
 func (h *Hub) GetServerList() (r []api.Server) {
 	debug := h.conf.Coordinator.Debug
 	for w := range h.workers.Values() {
+		used := w.UsedSlots()
 		server := api.Server{
-			Addr:    w.Addr,
-			Id:      w.Id(),
-			IsBusy:  !w.HasSlot(),
-			Machine: string(w.Id().Machine()),
-			PingURL: w.PingServer,
-			Port:    w.Port,
-			Tag:     w.Tag,
-			Zone:    w.Zone,
+			Addr:      w.Addr,
+			Id:        w.Id(),
+			IsBusy:    !w.HasSlot(),
+			Machine:   string(w.Id().Machine()),
+			PingURL:   w.PingServer,
+			Port:      w.Port,
+			FreeSlots: int(w.Capacity() - used),
+			UsedSlots: int(used),
+			Tag:       w.Tag,
+			Zone:      w.Zone,
 		}
 		if debug {
-			server.Room = w.RoomId
+			server.Rooms = w.Rooms()
 		}
 		r = append(r, server)
 	}
@@ -235,9 +264,9 @@ func (h *Hub) findWorkerByRoom(id string, deepId string, region string) *Worker
 	// if there is zone param, we need to ensure the worker in that zone,
 	// if not we consider the room is missing
 	w, _ := h.workers.FindBy(func(w *Worker) bool {
-		matchId := w.RoomId == id
+		matchId := w.HasRoom(id)
 		if !matchId && deepId != "" {
-			matchId = w.RoomId == deepId
+			matchId = w.HasRoom(deepId)
 		}
 		return matchId && w.In(region)
 	})
@@ -0,0 +1,43 @@
+package coordinator
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/giongto35/cloud-game/v3/pkg/com"
+)
+
+// resumable is the session of a disconnected user,
+// the new connection with its token takes the same user id and worker,
+// so the worker could reattach the user to its room.
+type resumable struct {
+	id  com.Uid
+	wid com.Uid
+}
+
+func newResumeToken() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// keepSession keeps the session of the disconnected user for a while.
+func (h *Hub) keepSession(u *User) {
+	timeout := time.Duration(h.conf.Webrtc.ResumeTimeout) * time.Second
+	if timeout <= 0 || u.resume == "" || u.w == nil {
+		return
+	}
+	token := u.resume
+	h.sessions.Put(token, resumable{id: u.Id(), wid: u.w.Id()})
+	time.AfterFunc(timeout, func() { h.sessions.Remove(token) })
+}
+
+// resumeSession returns the kept session with the token, once.
+func (h *Hub) resumeSession(token string) (resumable, bool) {
+	if token == "" {
+		return resumable{}, false
+	}
+	s := h.sessions.Pop(token)
+	return s, s.id != com.NilUid
+}
@@ -9,16 +9,19 @@ import (
 
 type User struct {
 	Connection
-	w   *Worker // linked worker
-	log *logger.Logger
+	w      *Worker // linked worker
+	room   string  // the id of the worker room the user is in
+	resume string  // the token to resume the session after a disconnect
+	log    *logger.Logger
 }
 
 type HasServerInfo interface {
 	GetServerList() []api.Server
 }
 
-func NewUser(sock *com.Connection, log *logger.Logger) *User {
-	conn := com.NewConnection[api.PT, api.In[com.Uid], api.Out, *api.Out](sock, com.NewUid(), log)
+// NewUser returns a new user with the id or a new one if the id is nil.
+func NewUser(sock *com.Connection, id com.Uid, log *logger.Logger) *User {
+	conn := com.NewConnection[api.PT, api.In[com.Uid], api.Out, *api.Out](sock, id, log)
 	return &User{
 		Connection: conn,
 		log: log.Extend(log.With().
@@ -58,10 +61,52 @@ func (u *User) HandleRequests(info HasServerInfo, conf config.CoordinatorConfig)
 			err = u.HandleSaveGame()
 		case api.LoadGame:
 			err = u.HandleLoadGame()
+		case api.SaveSlot:
+			err = api.DoE(x, u.HandleSaveSlot)
+		case api.LoadSlot:
+			err = api.DoE(x, u.HandleLoadSlot)
+		case api.ListSlots:
+			err = u.HandleListSlots()
 		case api.ChangePlayer:
 			err = api.Do(x, u.HandleChangePlayer)
+		case api.VideoLayer:
+			err = api.DoE(x, u.HandleVideoLayer)
 		case api.ResetGame:
 			err = api.Do(x, u.HandleResetGame)
+		case api.RoomUsers:
+			err = u.HandleRoomUsers()
+		case api.KickUser:
+			err = api.DoE(x, u.HandleKickUser)
+		case api.TransferHost:
+			err = api.DoE(x, u.HandleTransferHost)
+		case api.LockRoom:
+			err = api.DoE(x, u.HandleLockRoom)
+		case api.WhepToken:
+			err = u.HandleWhepToken()
+		case api.RoomAudio:
+			err = api.DoE(x, u.HandleRoomAudio)
+		case api.VideoFilter:
+			err = api.DoE(x, u.HandleVideoFilter)
+		case api.Cheats:
+			err = u.HandleCheats()
+		case api.SetCheat:
+			err = api.DoE(x, u.HandleSetCheat)
+		case api.Disks:
+			err = u.HandleDisks()
+		case api.EjectDisk:
+			err = api.DoE(x, u.HandleEjectDisk)
+		case api.SetDisk:
+			err = api.DoE(x, u.HandleSetDisk)
+		case api.Speed:
+			err = api.DoE(x, u.HandleSpeed)
+		case api.FrameAdvance:
+			err = u.HandleFrameAdvance()
+		case api.Rewind:
+			err = api.DoE(x, u.HandleRewind)
+		case api.CoreOptions:
+			err = u.HandleCoreOptions()
+		case api.SetCoreOption:
+			err = api.DoE(x, u.HandleSetCoreOption)
 		case api.RecordGame:
 			if !conf.Recording.Enabled {
 				return api.ErrForbidden
@@ -20,9 +20,10 @@ func (u *User) CheckLatency(req api.CheckLatencyUserResponse) (api.CheckLatencyU
 // InitSession signals the user that the app is ready to go.
 func (u *User) InitSession(wid string, ice []config.IceServer, games []api.AppMeta) {
 	u.Notify(api.InitSession, api.InitSessionUserResponse{
-		Ice:   *(*[]api.IceServer)(unsafe.Pointer(&ice)), // don't do this at home
-		Games: games,
-		Wid:   wid,
+		Ice:    *(*[]api.IceServer)(unsafe.Pointer(&ice)), // don't do this at home
+		Games:  games,
+		Wid:    wid,
+		Resume: u.resume,
 	})
 }
 
@@ -37,6 +38,19 @@ func (u *User) SendWebrtcIceCandidate(candidate string) {
 }
 
 // StartGame signals the user that everything is ready to start a game.
-func (u *User) StartGame(av *api.AppVideoInfo, kbMouse bool) {
-	u.Notify(api.StartGame, api.GameStartUserResponse{RoomId: u.w.RoomId, Av: av, KbMouse: kbMouse})
+func (u *User) StartGame(rq *api.StartGameResponse) {
+	u.Notify(api.StartGame, api.GameStartUserResponse{
+		RoomId:    rq.Rid,
+		Av:        rq.AV,
+		KbMouse:   rq.KbMouse,
+		Spectator: rq.Spectator,
+		Host:      rq.Host,
+		Layers:    rq.Layers,
+	})
 }
+
+// HostChanged signals the user that they are the host of the room now.
+func (u *User) HostChanged(rid string) { u.Notify(api.HostChanged, rid) }
+
+// Kicked signals the user that they have been kicked out of the room.
+func (u *User) Kicked(rid string) { u.Notify(api.Kicked, rid) }
@@ -13,7 +13,7 @@ func (u *User) HandleInitWebrtcStream(rq api.InitUserWebrtcStreamRequest) {
 		u.log.Warn().Msg("no worker assigned")
 		return
 	}
-	resp, err := u.w.InitWebrtcStream(u.Id().String(), rq.Initiator, rq.Sdp)
+	resp, err := u.w.InitWebrtcStream(u.Id().String(), rq.Initiator, rq.Sdp, rq.Codecs)
 	if err != nil || resp == nil || *resp == api.EMPTY {
 		u.log.Error().Err(err).Msg("malformed WebRTC init response")
 		return
@@ -22,54 +22,34 @@ func (u *User) HandleInitWebrtcStream(rq api.InitUserWebrtcStreamRequest) {
 }
 
 func (u *User) HandleWebrtcSignal(rq api.WebrtcSignalUser) {
-	u.w.WebrtcSignal(u.Id().String(), rq.Sdp, rq.Ice)
+	u.w.WebrtcSignal(u.Id().String(), rq.Sdp, rq.Ice, rq.Restart)
 }
 
 func (u *User) HandleStartGame(rq api.GameStartUserRequest, conf config.CoordinatorConfig) {
 	// Worker slot / room gating:
-	// - If the worker is BUSY (no free slot), we must not create another room.
-	//   * If the worker has already reported a room id, only allow requests
-	//     for that same room (deep-link joins / reloads).
-	//   * If the worker hasn't reported a room yet, deny any new StartGame to
-	//     avoid racing concurrent room creation on the worker.
-	//   * When the user is starting a NEW game (empty room id), we give the
-	//     worker a short grace period to close the previous room and free the
-	//     slot before rejecting with "no slots".
-	// - If the worker is FREE, reserve the slot lazily before starting the
-	//   game; the room id (if any) comes from the request / worker.
-
-	// Grace period: when there's no room id in the request (new game) but the
-	// worker still appears busy, wait a bit for the previous room to close.
-	if rq.RoomId == "" && !u.w.HasSlot() {
-		const waitTotal = 3 * time.Second
-		const step = 100 * time.Millisecond
-		waited := time.Duration(0)
-		for waited < waitTotal {
-			if u.w.HasSlot() {
-				break
+	// - If the requested room is already running on the worker,
+	//   the user just joins it (deep-link joins / reloads) without
+	//   taking any new slots.
+	// - Otherwise, a new room will be created, so we must reserve one
+	//   of the free worker slots before starting the game. The slot
+	//   is freed when the worker closes the room.
+	//   If the worker is BUSY (no free slots), we give it a short grace
+	//   period to close some previous room (i.e. the user restarts the game)
+	//   before rejecting with "no slots".
+	join := u.w.HasRoom(rq.RoomId)
+	if !join {
+		if !u.w.HasSlot() {
+			const waitTotal = 3 * time.Second
+			const step = 100 * time.Millisecond
+			waited := time.Duration(0)
+			for waited < waitTotal {
+				if u.w.HasSlot() {
+					break
+				}
+				time.Sleep(step)
+				waited += step
 			}
-			time.Sleep(step)
-			waited += step
 		}
-	}
-
-	busy := !u.w.HasSlot()
-	if busy {
-		if u.w.RoomId == "" {
-			u.Notify(api.ErrNoFreeSlots, "")
-			return
-		}
-		// if rq.RoomId == "" {
-		// 	// No room id but worker is busy -> assume user wants to continue
-		// 	// the existing room instead of starting a parallel game.
-		// 	rq.RoomId = u.w.RoomId
-		// } else
-		if rq.RoomId != u.w.RoomId {
-			u.Notify(api.ErrNoFreeSlots, "")
-			return
-		}
-	} else {
-		// Worker is free: try to reserve the single slot for this new room.
 		if !u.w.TryReserve() {
 			u.Notify(api.ErrNoFreeSlots, "")
 			return
@@ -77,16 +57,27 @@ func (u *User) HandleStartGame(rq api.GameStartUserRequest, conf config.Coordina
 	}
 
 	startGameResp, err := u.w.StartGame(u.Id().String(), rq)
-	if err != nil || startGameResp == nil {
-		u.log.Error().Err(err).Msg("malformed game start response")
+	if err == nil && startGameResp != nil && startGameResp.Full {
+		if !join {
+			u.w.UnReserve()
+		}
+		u.Notify(api.ErrRoomFull, "")
 		return
 	}
-	if startGameResp.Rid == "" {
-		u.log.Error().Msg("there is no room")
+	if err != nil || startGameResp == nil || startGameResp.Rid == "" {
+		if !join {
+			u.w.UnReserve()
+		}
+		if err != nil || startGameResp == nil {
+			u.log.Error().Err(err).Msg("malformed game start response")
+		} else {
+			u.log.Error().Msg("there is no room")
+		}
 		return
 	}
 	u.log.Info().Str("id", startGameResp.Rid).Msg("Received room response from worker")
-	u.StartGame(startGameResp.AV, startGameResp.KbMouse)
+	u.room = startGameResp.Rid
+	u.StartGame(startGameResp)
 
 	// send back recording status
 	if conf.Recording.Enabled && rq.Record {
@@ -95,26 +86,27 @@ func (u *User) HandleStartGame(rq api.GameStartUserRequest, conf config.Coordina
 }
 
 func (u *User) HandleQuitGame(rq api.GameQuitRequest) {
-	if rq.Rid == u.w.RoomId {
-		u.w.QuitGame(u.Id().String())
+	if rq.Rid != "" && rq.Rid == u.room {
+		u.w.QuitGame(u.Id().String(), u.room)
+		u.room = ""
 	}
 }
 
 func (u *User) HandleResetGame(rq api.ResetGameRequest) {
-	if rq.Rid != u.w.RoomId {
+	if rq.Rid == "" || rq.Rid != u.room {
 		return
 	}
-	u.w.ResetGame(u.Id().String())
+	u.w.ResetGame(u.Id().String(), u.room)
 }
 
 func (u *User) HandleSaveGame() error {
-	resp, err := u.w.SaveGame(u.Id().String())
+	resp, err := u.w.SaveGame(u.Id().String(), u.room)
 	if err != nil {
 		return err
 	}
 
 	if *resp == api.OK {
-		if id, _ := api.ExplodeDeepLink(u.w.RoomId); id != "" {
+		if id, _ := api.ExplodeDeepLink(u.room); id != "" {
 			u.w.AddSession(id)
 		}
 	}
@@ -124,7 +116,7 @@ func (u *User) HandleSaveGame() error {
 }
 
 func (u *User) HandleLoadGame() error {
-	resp, err := u.w.LoadGame(u.Id().String())
+	resp, err := u.w.LoadGame(u.Id().String(), u.room)
 	if err != nil {
 		return err
 	}
@@ -132,8 +124,56 @@ func (u *User) HandleLoadGame() error {
 	return nil
 }
 
+func (u *User) HandleSaveSlot(rq api.SaveSlotUserRequest) error {
+	if u.room == "" {
+		return nil
+	}
+	resp, err := u.w.SaveSlot(u.Id().String(), u.room, string(rq))
+	if err != nil {
+		return err
+	}
+	u.Notify(api.SaveSlot, resp)
+	return nil
+}
+
+func (u *User) HandleLoadSlot(rq api.LoadSlotUserRequest) error {
+	if u.room == "" {
+		return nil
+	}
+	resp, err := u.w.LoadSlot(u.Id().String(), u.room, string(rq))
+	if err != nil {
+		return err
+	}
+	u.Notify(api.LoadSlot, resp)
+	return nil
+}
+
+func (u *User) HandleListSlots() error {
+	if u.room == "" {
+		return nil
+	}
+	resp, err := u.w.ListSlots(u.Id().String(), u.room)
+	if err != nil {
+		return err
+	}
+	u.Notify(api.ListSlots, resp)
+	return nil
+}
+
+func (u *User) HandleRoomUsers() error {
+	if u.room == "" {
+		return nil
+	}
+	resp, err := u.w.RoomUsers(u.Id().String(), u.room)
+	if err != nil {
+		return err
+	}
+	u.Notify(api.RoomUsers, resp)
+	return nil
+}
+
 func (u *User) HandleChangePlayer(rq api.ChangePlayerUserRequest) {
-	resp, err := u.w.ChangePlayer(u.Id().String(), int(rq))
+	resp, err := u.w.ChangePlayer(u.Id().String(), u.room, int(rq))
 	// !to make it a little less convoluted
 	if err != nil || resp == nil || *resp == -1 {
 		u.log.Error().Err(err).Msgf("player select fail, req: %v", rq)
@@ -142,19 +182,223 @@ func (u *User) HandleChangePlayer(rq api.ChangePlayerUserRequest) {
 	u.Notify(api.ChangePlayer, rq)
 }
 
+func (u *User) HandleVideoLayer(rq api.VideoLayerUserRequest) error {
+	if u.room == "" {
+		return nil
+	}
+	resp, err := u.w.VideoLayer(u.Id().String(), u.room, int(rq))
+	if err != nil {
+		return err
+	}
+	u.Notify(api.VideoLayer, resp)
+	return nil
+}
+
+func (u *User) HandleKickUser(rq api.KickUserUserRequest) error {
+	if u.room == "" {
+		return nil
+	}
+	resp, err := u.w.KickUser(u.Id().String(), u.room, string(rq))
+	if err != nil {
+		return err
+	}
+	u.Notify(api.KickUser, resp)
+	return nil
+}
+
+func (u *User) HandleTransferHost(rq api.TransferHostUserRequest) error {
+	if u.room == "" {
+		return nil
+	}
+	resp, err := u.w.TransferHost(u.Id().String(), u.room, string(rq))
+	if err != nil {
+		return err
+	}
+	u.Notify(api.TransferHost, resp)
+	return nil
+}
+
+func (u *User) HandleLockRoom(rq api.LockRoomUserRequest) error {
+	if u.room == "" {
+		return nil
+	}
+	resp, err := u.w.LockRoom(u.Id().String(), u.room, bool(rq))
+	if err != nil {
+		return err
+	}
+	u.Notify(api.LockRoom, resp)
+	return nil
+}
+
+func (u *User) HandleWhepToken() error {
+	if u.room == "" {
+		return nil
+	}
+	resp, err := u.w.WhepToken(u.Id().String(), u.room)
+	if err != nil {
+		return err
+	}
+	u.Notify(api.WhepToken, resp)
+	return nil
+}
+
+func (u *User) HandleRoomAudio(rq api.RoomAudioUserRequest) error {
+	if u.room == "" {
+		return nil
+	}
+	resp, err := u.w.RoomAudio(u.Id().String(), u.room, rq)
+	if err != nil {
+		return err
+	}
+	u.Notify(api.RoomAudio, resp)
+	return nil
+}
+
+func (u *User) HandleVideoFilter(rq api.VideoFilterUserRequest) error {
+	if u.room == "" {
+		return nil
+	}
+	resp, err := u.w.VideoFilter(u.Id().String(), u.room, api.VideoFilterInfo(rq))
+	if err != nil {
+		return err
+	}
+	u.Notify(api.VideoFilter, resp)
+	return nil
+}
+
+func (u *User) HandleCheats() error {
+	if u.room == "" {
+		return nil
+	}
+	resp, err := u.w.Cheats(u.Id().String(), u.room)
+	if err != nil {
+		return err
+	}
+	u.Notify(api.Cheats, resp)
+	return nil
+}
+
+func (u *User) HandleSetCheat(rq api.SetCheatUserRequest) error {
+	if u.room == "" {
+		return nil
+	}
+	resp, err := u.w.SetCheat(u.Id().String(), u.room, rq)
+	if err != nil {
+		return err
+	}
+	u.Notify(api.SetCheat, resp)
+	return nil
+}
+
+func (u *User) HandleDisks() error {
+	if u.room == "" {
+		return nil
+	}
+	resp, err := u.w.Disks(u.Id().String(), u.room)
+	if err != nil {
+		return err
+	}
+	u.Notify(api.Disks, resp)
+	return nil
+}
+
+func (u *User) HandleEjectDisk(rq api.EjectDiskUserRequest) error {
+	if u.room == "" {
+		return nil
+	}
+	resp, err := u.w.EjectDisk(u.Id().String(), u.room, bool(rq))
+	if err != nil {
+		return err
+	}
+	u.Notify(api.EjectDisk, resp)
+	return nil
+}
+
+func (u *User) HandleSetDisk(rq api.SetDiskUserRequest) error {
+	if u.room == "" {
+		return nil
+	}
+	resp, err := u.w.SetDisk(u.Id().String(), u.room, int(rq))
+	if err != nil {
+		return err
+	}
+	u.Notify(api.SetDisk, resp)
+	return nil
+}
+
+func (u *User) HandleSpeed(rq api.SpeedUserRequest) error {
+	if u.room == "" {
+		return nil
+	}
+	resp, err := u.w.Speed(u.Id().String(), u.room, float64(rq))
+	if err != nil {
+		return err
+	}
+	u.Notify(api.Speed, resp)
+	return nil
+}
+
+func (u *User) HandleFrameAdvance() error {
+	if u.room == "" {
+		return nil
+	}
+	resp, err := u.w.FrameAdvance(u.Id().String(), u.room)
+	if err != nil {
+		return err
+	}
+	u.Notify(api.FrameAdvance, resp)
+	return nil
+}
+
+func (u *User) HandleRewind(rq api.RewindUserRequest) error {
+	if u.room == "" {
+		return nil
+	}
+	resp, err := u.w.Rewind(u.Id().String(), u.room, float64(rq))
+	if err != nil {
+		return err
+	}
+	u.Notify(api.Rewind, resp)
+	return nil
+}
+
+func (u *User) HandleCoreOptions() error {
+	if u.room == "" {
+		return nil
+	}
+	resp, err := u.w.CoreOptions(u.Id().String(), u.room)
+	if err != nil {
+		return err
+	}
+	u.Notify(api.CoreOptions, resp)
+	return nil
+}
+
+func (u *User) HandleSetCoreOption(rq api.SetCoreOptionUserRequest) error {
+	if u.room == "" {
+		return nil
+	}
+	resp, err := u.w.SetCoreOption(u.Id().String(), u.room, rq)
+	if err != nil {
+		return err
+	}
+	u.Notify(api.SetCoreOption, resp)
+	return nil
+}
+
 func (u *User) HandleRecordGame(rq api.RecordGameRequest) {
 	if u.w == nil {
 		return
 	}
 
-	u.log.Debug().Msgf("??? room: %v, rec: %v user: %v", u.w.RoomId, rq.Active, rq.User)
+	u.log.Debug().Msgf("??? room: %v, rec: %v user: %v", u.room, rq.Active, rq.User)
 
-	if u.w.RoomId == "" {
+	if u.room == "" {
 		u.log.Error().Msg("Recording in the empty room is not allowed!")
 		return
 	}
 
-	resp, err := u.w.RecordGame(u.Id().String(), rq.Active, rq.User)
+	resp, err := u.w.RecordGame(u.Id().String(), u.room, rq.Active, rq.User)
 	if err != nil {
 		u.log.Error().Err(err).Msg("malformed game record request")
 		return
@@ -178,6 +422,8 @@ func (u *User) handleGetWorkerList(debug bool, info HasServerInfo) {
 			v := unique[mid]
 			if v != nil {
 				v.Replicas++
+				v.FreeSlots += s.FreeSlots
+				v.UsedSlots += s.UsedSlots
 			}
 		}
 		for _, v := range unique {
@@ -6,6 +6,7 @@ import (
 	"sync/atomic"
 
 	"github.com/giongto35/cloud-game/v3/pkg/api"
+	"github.com/giongto35/cloud-game/v3/pkg/cloud"
 	"github.com/giongto35/cloud-game/v3/pkg/com"
 	"github.com/giongto35/cloud-game/v3/pkg/logger"
 )
@@ -20,13 +21,15 @@ type Worker struct {
 	Addr       string
 	PingServer string
 	Port       string
-	RoomId     string // room reference
 	Tag        string
 	Zone       string
 
 	Lib      []api.GameInfo
 	Sessions map[string]struct{}
 
+	rooms com.Map[string, struct{}] // running rooms references
+	files com.Map[string, struct{}] // rooms with the files in the shared storage
+
 	log *logger.Logger
 }
 
@@ -67,6 +70,7 @@ func NewWorker(sock *com.Connection, handshake api.ConnectionRequest[com.Uid], l
 		PingServer: handshake.PingURL,
 		Port:       handshake.Port,
 		Tag:        handshake.Tag,
+		slotted:    slotted{capacity: int32(handshake.Slots)},
 		Zone:       handshake.Zone,
 		log: log.Extend(log.With().
 			Str(logger.ClientField, logger.MarkNone).
@@ -75,8 +79,10 @@ func NewWorker(sock *com.Connection, handshake api.ConnectionRequest[com.Uid], l
 	}
 }
 
-func (w *Worker) HandleRequests(users HasUserRegistry) chan struct{} {
+func (w *Worker) HandleRequests(users HasUserRegistry, storage cloud.Storage) chan struct{} {
 	return w.ProcessPackets(func(p api.In[com.Uid]) (err error) {
+		var out api.Out
+
 		switch p.T {
 		case api.RegisterRoom:
 			err = api.Do(p, func(d api.RegisterRoomRequest) {
@@ -87,18 +93,37 @@ func (w *Worker) HandleRequests(users HasUserRegistry) chan struct{} {
 			err = api.Do(p, w.HandleCloseRoom)
 		case api.WebrtcSignal:
 			err = api.DoE(p, func(rq api.WebrtcSignalRequest) error {
-				if rq.Ice == nil {
-					return fmt.Errorf("ice candidate is missing")
+				if rq.Ice == nil && rq.Sdp == nil {
+					return fmt.Errorf("ice candidate or sdp is missing")
 				}
-				return w.HandleIceCandidate(rq, users)
+				return w.HandleWebrtcSignal(rq, users)
 			})
+		case api.HostChanged:
+			err = api.Do(p, func(d api.HostChangedRequest) { w.HandleHostChanged(d, users) })
+		case api.Kicked:
+			err = api.Do(p, func(d api.KickedRequest) { w.HandleKicked(d, users) })
 		case api.LibNewGameList:
 			err = api.DoE(p, w.HandleLibGameList)
 		case api.PrevSessions:
 			err = api.DoE(p, w.HandlePrevSessionList)
+		case api.StorageSave:
+			err = api.Do(p, func(d api.StorageSaveRequest) { out = w.HandleStorageSave(d, storage) })
+		case api.StorageLoad:
+			err = api.Do(p, func(d api.StorageLoadRequest) { out = w.HandleStorageLoad(d, storage) })
+		case api.StorageHas:
+			err = api.Do(p, func(d api.StorageHasRequest) { out = w.HandleStorageHas(d, storage) })
+		case api.StorageDelete:
+			err = api.Do(p, func(d api.StorageDeleteRequest) { out = w.HandleStorageDelete(d, storage) })
+		case api.StorageList:
+			err = api.Do(p, func(d api.StorageListRequest) { out = w.HandleStorageList(d, storage) })
+		case api.StorageRoom:
+			err = api.Do(p, w.HandleStorageRoom)
 		default:
 			w.log.Warn().Msgf("Unknown packet: %+v", p)
 		}
+		if out != (api.Out{}) {
+			w.Route(p, &out)
+		}
 		if err != nil && !errors.Is(err, api.ErrMalformed) {
 			w.log.Error().Err(err).Send()
 			err = api.ErrMalformed
@@ -135,35 +160,52 @@ func (w *Worker) SetSessions(sessions map[string]struct{}) {
 // Empty region always returns true.
 func (w *Worker) In(region string) bool { return region == "" || region == w.Zone }
 
-// slotted used for tracking user slots and the availability.
-type slotted int32
+// HasRoom checks if the worker runs a room with the id.
+func (w *Worker) HasRoom(id string) bool { return id != "" && w.rooms.Has(id) }
+
+// Rooms returns a list of running rooms of the worker.
+func (w *Worker) Rooms() []string {
+	var rooms []string
+	for id := range w.rooms.Keys() {
+		rooms = append(rooms, id)
+	}
+	return rooms
+}
+
+// slotted used for tracking room slots and the availability.
+type slotted struct {
+	capacity int32
+	used     atomic.Int32
+}
+
+// Capacity returns the max number of concurrent rooms (games) of the worker.
+// Workers without explicit capacity support only one game at a time.
+func (s *slotted) Capacity() int32 { return max(s.capacity, 1) }
 
 // HasSlot checks if the current worker has a free slot to start a new game.
-// Workers support only one game at a time, so it returns true in case if
-// there are no players in the room (worker).
-func (s *slotted) HasSlot() bool { return atomic.LoadInt32((*int32)(s)) == 0 }
+func (s *slotted) HasSlot() bool { return s.used.Load() < s.Capacity() }
 
 // TryReserve reserves the slot only when it's free.
 func (s *slotted) TryReserve() bool {
 	for {
-		current := atomic.LoadInt32((*int32)(s))
-		if current != 0 {
+		current := s.used.Load()
+		if current >= s.Capacity() {
 			return false
 		}
-		if atomic.CompareAndSwapInt32((*int32)(s), 0, 1) {
+		if s.used.CompareAndSwap(current, current+1) {
 			return true
 		}
 	}
 }
 
-// UnReserve decrements user counter of the worker.
+// UnReserve decrements used slots counter of the worker.
 func (s *slotted) UnReserve() {
 	for {
-		current := atomic.LoadInt32((*int32)(s))
+		current := s.used.Load()
 		if current <= 0 {
 			// reset to zero
 			if current < 0 {
-				if atomic.CompareAndSwapInt32((*int32)(s), current, 0) {
+				if s.used.CompareAndSwap(current, 0) {
 					return
 				}
 				continue
@@ -173,22 +215,23 @@ func (s *slotted) UnReserve() {
 		}
 
 		// Regular decrement for positive values
-		newVal := current - 1
-		if atomic.CompareAndSwapInt32((*int32)(s), current, newVal) {
+		if s.used.CompareAndSwap(current, current-1) {
 			return
 		}
 	}
 }
 
-func (s *slotted) FreeSlots() { atomic.StoreInt32((*int32)(s), 0) }
+func (s *slotted) FreeSlots()       { s.used.Store(0) }
+func (s *slotted) UsedSlots() int32 { return min(s.used.Load(), s.Capacity()) }
 
 func (w *Worker) Disconnect() {
 	w.Connection.Disconnect()
-	w.RoomId = ""
+	w.rooms.Clear()
+	w.files.Clear()
 	w.FreeSlots()
 }
 
 func (w *Worker) PrintInfo() string {
-	return fmt.Sprintf("id: %v, addr: %v, port: %v, zone: %v, ping addr: %v, tag: %v",
-		w.Id(), w.Addr, w.Port, w.Zone, w.PingServer, w.Tag)
+	return fmt.Sprintf("id: %v, addr: %v, port: %v, zone: %v, ping addr: %v, tag: %v, slots: %v",
+		w.Id(), w.Addr, w.Port, w.Zone, w.PingServer, w.Tag, w.Capacity())
 }
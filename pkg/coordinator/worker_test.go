@@ -17,6 +17,7 @@ func TestSlotted(t *testing.T) {
 		t.Run("SuccessWhenZero", testTryReserveSuccess)
 		t.Run("FailWhenNonZero", testTryReserveFailure)
 		t.Run("ConcurrentReservations", testTryReserveConcurrent)
+		t.Run("Capacity", testTryReserveCapacity)
 	})
 
 	t.Run("Integration", func(t *testing.T) {
@@ -31,14 +32,14 @@ func testUnReserveBasic(t *testing.T) {
 	var s slotted
 
 	// Initial state
-	if atomic.LoadInt32((*int32)(&s)) != 0 {
+	if s.used.Load() != 0 {
 		t.Fatal("initial state not zero")
 	}
 
 	// Test normal decrement
 	s.TryReserve() // 0 -> 1
 	s.UnReserve()
-	if atomic.LoadInt32((*int32)(&s)) != 0 {
+	if s.used.Load() != 0 {
 		t.Error("failed to decrement to zero")
 	}
 
@@ -47,7 +48,7 @@ func testUnReserveBasic(t *testing.T) {
 	s.TryReserve() // 1 -> 2
 	s.UnReserve()
 	s.UnReserve()
-	if atomic.LoadInt32((*int32)(&s)) != 0 {
+	if s.used.Load() != 0 {
 		t.Error("failed to decrement multiple times")
 	}
 }
@@ -58,15 +59,15 @@ func testUnReserveUnderflow(t *testing.T) {
 
 	t.Run("PreventNewUnderflow", func(t *testing.T) {
 		s.UnReserve() // Start at 0
-		if atomic.LoadInt32((*int32)(&s)) != 0 {
+		if s.used.Load() != 0 {
 			t.Error("should remain at 0 when unreserving from 0")
 		}
 	})
 
 	t.Run("FixExistingNegative", func(t *testing.T) {
-		atomic.StoreInt32((*int32)(&s), -5)
+		s.used.Store(-5)
 		s.UnReserve()
-		if current := atomic.LoadInt32((*int32)(&s)); current != 0 {
+		if current := s.used.Load(); current != 0 {
 			t.Errorf("should fix negative value to 0, got %d", current)
 		}
 	})
@@ -79,7 +80,7 @@ func testUnReserveConcurrent(t *testing.T) {
 	const workers = 100
 	var wg sync.WaitGroup
 
-	atomic.StoreInt32((*int32)(&s), int32(workers))
+	s.used.Store(int32(workers))
 	wg.Add(workers)
 
 	for range workers {
@@ -91,7 +92,7 @@ func testUnReserveConcurrent(t *testing.T) {
 
 	wg.Wait()
 
-	if current := atomic.LoadInt32((*int32)(&s)); current != 0 {
+	if current := s.used.Load(); current != 0 {
 		t.Errorf("unexpected final value: %d (want 0)", current)
 	}
 }
@@ -103,7 +104,7 @@ func testTryReserveSuccess(t *testing.T) {
 	if !s.TryReserve() {
 		t.Error("should succeed when zero")
 	}
-	if atomic.LoadInt32((*int32)(&s)) != 1 {
+	if s.used.Load() != 1 {
 		t.Error("failed to increment")
 	}
 }
@@ -112,7 +113,7 @@ func testTryReserveFailure(t *testing.T) {
 	t.Parallel()
 	var s slotted
 
-	atomic.StoreInt32((*int32)(&s), 1)
+	s.used.Store(1)
 	if s.TryReserve() {
 		t.Error("should fail when non-zero")
 	}
@@ -140,11 +141,42 @@ func testTryReserveConcurrent(t *testing.T) {
 	if success != 1 {
 		t.Errorf("unexpected success count: %d (want 1)", success)
 	}
-	if atomic.LoadInt32((*int32)(&s)) != 1 {
+	if s.used.Load() != 1 {
 		t.Error("counter not properly incremented")
 	}
 }
 
+func testTryReserveCapacity(t *testing.T) {
+	t.Parallel()
+	s := slotted{capacity: 3}
+	const workers = 100
+	var success int32
+	var wg sync.WaitGroup
+
+	wg.Add(workers)
+	for range workers {
+		go func() {
+			defer wg.Done()
+			if s.TryReserve() {
+				atomic.AddInt32(&success, 1)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if success != 3 {
+		t.Errorf("unexpected success count: %d (want 3)", success)
+	}
+	if s.HasSlot() {
+		t.Error("shouldn't have slot when all reserved")
+	}
+	s.UnReserve()
+	if !s.HasSlot() {
+		t.Error("should have slot after unreserve")
+	}
+}
+
 func testReserveUnreserveFlow(t *testing.T) {
 	t.Parallel()
 	var s slotted
@@ -171,9 +203,9 @@ func testFreeSlots(t *testing.T) {
 	var s slotted
 
 	// Set to arbitrary value
-	atomic.StoreInt32((*int32)(&s), 5)
+	s.used.Store(5)
 	s.FreeSlots()
-	if atomic.LoadInt32((*int32)(&s)) != 0 {
+	if s.used.Load() != 0 {
 		t.Error("FreeSlots failed to reset counter")
 	}
 }
@@ -191,3 +223,37 @@ func testHasSlot(t *testing.T) {
 		t.Error("shouldn't have slot when reserved")
 	}
 }
+
+func TestWorkerOwnsFile(t *testing.T) {
+	var w Worker
+	w.HandleStorageRoom("abc___Sonic")
+
+	tests := []struct {
+		name string
+		own  bool
+	}{
+		{"abc___Sonic.bundle", true},
+		{"abc___Sonic.dat.zip", true},
+		{"abc___Sonic.1.dat.zip.png", true},
+		{"abc___Sonic", true},
+		{"abc___Sonic2.bundle", false},
+		{"def___Sonic.bundle", false},
+		{"abc___Sonic./../x", false},
+		{"", false},
+	}
+	for _, test := range tests {
+		if own := w.ownsFile(test.name); own != test.own {
+			t.Errorf("%v: %v, want %v", test.name, own, test.own)
+		}
+	}
+	// the files of the room don't take the slots
+	w.capacity = 1
+	w.TryReserve()
+	w.HandleCloseRoom("abc___Sonic")
+	if w.ownsFile("abc___Sonic.bundle") {
+		t.Errorf("the file of the closed room")
+	}
+	if w.HasSlot() {
+		t.Errorf("the slot of an unregistered room is free")
+	}
+}
@@ -2,14 +2,14 @@ package coordinator
 
 import "github.com/giongto35/cloud-game/v3/pkg/api"
 
-func (w *Worker) InitWebrtcStream(id string, initiator bool, sdp string) (*api.InitWebrtcStreamResponse, error) {
+func (w *Worker) InitWebrtcStream(id string, initiator bool, sdp string, codecs []string) (*api.InitWebrtcStreamResponse, error) {
 	return api.UnwrapChecked[api.InitWebrtcStreamResponse](
-		w.Send(api.InitWebrtcStream, api.InitWebrtcStreamRequest{Id: id, Initiator: initiator, Sdp: sdp}))
+		w.Send(api.InitWebrtcStream, api.InitWebrtcStreamRequest{Id: id, Initiator: initiator, Sdp: sdp, Codecs: codecs}))
 }
 
-func (w *Worker) WebrtcSignal(id string, sdp, ice *string) {
+func (w *Worker) WebrtcSignal(id string, sdp, ice *string, restart bool) {
 	w.Notify(api.WebrtcSignal, api.WebrtcSignalRequest{
-		Stateful: api.Stateful{Id: id}, Ice: ice, Sdp: sdp,
+		Stateful: api.Stateful{Id: id}, Ice: ice, Sdp: sdp, Restart: restart,
 	})
 }
 
@@ -24,36 +24,183 @@ func (w *Worker) StartGame(id string, req api.GameStartUserRequest) (*api.StartG
 		}))
 }
 
-func (w *Worker) QuitGame(id string) {
-	w.Notify(api.QuitGame, api.GameQuitRequest{Id: id, Rid: w.RoomId})
+func (w *Worker) QuitGame(id string, rid string) {
+	w.Notify(api.QuitGame, api.GameQuitRequest{Id: id, Rid: rid})
 }
 
-func (w *Worker) SaveGame(id string) (*api.SaveGameResponse, error) {
+func (w *Worker) SaveGame(id string, rid string) (*api.SaveGameResponse, error) {
 	return api.UnwrapChecked[api.SaveGameResponse](
-		w.Send(api.SaveGame, api.SaveGameRequest{Id: id, Rid: w.RoomId}))
+		w.Send(api.SaveGame, api.SaveGameRequest{Id: id, Rid: rid}))
 }
 
-func (w *Worker) LoadGame(id string) (*api.LoadGameResponse, error) {
+func (w *Worker) LoadGame(id string, rid string) (*api.LoadGameResponse, error) {
 	return api.UnwrapChecked[api.LoadGameResponse](
-		w.Send(api.LoadGame, api.LoadGameRequest{Id: id, Rid: w.RoomId}))
+		w.Send(api.LoadGame, api.LoadGameRequest{Id: id, Rid: rid}))
 }
 
-func (w *Worker) ChangePlayer(id string, index int) (*api.ChangePlayerResponse, error) {
+func (w *Worker) SaveSlot(id string, rid string, slot string) (*api.SaveSlotResponse, error) {
+	return api.UnwrapChecked[api.SaveSlotResponse](
+		w.Send(api.SaveSlot, api.SaveSlotRequest{
+			StatefulRoom: api.StatefulRoom{Id: id, Rid: rid},
+			Slot:         slot,
+		}))
+}
+
+func (w *Worker) LoadSlot(id string, rid string, slot string) (*api.LoadSlotResponse, error) {
+	return api.UnwrapChecked[api.LoadSlotResponse](
+		w.Send(api.LoadSlot, api.LoadSlotRequest{
+			StatefulRoom: api.StatefulRoom{Id: id, Rid: rid},
+			Slot:         slot,
+		}))
+}
+
+func (w *Worker) ListSlots(id string, rid string) (*api.ListSlotsResponse, error) {
+	return api.UnwrapChecked[api.ListSlotsResponse](
+		w.Send(api.ListSlots, api.ListSlotsRequest{Id: id, Rid: rid}))
+}
+
+func (w *Worker) ChangePlayer(id string, rid string, index int) (*api.ChangePlayerResponse, error) {
 	return api.UnwrapChecked[api.ChangePlayerResponse](
 		w.Send(api.ChangePlayer, api.ChangePlayerRequest{
-			StatefulRoom: api.StatefulRoom{Id: id, Rid: w.RoomId},
+			StatefulRoom: api.StatefulRoom{Id: id, Rid: rid},
 			Index:        index,
 		}))
 }
 
-func (w *Worker) ResetGame(id string) {
-	w.Notify(api.ResetGame, api.ResetGameRequest{Id: id, Rid: w.RoomId})
+func (w *Worker) VideoLayer(id string, rid string, layer int) (*api.VideoLayerResponse, error) {
+	return api.UnwrapChecked[api.VideoLayerResponse](
+		w.Send(api.VideoLayer, api.VideoLayerRequest{
+			StatefulRoom: api.StatefulRoom{Id: id, Rid: rid},
+			Layer:        layer,
+		}))
+}
+
+func (w *Worker) RoomUsers(id string, rid string) (*api.RoomUsersResponse, error) {
+	return api.UnwrapChecked[api.RoomUsersResponse](
+		w.Send(api.RoomUsers, api.RoomUsersRequest{Id: id, Rid: rid}))
+}
+
+func (w *Worker) KickUser(id string, rid string, target string) (*api.KickUserResponse, error) {
+	return api.UnwrapChecked[api.KickUserResponse](
+		w.Send(api.KickUser, api.KickUserRequest{
+			StatefulRoom: api.StatefulRoom{Id: id, Rid: rid},
+			Target:       target,
+		}))
+}
+
+func (w *Worker) TransferHost(id string, rid string, target string) (*api.TransferHostResponse, error) {
+	return api.UnwrapChecked[api.TransferHostResponse](
+		w.Send(api.TransferHost, api.TransferHostRequest{
+			StatefulRoom: api.StatefulRoom{Id: id, Rid: rid},
+			Target:       target,
+		}))
+}
+
+func (w *Worker) LockRoom(id string, rid string, locked bool) (*api.LockRoomResponse, error) {
+	return api.UnwrapChecked[api.LockRoomResponse](
+		w.Send(api.LockRoom, api.LockRoomRequest{
+			StatefulRoom: api.StatefulRoom{Id: id, Rid: rid},
+			Locked:       locked,
+		}))
+}
+
+func (w *Worker) WhepToken(id string, rid string) (*api.WhepTokenResponse, error) {
+	return api.UnwrapChecked[api.WhepTokenResponse](
+		w.Send(api.WhepToken, api.WhepTokenRequest{Id: id, Rid: rid}))
+}
+
+func (w *Worker) RoomAudio(id string, rid string, rq api.RoomAudioUserRequest) (*api.RoomAudioResponse, error) {
+	return api.UnwrapChecked[api.RoomAudioResponse](
+		w.Send(api.RoomAudio, api.RoomAudioRequest{
+			StatefulRoom:         api.StatefulRoom{Id: id, Rid: rid},
+			RoomAudioUserRequest: rq,
+		}))
+}
+
+func (w *Worker) VideoFilter(id string, rid string, filter api.VideoFilterInfo) (*api.VideoFilterResponse, error) {
+	return api.UnwrapChecked[api.VideoFilterResponse](
+		w.Send(api.VideoFilter, api.VideoFilterRequest{
+			StatefulRoom: api.StatefulRoom{Id: id, Rid: rid},
+			Filter:       filter,
+		}))
+}
+
+func (w *Worker) Cheats(id string, rid string) (*api.CheatsResponse, error) {
+	return api.UnwrapChecked[api.CheatsResponse](
+		w.Send(api.Cheats, api.CheatsRequest{Id: id, Rid: rid}))
+}
+
+func (w *Worker) SetCheat(id string, rid string, rq api.SetCheatUserRequest) (*api.SetCheatResponse, error) {
+	return api.UnwrapChecked[api.SetCheatResponse](
+		w.Send(api.SetCheat, api.SetCheatRequest{
+			StatefulRoom:        api.StatefulRoom{Id: id, Rid: rid},
+			SetCheatUserRequest: rq,
+		}))
+}
+
+func (w *Worker) Disks(id string, rid string) (*api.DisksResponse, error) {
+	return api.UnwrapChecked[api.DisksResponse](
+		w.Send(api.Disks, api.DisksRequest{Id: id, Rid: rid}))
+}
+
+func (w *Worker) EjectDisk(id string, rid string, ejected bool) (*api.EjectDiskResponse, error) {
+	return api.UnwrapChecked[api.EjectDiskResponse](
+		w.Send(api.EjectDisk, api.EjectDiskRequest{
+			StatefulRoom: api.StatefulRoom{Id: id, Rid: rid},
+			Ejected:      ejected,
+		}))
+}
+
+func (w *Worker) SetDisk(id string, rid string, index int) (*api.SetDiskResponse, error) {
+	return api.UnwrapChecked[api.SetDiskResponse](
+		w.Send(api.SetDisk, api.SetDiskRequest{
+			StatefulRoom: api.StatefulRoom{Id: id, Rid: rid},
+			Index:        index,
+		}))
+}
+
+func (w *Worker) Speed(id string, rid string, speed float64) (*api.SpeedResponse, error) {
+	return api.UnwrapChecked[api.SpeedResponse](
+		w.Send(api.Speed, api.SpeedRequest{
+			StatefulRoom: api.StatefulRoom{Id: id, Rid: rid},
+			Speed:        speed,
+		}))
+}
+
+func (w *Worker) FrameAdvance(id string, rid string) (*api.FrameAdvanceResponse, error) {
+	return api.UnwrapChecked[api.FrameAdvanceResponse](
+		w.Send(api.FrameAdvance, api.FrameAdvanceRequest{Id: id, Rid: rid}))
+}
+
+func (w *Worker) Rewind(id string, rid string, seconds float64) (*api.RewindResponse, error) {
+	return api.UnwrapChecked[api.RewindResponse](
+		w.Send(api.Rewind, api.RewindRequest{
+			StatefulRoom: api.StatefulRoom{Id: id, Rid: rid},
+			Seconds:      seconds,
+		}))
+}
+
+func (w *Worker) CoreOptions(id string, rid string) (*api.CoreOptionsResponse, error) {
+	return api.UnwrapChecked[api.CoreOptionsResponse](
+		w.Send(api.CoreOptions, api.CoreOptionsRequest{Id: id, Rid: rid}))
+}
+
+func (w *Worker) SetCoreOption(id string, rid string, rq api.SetCoreOptionUserRequest) (*api.SetCoreOptionResponse, error) {
+	return api.UnwrapChecked[api.SetCoreOptionResponse](
+		w.Send(api.SetCoreOption, api.SetCoreOptionRequest{
+			StatefulRoom:             api.StatefulRoom{Id: id, Rid: rid},
+			SetCoreOptionUserRequest: rq,
+		}))
+}
+
+func (w *Worker) ResetGame(id string, rid string) {
+	w.Notify(api.ResetGame, api.ResetGameRequest{Id: id, Rid: rid})
 }
 
-func (w *Worker) RecordGame(id string, rec bool, recUser string) (*api.RecordGameResponse, error) {
+func (w *Worker) RecordGame(id string, rid string, rec bool, recUser string) (*api.RecordGameResponse, error) {
 	return api.UnwrapChecked[api.RecordGameResponse](
 		w.Send(api.RecordGame, api.RecordGameRequest{
-			StatefulRoom: api.StatefulRoom{Id: id, Rid: w.RoomId},
+			StatefulRoom: api.StatefulRoom{Id: id, Rid: rid},
 			Active:       rec,
 			User:         recUser,
 		}))
@@ -1,25 +1,55 @@
 package coordinator
 
-import "github.com/giongto35/cloud-game/v3/pkg/api"
+import (
+	"errors"
+	"strings"
 
-func (w *Worker) HandleRegisterRoom(rq api.RegisterRoomRequest) { w.RoomId = string(rq) }
+	"github.com/giongto35/cloud-game/v3/pkg/api"
+	"github.com/giongto35/cloud-game/v3/pkg/cloud"
+)
+
+var (
+	errNoStorage     = errors.New("no storage")
+	errStorageAccess = errors.New("no access to the file")
+)
+
+func (w *Worker) HandleRegisterRoom(rq api.RegisterRoomRequest) { w.rooms.Put(string(rq), struct{}{}) }
 
 func (w *Worker) HandleCloseRoom(rq api.CloseRoomRequest) {
-	if string(rq) == w.RoomId {
-		w.RoomId = ""
-		w.FreeSlots()
+	w.files.Remove(string(rq))
+	if w.HasRoom(string(rq)) {
+		w.rooms.Remove(string(rq))
+		w.UnReserve()
 	}
 }
 
-func (w *Worker) HandleIceCandidate(rq api.WebrtcSignalRequest, users HasUserRegistry) error {
+func (w *Worker) HandleWebrtcSignal(rq api.WebrtcSignalRequest, users HasUserRegistry) error {
 	if usr := users.Find(rq.Id); usr != nil {
-		usr.SendWebrtcIceCandidate(*rq.Ice)
+		if rq.Sdp != nil {
+			usr.SendWebrtcOffer(*rq.Sdp)
+		}
+		if rq.Ice != nil {
+			usr.SendWebrtcIceCandidate(*rq.Ice)
+		}
 	} else {
 		w.log.Warn().Str("id", rq.Id).Msg("unknown session")
 	}
 	return nil
 }
 
+func (w *Worker) HandleHostChanged(rq api.HostChangedRequest, users HasUserRegistry) {
+	if usr := users.Find(rq.Id); usr != nil {
+		usr.HostChanged(rq.Rid)
+	}
+}
+
+func (w *Worker) HandleKicked(rq api.KickedRequest, users HasUserRegistry) {
+	if usr := users.Find(rq.Id); usr != nil && usr.room == rq.Rid {
+		usr.room = ""
+		usr.Kicked(rq.Rid)
+	}
+}
+
 func (w *Worker) HandleLibGameList(inf api.LibGameListInfo) error {
 	w.SetLib(inf.List)
 	return nil
@@ -37,3 +67,83 @@ func (w *Worker) HandlePrevSessionList(sess api.PrevSessionInfo) error {
 	w.SetSessions(m)
 	return nil
 }
+
+func (w *Worker) HandleStorageSave(rq api.StorageSaveRequest, st cloud.Storage) api.Out {
+	if st == nil {
+		return storageOut(nil, errNoStorage)
+	}
+	if !w.ownsFile(rq.Name) {
+		return storageOut(nil, errStorageAccess)
+	}
+	return storageOut(nil, st.Save(rq.Name, rq.Data, rq.Tags))
+}
+
+func (w *Worker) HandleStorageLoad(rq api.StorageLoadRequest, st cloud.Storage) api.Out {
+	if st == nil {
+		return storageOut(nil, errNoStorage)
+	}
+	if !w.ownsFile(string(rq)) {
+		return storageOut(nil, errStorageAccess)
+	}
+	data, err := st.Load(string(rq))
+	return storageOut(&api.StorageResponse{Data: data}, err)
+}
+
+func (w *Worker) HandleStorageHas(rq api.StorageHasRequest, st cloud.Storage) api.Out {
+	if st == nil {
+		return storageOut(nil, errNoStorage)
+	}
+	if !w.ownsFile(string(rq)) {
+		return storageOut(nil, errStorageAccess)
+	}
+	return storageOut(&api.StorageResponse{Has: st.Has(string(rq))}, nil)
+}
+
+func (w *Worker) HandleStorageDelete(rq api.StorageDeleteRequest, st cloud.Storage) api.Out {
+	if st == nil {
+		return storageOut(nil, errNoStorage)
+	}
+	if !w.ownsFile(string(rq)) {
+		return storageOut(nil, errStorageAccess)
+	}
+	return storageOut(nil, st.Delete(string(rq)))
+}
+
+func (w *Worker) HandleStorageList(rq api.StorageListRequest, st cloud.Storage) api.Out {
+	if st == nil {
+		return storageOut(nil, errNoStorage)
+	}
+	if !w.ownsFile(string(rq)) {
+		return storageOut(nil, errStorageAccess)
+	}
+	names, err := st.List(string(rq))
+	return storageOut(&api.StorageResponse{Names: names}, err)
+}
+
+func (w *Worker) HandleStorageRoom(rq api.StorageRoomRequest) { w.files.Put(string(rq), struct{}{}) }
+
+// ownsFile checks that the file of the shared storage belongs to one of
+// the rooms of the worker, so the workers can't touch the saves
+// of each other. The names of the room files start with the room id.
+func (w *Worker) ownsFile(name string) bool {
+	if name == "" || strings.ContainsAny(name, `/\`) {
+		return false
+	}
+	for id := range w.files.Keys() {
+		if name == id || strings.HasPrefix(name, id+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// storageOut wraps the storage response, the error replaces the data.
+func storageOut(rs *api.StorageResponse, err error) api.Out {
+	if err != nil {
+		rs = &api.StorageResponse{Err: err.Error()}
+	}
+	if rs == nil {
+		rs = &api.StorageResponse{}
+	}
+	return api.Out{Payload: rs}
+}
@@ -0,0 +1,248 @@
+package av1
+
+/*
+// See: [libaom](https://aomedia.googlesource.com/aom)
+#cgo pkg-config: aom
+#cgo st LDFLAGS: -l:libaom.a
+
+#include "aom/aom_encoder.h"
+#include "aom/aom_image.h"
+#include "aom/aomcx.h"
+
+#include <stdlib.h>
+#include <string.h>
+
+typedef struct FrameBuffer {
+  void *ptr;
+  int size;
+} FrameBuffer;
+
+aom_codec_err_t call_aom_codec_enc_config_default(aom_codec_enc_cfg_t *cfg) {
+	return aom_codec_enc_config_default(aom_codec_av1_cx(), cfg, AOM_USAGE_REALTIME);
+}
+aom_codec_err_t call_aom_codec_enc_init(aom_codec_ctx_t *codec, aom_codec_enc_cfg_t *cfg) {
+	return aom_codec_enc_init(codec, aom_codec_av1_cx(), cfg, 0);
+}
+
+// Drains all encoded packets, returning the first frame found.
+FrameBuffer get_frame_buffer(aom_codec_ctx_t *codec, aom_codec_iter_t *iter) {
+    FrameBuffer fb = {NULL, 0};
+    const aom_codec_cx_pkt_t *pkt;
+    while ((pkt = aom_codec_get_cx_data(codec, iter)) != NULL) {
+        if (pkt->kind == AOM_CODEC_CX_FRAME_PKT && fb.ptr == NULL) {
+            fb.ptr = pkt->data.frame.buf;
+            fb.size = pkt->data.frame.sz;
+        }
+    }
+    return fb;
+}
+
+aom_codec_err_t set_aom_cpu_used(aom_codec_ctx_t *ctx, int value) {
+	return aom_codec_control(ctx, AOME_SET_CPUUSED, value);
+}
+
+aom_codec_err_t set_av1_tile_columns(aom_codec_ctx_t *ctx, int value) {
+	return aom_codec_control(ctx, AV1E_SET_TILE_COLUMNS, value);
+}
+
+aom_codec_err_t set_av1_tune_content(aom_codec_ctx_t *ctx, int value) {
+	return aom_codec_control(ctx, AV1E_SET_TUNE_CONTENT, value);
+}
+
+aom_codec_err_t set_av1_row_mt(aom_codec_ctx_t *ctx, int value) {
+	return aom_codec_control(ctx, AV1E_SET_ROW_MT, value);
+}
+
+aom_codec_err_t set_av1_screen_tools(aom_codec_ctx_t *ctx, int value) {
+	return aom_codec_control(ctx, AV1E_SET_ENABLE_PALETTE, value);
+}
+
+aom_codec_err_t set_av1_aq_mode(aom_codec_ctx_t *ctx, int value) {
+	return aom_codec_control(ctx, AV1E_SET_AQ_MODE, value);
+}
+
+int aom_img_plane_width(const aom_image_t *img, int plane) {
+	if (plane > 0 && img->x_chroma_shift > 0)
+		return (img->d_w + 1) >> img->x_chroma_shift;
+	else
+		return img->d_w;
+}
+
+int aom_img_plane_height(const aom_image_t *img, int plane) {
+	if (plane > 0 && img->y_chroma_shift > 0)
+		return (img->d_h + 1) >> img->y_chroma_shift;
+	else
+		return img->d_h;
+}
+
+void aom_img_read(aom_image_t *dst, void *src) {
+	for (int plane = 0; plane < 3; ++plane) {
+		unsigned char *buf = dst->planes[plane];
+		const int stride = dst->stride[plane];
+		const int w = aom_img_plane_width(dst, plane);
+		const int h = aom_img_plane_height(dst, plane);
+
+		for (int y = 0; y < h; ++y) {
+			memcpy(buf, src, w);
+			buf += stride;
+			src += w;
+		}
+	}
+}
+*/
+import "C"
+import (
+	"fmt"
+	"runtime"
+	"unsafe"
+)
+
+type Av1 struct {
+	frameCount C.int
+	image      C.aom_image_t
+	codecCtx   C.aom_codec_ctx_t
+	cfg        C.aom_codec_enc_cfg_t
+	kfi        C.int
+	kf         bool // force a keyframe for the next frame
+}
+
+type Options struct {
+	// Target bandwidth to use for this stream, in kilobits per second.
+	Bitrate uint
+	// Speed/quality tradeoff (0=auto, 6–10 for the real-time mode).
+	CpuUsed int
+	// Log2 of tile columns for frame-level parallelism (0–6).
+	TileColumns int
+	// Content tuning: "screen" for game/screen content.
+	Tune string
+}
+
+func autoThreads(configured, cpus int) int {
+	if configured != 0 {
+		return configured
+	}
+	reserve := max(cpus/4, 1)
+	if t := cpus - reserve; t > 0 {
+		return t
+	}
+	return 1
+}
+
+func NewEncoder(w, h int, th int, kfi int, opts *Options) (*Av1, error) {
+	if opts == nil {
+		opts = &Options{
+			Bitrate: 1000,
+			Tune:    "screen",
+		}
+	}
+	if kfi == 0 {
+		kfi = 120
+	}
+
+	av1 := Av1{kfi: C.int(kfi)}
+
+	if C.aom_img_alloc(&av1.image, C.AOM_IMG_FMT_I420, C.uint(w), C.uint(h), 1) == nil {
+		return nil, fmt.Errorf("aom_img_alloc failed")
+	}
+
+	cfg := &av1.cfg
+	if C.call_aom_codec_enc_config_default(cfg) != 0 {
+		C.aom_img_free(&av1.image)
+		return nil, fmt.Errorf("failed to get default codec config")
+	}
+
+	threads := autoThreads(th, runtime.NumCPU())
+	cfg.g_w = C.uint(w)
+	cfg.g_h = C.uint(h)
+	cfg.g_threads = C.uint(threads)
+	cfg.g_lag_in_frames = 0
+	cfg.g_error_resilient = 0
+	cfg.rc_end_usage = C.AOM_CBR
+	cfg.rc_target_bitrate = C.uint(opts.Bitrate)
+	// keyframes are forced by the encoder
+	cfg.kf_mode = C.AOM_KF_DISABLED
+
+	if C.call_aom_codec_enc_init(&av1.codecCtx, cfg) != 0 {
+		C.aom_img_free(&av1.image)
+		return nil, fmt.Errorf("failed to initialize encoder")
+	}
+
+	// Speed/quality tradeoff: higher = faster, lower = better quality.
+	if opts.CpuUsed == 0 {
+		switch {
+		case threads <= 2:
+			opts.CpuUsed = 10
+		case threads <= 4:
+			opts.CpuUsed = 9
+		default:
+			opts.CpuUsed = 8
+		}
+	}
+	C.set_aom_cpu_used(&av1.codecCtx, C.int(opts.CpuUsed))
+
+	if opts.TileColumns == 0 {
+		if threads >= 4 {
+			opts.TileColumns = 2
+		} else if threads >= 2 {
+			opts.TileColumns = 1
+		}
+	}
+	if opts.TileColumns > 0 {
+		C.set_av1_tile_columns(&av1.codecCtx, C.int(opts.TileColumns))
+	}
+	if opts.Tune == "screen" {
+		C.set_av1_tune_content(&av1.codecCtx, C.AOM_CONTENT_SCREEN)
+		// palette mode is good for the low-color game graphics
+		C.set_av1_screen_tools(&av1.codecCtx, 1)
+	}
+	C.set_av1_row_mt(&av1.codecCtx, 1)
+	// cyclic refresh, the default of the real-time mode in WebRTC
+	C.set_av1_aq_mode(&av1.codecCtx, 3)
+
+	return &av1, nil
+}
+
+// Encode encodes yuv image with the AV1 encoder.
+// see: https://aomedia.googlesource.com/aom/+/refs/heads/main/examples/simple_encoder.c
+func (e *Av1) Encode(yuv []byte) []byte {
+	C.aom_img_read(&e.image, unsafe.Pointer(&yuv[0]))
+
+	var flags C.aom_enc_frame_flags_t
+	if e.kf || e.frameCount == 0 || (e.kfi > 0 && e.frameCount%e.kfi == 0) {
+		flags |= C.AOM_EFLAG_FORCE_KF
+		e.kf = false
+	}
+	C.aom_codec_encode(&e.codecCtx, &e.image, C.aom_codec_pts_t(e.frameCount), 1, flags)
+	e.frameCount++
+
+	var iter C.aom_codec_iter_t
+	fb := C.get_frame_buffer(&e.codecCtx, &iter)
+	if fb.ptr == nil {
+		return nil
+	}
+
+	// zero-copy slice view into the encoder's internal buffer
+	return unsafe.Slice((*byte)(fb.ptr), fb.size)
+}
+
+// SetBitrate changes the target bitrate (Kbit/s) of the encoder.
+func (e *Av1) SetBitrate(kbps int) error {
+	e.cfg.rc_target_bitrate = C.uint(kbps)
+	if C.aom_codec_enc_config_set(&e.codecCtx, &e.cfg) != 0 {
+		return fmt.Errorf("aom: config set fail, %v", C.GoString(C.aom_codec_error(&e.codecCtx)))
+	}
+	return nil
+}
+
+func (e *Av1) Info() string {
+	return fmt.Sprintf("av1: %v", C.GoString(C.aom_codec_version_str()))
+}
+
+// IntraRefresh makes the next frame a keyframe.
+func (e *Av1) IntraRefresh() { e.kf = true }
+
+func (e *Av1) Shutdown() error {
+	C.aom_img_free(&e.image)
+	C.aom_codec_destroy(&e.codecCtx)
+	return nil
+}
@@ -5,6 +5,8 @@ import (
 	"sync/atomic"
 
 	"github.com/giongto35/cloud-game/v3/pkg/config"
+	"github.com/giongto35/cloud-game/v3/pkg/encoder/av1"
+	"github.com/giongto35/cloud-game/v3/pkg/encoder/filter"
 	"github.com/giongto35/cloud-game/v3/pkg/encoder/h264"
 	"github.com/giongto35/cloud-game/v3/pkg/encoder/vpx"
 	"github.com/giongto35/cloud-game/v3/pkg/encoder/yuv"
@@ -16,8 +18,11 @@ type (
 	OutFrame []byte
 	Encoder  interface {
 		Encode([]byte) []byte
+		// IntraRefresh forces a keyframe for the next frame.
 		IntraRefresh()
 		Info() string
+		// SetBitrate changes the target bitrate (Kbit/s) of the running encoder.
+		SetBitrate(kbps int) error
 		Shutdown() error
 	}
 )
@@ -26,7 +31,10 @@ type Video struct {
 	codec   Encoder
 	log     *logger.Logger
 	stopped atomic.Bool
+	bitrate atomic.Int64 // a new bitrate for the next frame
+	kf      atomic.Bool  // a keyframe request for the next frame
 	y       yuv.Conv
+	f       *filter.Filter // optional
 	pf      yuv.PixFmt
 	rot     uint
 }
@@ -38,6 +46,7 @@ const (
 	VP8  VideoCodec = "vp8"
 	VP9  VideoCodec = "vp9"
 	VPX  VideoCodec = "vpx"
+	AV1  VideoCodec = "av1"
 )
 
 // NewVideoEncoder returns new video encoder.
@@ -45,7 +54,13 @@ const (
 // converts them into YUV I420 format,
 // encodes with provided video encoder, and
 // puts the result into the output channel.
+// The filter of the config changes the size of the encoded frames.
 func NewVideoEncoder(w, h, dw, dh int, scale float64, conf config.Video, log *logger.Logger) (*Video, error) {
+	f := filter.New(dw, dh, conf.Filter)
+	if f != nil {
+		dw, dh = f.Size()
+	}
+
 	var enc Encoder
 	var err error
 	codec := VideoCodec(conf.Codec)
@@ -60,6 +75,9 @@ func NewVideoEncoder(w, h, dw, dh int, scale float64, conf config.Video, log *lo
 			v = 9
 		}
 		enc, err = vpx.NewEncoder(dw, dh, conf.Threads, conf.KeyframeInterval, v, &opts)
+	case AV1:
+		opts := av1.Options(conf.Av1)
+		enc, err = av1.NewEncoder(dw, dh, conf.Threads, conf.KeyframeInterval, &opts)
 	default:
 		err = fmt.Errorf("unsupported codec: %v", conf.Codec)
 	}
@@ -70,7 +88,7 @@ func NewVideoEncoder(w, h, dw, dh int, scale float64, conf config.Video, log *lo
 		return nil, fmt.Errorf("no encoder")
 	}
 
-	return &Video{codec: enc, y: yuv.NewYuvConv(w, h, scale), log: log}, nil
+	return &Video{codec: enc, y: yuv.NewYuvConv(w, h, scale), f: f, log: log}, nil
 }
 
 func (v *Video) Encode(frame InFrame) OutFrame {
@@ -78,7 +96,19 @@ func (v *Video) Encode(frame InFrame) OutFrame {
 		return nil
 	}
 
+	if kbps := v.bitrate.Swap(0); kbps > 0 {
+		if err := v.codec.SetBitrate(int(kbps)); err != nil && v.log != nil {
+			v.log.Error().Err(err).Msg("failed to change the bitrate")
+		}
+	}
+	if v.kf.Swap(false) {
+		v.codec.IntraRefresh()
+	}
+
 	yCbCr := v.y.Process(yuv.RawFrame(frame), v.rot, v.pf)
+	if v.f != nil {
+		yCbCr = v.f.Process(yCbCr)
+	}
 	//defer v.y.Put(&yCbCr)
 	if bytes := v.codec.Encode(yCbCr); len(bytes) > 0 {
 		return bytes
@@ -86,6 +116,23 @@ func (v *Video) Encode(frame InFrame) OutFrame {
 	return nil
 }
 
+// SetBitrate sets the bitrate (Kbit/s) of the encoder,
+// it will be changed right before the next frame.
+func (v *Video) SetBitrate(kbps int) {
+	if v == nil || kbps <= 0 {
+		return
+	}
+	v.bitrate.Store(int64(kbps))
+}
+
+// KeyFrame makes the next frame a keyframe.
+func (v *Video) KeyFrame() {
+	if v == nil {
+		return
+	}
+	v.kf.Store(true)
+}
+
 func (v *Video) Info() string {
 	return fmt.Sprintf("%v, libyuv: %v", v.codec.Info(), v.y.Version())
 }
@@ -0,0 +1,157 @@
+// Package filter contains the post-processing of the video frames (I420) before the encoder:
+// sharpening, integer nearest-neighbour upscaling, scanlines and letterboxing.
+package filter
+
+import "github.com/giongto35/cloud-game/v3/pkg/config"
+
+const (
+	black  = 16 // Y
+	grey   = 128
+	maxMul = 256
+)
+
+type Filter struct {
+	sw, sh int // source
+	w, h   int // output
+	x, y   int // the offset of the image in the output
+	cw, ch int // the size of the image in the output
+
+	sharpen   int // x/256
+	scanlines int // the darkness of the scanlines x/256
+
+	out []byte
+	tmp []byte
+}
+
+// New returns the filter of the I420 frames with the size w x h or nil
+// if the filter doesn't change the frames.
+func New(w, h int, conf config.VideoFilter) *Filter {
+	if !Enabled(conf) || w <= 0 || h <= 0 {
+		return nil
+	}
+	f := Filter{sw: w, sh: h, w: w, h: h, cw: w, ch: h}
+
+	if n := conf.Scale; n > 1 {
+		f.cw, f.ch = w*n, h*n
+	}
+	if conf.Width > 0 && conf.Height > 0 {
+		f.w, f.h = even(conf.Width), even(conf.Height)
+		if f.cw > f.w || f.ch > f.h {
+			// the max integer scale that fits or any that fits
+			if n := min(f.w/w, f.h/h); n >= 1 {
+				f.cw, f.ch = w*n, h*n
+			} else {
+				k := min(float64(f.w)/float64(w), float64(f.h)/float64(h))
+				f.cw, f.ch = max(even(int(float64(w)*k)), 2), max(even(int(float64(h)*k)), 2)
+			}
+		}
+		f.x, f.y = even((f.w-f.cw)/2), even((f.h-f.ch)/2)
+	} else {
+		f.w, f.h = f.cw, f.ch
+	}
+
+	f.sharpen = int(clamp(conf.Sharpen) * maxMul)
+	f.scanlines = int(clamp(conf.Scanlines) * maxMul)
+
+	// the black borders
+	f.out = make([]byte, f.w*f.h+2*((f.w+1)/2)*((f.h+1)/2))
+	for i := range f.out {
+		f.out[i] = grey
+		if i < f.w*f.h {
+			f.out[i] = black
+		}
+	}
+	if f.sharpen > 0 {
+		f.tmp = make([]byte, w*h)
+	}
+	return &f
+}
+
+// Enabled checks if the filter changes the frames.
+func Enabled(conf config.VideoFilter) bool {
+	return conf.Scale > 1 || conf.Scanlines > 0 || conf.Sharpen > 0 || (conf.Width > 0 && conf.Height > 0)
+}
+
+// Size returns the size of the output frames.
+func (f *Filter) Size() (int, int) { return f.w, f.h }
+
+// Process returns the filtered frame, the source frame can be changed.
+func (f *Filter) Process(frame []byte) []byte {
+	if f.sharpen > 0 {
+		f.sharpenY(frame[:f.sw*f.sh])
+	}
+
+	sw2, sh2 := (f.sw+1)/2, (f.sh+1)/2
+	w2, h2 := (f.w+1)/2, (f.h+1)/2
+	ys, us := f.sw*f.sh, f.sw*f.sh+sw2*sh2
+	yd, ud := f.w*f.h, f.w*f.h+w2*h2
+
+	nearest(frame[:ys], f.sw, f.sh, f.out[:yd], f.w, f.x, f.y, f.cw, f.ch)
+	nearest(frame[ys:us], sw2, sh2, f.out[yd:ud], w2, f.x/2, f.y/2, f.cw/2, f.ch/2)
+	nearest(frame[us:], sw2, sh2, f.out[ud:], w2, f.x/2, f.y/2, f.cw/2, f.ch/2)
+
+	if f.scanlines > 0 {
+		f.scanlinesY()
+	}
+	return f.out
+}
+
+// nearest scales the plane (w x h) into the area (x, y, dw x dh)
+// of the plane with the stride.
+func nearest(src []byte, w, h int, dst []byte, stride, x, y, dw, dh int) {
+	if dw <= 0 || dh <= 0 {
+		return
+	}
+	if dw == w && dh == h {
+		for j := range h {
+			copy(dst[(y+j)*stride+x:], src[j*w:(j+1)*w])
+		}
+		return
+	}
+	for j := range dh {
+		row := src[(j*h/dh)*w:]
+		out := dst[(y+j)*stride+x : (y+j)*stride+x+dw]
+		if j > 0 && j*h/dh == (j-1)*h/dh {
+			copy(out, dst[(y+j-1)*stride+x:])
+			continue
+		}
+		for i := range out {
+			out[i] = row[i*w/dw]
+		}
+	}
+}
+
+// scanlinesY darkens the last output row of each source row,
+// or each second row without the upscale.
+func (f *Filter) scanlinesY() {
+	up := f.ch >= 2*f.sh
+	for j := range f.ch {
+		if up && (j+1)*f.sh/f.ch == j*f.sh/f.ch || !up && j%2 == 0 {
+			continue
+		}
+		row := f.out[(f.y+j)*f.w+f.x:][:f.cw]
+		for i, v := range row {
+			row[i] = byte(black + (int(v)-black)*(maxMul-f.scanlines)/maxMul)
+		}
+	}
+}
+
+// sharpenY sharpens the luma plane with the unsharp mask of the 3x3 cross.
+func (f *Filter) sharpenY(y []byte) {
+	w, h := f.sw, f.sh
+	copy(f.tmp, y)
+	src := f.tmp
+	for j := 1; j < h-1; j++ {
+		for i := 1; i < w-1; i++ {
+			c := int(src[j*w+i])
+			blur := (4*c + int(src[j*w+i-1]) + int(src[j*w+i+1]) + int(src[(j-1)*w+i]) + int(src[(j+1)*w+i])) >> 3
+			y[j*w+i] = clip(c + 2*(c-blur)*f.sharpen/maxMul)
+		}
+	}
+}
+
+func clip(x int) byte { return byte(min(max(x, 0), 255)) }
+
+func clamp(x float64) float64 { return min(max(x, 0), 1) }
+
+func even(x int) int { return x &^ 1 }
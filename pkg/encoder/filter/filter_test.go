@@ -0,0 +1,112 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/giongto35/cloud-game/v3/pkg/config"
+)
+
+// frame returns the I420 frame with the luma of x + y.
+func frame(w, h int) []byte {
+	f := make([]byte, w*h+2*((w+1)/2)*((h+1)/2))
+	for y := range h {
+		for x := range w {
+			f[y*w+x] = byte(100 + x + y)
+		}
+	}
+	for i := w * h; i < len(f); i++ {
+		f[i] = 90
+	}
+	return f
+}
+
+func TestNoFilter(t *testing.T) {
+	if f := New(4, 4, config.VideoFilter{Scale: 1}); f != nil {
+		t.Errorf("should be no filter")
+	}
+}
+
+func TestSize(t *testing.T) {
+	tests := []struct {
+		name   string
+		conf   config.VideoFilter
+		w, h   int
+		cw, ch int
+	}{
+		{name: "scale", conf: config.VideoFilter{Scale: 3}, w: 12, h: 6, cw: 12, ch: 6},
+		{name: "letterbox", conf: config.VideoFilter{Width: 20, Height: 10}, w: 20, h: 10, cw: 4, ch: 2},
+		{name: "letterbox with scale", conf: config.VideoFilter{Scale: 2, Width: 20, Height: 10}, w: 20, h: 10, cw: 8, ch: 4},
+		{name: "max scale that fits", conf: config.VideoFilter{Scale: 9, Width: 20, Height: 10}, w: 20, h: 10, cw: 20, ch: 10},
+		{name: "downscale", conf: config.VideoFilter{Width: 2, Height: 2}, w: 2, h: 2, cw: 2, ch: 2},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f := New(4, 2, test.conf)
+			if w, h := f.Size(); w != test.w || h != test.h || f.cw != test.cw || f.ch != test.ch {
+				t.Errorf("got %vx%v (%vx%v), want %vx%v (%vx%v)", w, h, f.cw, f.ch, test.w, test.h, test.cw, test.ch)
+			}
+			if out := f.Process(frame(4, 2)); len(out) != test.w*test.h*3/2 {
+				t.Errorf("wrong frame size: %v", len(out))
+			}
+		})
+	}
+}
+
+func TestUpscale(t *testing.T) {
+	f := New(4, 2, config.VideoFilter{Scale: 2})
+	out := f.Process(frame(4, 2))
+	for y := range 4 {
+		for x := range 8 {
+			if want := byte(100 + x/2 + y/2); out[y*8+x] != want {
+				t.Fatalf("wrong pixel (%v,%v): %v, want %v", x, y, out[y*8+x], want)
+			}
+		}
+	}
+	for _, v := range out[32:] {
+		if v != 90 {
+			t.Fatalf("wrong chroma: %v", v)
+		}
+	}
+}
+
+func TestLetterbox(t *testing.T) {
+	f := New(4, 2, config.VideoFilter{Width: 8, Height: 6})
+	out := f.Process(frame(4, 2))
+	// the image is at (2, 2)
+	if out[0] != black || out[2*8+1] != black || out[4*8+2] != black {
+		t.Errorf("should be black borders")
+	}
+	if out[2*8+2] != 100 || out[3*8+5] != 104 {
+		t.Errorf("wrong image: %v %v", out[2*8+2], out[3*8+5])
+	}
+	if u := out[8*6]; u != grey {
+		t.Errorf("wrong border chroma: %v", u)
+	}
+}
+
+func TestScanlines(t *testing.T) {
+	f := New(4, 2, config.VideoFilter{Scale: 2, Scanlines: 1})
+	out := f.Process(frame(4, 2))
+	for y := range 4 {
+		dark := y%2 == 1
+		if (out[y*8] == black) != dark {
+			t.Errorf("wrong scanline %v: %v", y, out[y*8])
+		}
+	}
+}
+
+func TestSharpen(t *testing.T) {
+	src := make([]byte, 3*3+2*2*2)
+	for i := range 9 {
+		src[i] = 100
+	}
+	src[4] = 120
+	f := New(3, 3, config.VideoFilter{Sharpen: 1})
+	out := f.Process(src)
+	if out[4] <= 120 {
+		t.Errorf("should sharpen: %v", out[4])
+	}
+	if out[0] != 100 {
+		t.Errorf("should keep the edges: %v", out[0])
+	}
+}
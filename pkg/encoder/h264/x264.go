@@ -50,8 +50,9 @@ h264 *h264_new(x264_param_t *param)
     return h;
 }
 
-int h264_encode(h264 *h, uint8_t *yuv)
+int h264_encode(h264 *h, uint8_t *yuv, int idr)
 {
+	h->pic.i_type = idr ? X264_TYPE_IDR : X264_TYPE_AUTO;
 	h->pic.img.plane[0] = yuv;
 	h->pic.img.plane[1] = h->pic.img.plane[0] + h->y;
 	h->pic.img.plane[2] = h->pic.img.plane[1] + h->uv;
@@ -75,7 +76,9 @@ import (
 )
 
 type H264 struct {
-	h *C.h264
+	h     *C.h264
+	param C.x264_param_t
+	idr   bool // force IDR for the next frame
 }
 
 type Options struct {
@@ -177,18 +180,35 @@ func NewEncoder(w, h int, th int, kfi int, opts *Options) (encoder *H264, err er
 	if h264 == nil {
 		return nil, fmt.Errorf("x264: cannot open the encoder")
 	}
-	return &H264{h264}, nil
+	return &H264{h: h264, param: param}, nil
 }
 
 func (e *H264) Encode(yuv []byte) []byte {
-	bytes := C.h264_encode(e.h, (*C.uchar)(unsafe.SliceData(yuv)))
+	var idr C.int
+	if e.idr {
+		idr, e.idr = 1, false
+	}
+	bytes := C.h264_encode(e.h, (*C.uchar)(unsafe.SliceData(yuv)), idr)
 	// we merge multiple NALs stored in **nal into a single byte stream
 	// ret contains the total size of NALs in bytes, i.e. each e.nal[...].p_payload * i_payload
 	return unsafe.Slice((*byte)(e.h.nal.p_payload), bytes)
 }
 
-func (e *H264) IntraRefresh() {
-	// !to implement
+// IntraRefresh makes the next frame an IDR frame.
+func (e *H264) IntraRefresh() { e.idr = true }
+
+// SetBitrate changes the VBV max bitrate (Kbit/s) of the encoder.
+// The VBV can't be turned on if it wasn't on at the start, so MaxRate should be set.
+func (e *H264) SetBitrate(kbps int) error {
+	if e.param.rc.i_vbv_max_bitrate == 0 {
+		return fmt.Errorf("x264: no VBV, set maxRate to change the bitrate")
+	}
+	e.param.rc.i_bitrate = C.int(kbps)
+	e.param.rc.i_vbv_max_bitrate = C.int(kbps)
+	if C.x264_encoder_reconfig(e.h.h, &e.param) < 0 {
+		return fmt.Errorf("x264: reconfig fail")
+	}
+	return nil
 }
 
 func (e *H264) Info() string { return fmt.Sprintf("x264: v%v", Version()) }
@@ -48,7 +48,26 @@ type Encoder struct {
 	st  *C.struct_OpusEncoder
 }
 
-func NewEncoder(outFq int) (*Encoder, error) {
+type Options struct {
+	// Target bitrate of the encoder in bits per second.
+	Bitrate int
+	// Complexity of the encoder (0–10).
+	Complexity int
+	// Discontinuous transmission, skips the silence.
+	DTX bool
+	// In-band forward error correction of the lost packets.
+	FEC bool
+	// Expected packet loss percentage (0–100).
+	PacketLoss int
+}
+
+func NewEncoder(outFq int, opts *Options) (*Encoder, error) {
+	if opts == nil {
+		opts = &Options{
+			Bitrate:    96000,
+			Complexity: 5,
+		}
+	}
 	mem := make([]byte, C.opus_encoder_get_size(stereo))
 	out := make([]byte, 1024)
 	enc := Encoder{
@@ -61,8 +80,11 @@ func NewEncoder(outFq int) (*Encoder, error) {
 		return nil, fmt.Errorf("opus: initializatoin error (%v)", err)
 	}
 	_ = enc.SetMaxBandwidth(FullBand)
-	_ = enc.SetBitrate(96000)
-	_ = enc.SetComplexity(5)
+	_ = enc.SetBitrate(Bitrate(opts.Bitrate))
+	_ = enc.SetComplexity(opts.Complexity)
+	_ = enc.SetDTX(opts.DTX)
+	_ = enc.SetFEC(opts.FEC)
+	_ = enc.SetPacketLossPerc(opts.PacketLoss)
 
 	return &enc, nil
 }
@@ -118,8 +118,10 @@ type Vpx struct {
 	frameCount C.int
 	image      C.vpx_image_t
 	codecCtx   C.vpx_codec_ctx_t
+	cfg        C.vpx_codec_enc_cfg_t
 	kfi        C.int
 	v          int
+	kf         bool // force a keyframe for the next frame
 }
 
 func autoThreads(configured, cpus int) int {
@@ -173,8 +175,8 @@ func NewEncoder(w, h int, th int, kfi int, version int, opts *Options) (*Vpx, er
 	}
 	encoder := &C.vpx_encoders[idx]
 
-	var cfg C.vpx_codec_enc_cfg_t
-	if C.call_vpx_codec_enc_config_default(encoder, &cfg) != 0 {
+	cfg := &vpx.cfg
+	if C.call_vpx_codec_enc_config_default(encoder, cfg) != 0 {
 		return nil, fmt.Errorf("failed to get default codec config")
 	}
 
@@ -185,7 +187,7 @@ func NewEncoder(w, h int, th int, kfi int, version int, opts *Options) (*Vpx, er
 	cfg.rc_target_bitrate = C.uint(opts.Bitrate)
 	cfg.g_error_resilient = 0
 
-	if C.call_vpx_codec_enc_init(&vpx.codecCtx, encoder, &cfg) != 0 {
+	if C.call_vpx_codec_enc_init(&vpx.codecCtx, encoder, cfg) != 0 {
 		return nil, fmt.Errorf("failed to initialize encoder")
 	}
 
@@ -239,8 +241,9 @@ func (vpx *Vpx) Encode(yuv []byte) []byte {
 	C.vpx_img_read(&vpx.image, unsafe.Pointer(&yuv[0]))
 
 	var flags C.int
-	if vpx.frameCount < 3 || (vpx.kfi > 0 && vpx.frameCount%vpx.kfi == 0) {
+	if vpx.kf || vpx.frameCount < 3 || (vpx.kfi > 0 && vpx.frameCount%vpx.kfi == 0) {
 		flags |= C.VPX_EFLAG_FORCE_KF
+		vpx.kf = false
 	}
 	C.vpx_codec_encode(&vpx.codecCtx, &vpx.image, C.vpx_codec_pts_t(vpx.frameCount), 1, C.vpx_enc_frame_flags_t(flags), C.VPX_DL_REALTIME)
 	vpx.frameCount++
@@ -259,10 +262,18 @@ func (vpx *Vpx) Info() string {
 	return fmt.Sprintf("vpx (%v): %v", vpx.v, C.GoString(C.vpx_codec_version_str()))
 }
 
-func (vpx *Vpx) IntraRefresh() {
-	// !to implement
+// SetBitrate changes the target bitrate (Kbit/s) of the encoder.
+func (vpx *Vpx) SetBitrate(kbps int) error {
+	vpx.cfg.rc_target_bitrate = C.uint(kbps)
+	if C.vpx_codec_enc_config_set(&vpx.codecCtx, &vpx.cfg) != 0 {
+		return fmt.Errorf("vpx: config set fail, %v", C.GoString(C.vpx_codec_error(&vpx.codecCtx)))
+	}
+	return nil
 }
 
+// IntraRefresh makes the next frame a keyframe.
+func (vpx *Vpx) IntraRefresh() { vpx.kf = true }
+
 func (vpx *Vpx) Shutdown() error {
 	C.vpx_img_free(&vpx.image)
 	C.vpx_codec_destroy(&vpx.codecCtx)
@@ -0,0 +1,94 @@
+package games
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// CheatsDir is the folder with the cheat files of the games,
+// next to the game files in the library.
+//
+//	games/
+//	  cheats/
+//	    Sushi The Cat.cht
+//	  Sushi The Cat.gba
+const CheatsDir = "cheats"
+
+const cheatsExt = ".cht"
+
+// Cheat is a cheat code of a game.
+type Cheat struct {
+	Desc    string
+	Code    string
+	Enabled bool
+}
+
+// CheatsPath returns the path of the cheat file of the game.
+func (g GameMetadata) CheatsPath(base string) string {
+	return filepath.Join(filepath.Dir(g.FullPath(base)), CheatsDir, g.Name+cheatsExt)
+}
+
+// LoadCheats reads the cheat file or returns nothing if there is no such file.
+func LoadCheats(path string) ([]Cheat, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	return ParseCheats(f)
+}
+
+// ParseCheats reads the cheats in the format of the Libretro database (.cht):
+//
+//	cheats = 1
+//
+//	cheat0_desc = "Infinite Lives"
+//	cheat0_code = "SXIOPO"
+//	cheat0_enable = false
+//
+// The cheats without the codes are skipped.
+func ParseCheats(r io.Reader) ([]Cheat, error) {
+	kv := map[string]string{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		k, v, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		kv[strings.TrimSpace(k)] = strings.Trim(strings.TrimSpace(v), `"`)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	n, err := strconv.Atoi(kv["cheats"])
+	if err != nil {
+		return nil, errors.New("no number of cheats")
+	}
+	var cheats []Cheat
+	for i := range n {
+		key := "cheat" + strconv.Itoa(i) + "_"
+		code := kv[key+"code"]
+		if code == "" {
+			continue
+		}
+		desc := kv[key+"desc"]
+		if desc == "" {
+			desc = code
+		}
+		cheats = append(cheats, Cheat{Desc: desc, Code: code, Enabled: kv[key+"enable"] == "true"})
+	}
+	return cheats, nil
+}
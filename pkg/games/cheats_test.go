@@ -0,0 +1,57 @@
+package games
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseCheats(t *testing.T) {
+	cht := `cheats = 4
+
+# the codes
+cheat0_desc = "Infinite Lives"
+cheat0_code = "SXIOPO"
+cheat0_enable = false
+
+cheat1_desc = "Start on Level 3"
+cheat1_code = "PAXZLA+YZXZPA"
+cheat1_enable = true
+
+cheat2_desc = "Nothing"
+
+cheat3_code = "AEKPTZGA"
+`
+	cheats, err := ParseCheats(strings.NewReader(cht))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	want := []Cheat{
+		{Desc: "Infinite Lives", Code: "SXIOPO"},
+		{Desc: "Start on Level 3", Code: "PAXZLA+YZXZPA", Enabled: true},
+		{Desc: "AEKPTZGA", Code: "AEKPTZGA"},
+	}
+	if !reflect.DeepEqual(cheats, want) {
+		t.Errorf("got %+v, want %+v", cheats, want)
+	}
+
+	if _, err := ParseCheats(strings.NewReader("cheat0_code = 1")); err == nil {
+		t.Errorf("should fail without the number of cheats")
+	}
+}
+
+func TestLoadCheats(t *testing.T) {
+	cheats, err := LoadCheats(filepath.Join(t.TempDir(), "no.cht"))
+	if err != nil || cheats != nil {
+		t.Errorf("should be no cheats without the file: %v %v", cheats, err)
+	}
+}
+
+func TestCheatsPath(t *testing.T) {
+	game := GameMetadata{Name: "Sushi The Cat", Path: filepath.Join("gba", "Sushi The Cat.gba")}
+	want := filepath.Join("lib", "gba", CheatsDir, "Sushi The Cat.cht")
+	if path := game.CheatsPath("lib"); path != want {
+		t.Errorf("got %v, want %v", path, want)
+	}
+}
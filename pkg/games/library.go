@@ -245,6 +245,8 @@ func (lib *library) Scan() {
 		return
 	}
 
+	games = lib.withoutPlaylistFiles(games, dir)
+
 	if len(games) > 0 {
 		lib.set(games)
 	}
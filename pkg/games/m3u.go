@@ -0,0 +1,71 @@
+package games
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// playlistExt is the type of the playlists of multi-disc games,
+// the playlist is a single game while its files are skipped.
+//
+//	Game (Disc 1).cue
+//	Game (Disc 2).cue
+//	Game.m3u:
+//	  Game (Disc 1).cue
+//	  Game (Disc 2).cue|Disc 2
+const playlistExt = "m3u"
+
+// Playlist returns the full paths of the files in the .m3u playlist.
+func Playlist(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	dir := filepath.Dir(path)
+	var files []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		// the optional disk label after |
+		file, _, _ := strings.Cut(line, "|")
+		file = filepath.FromSlash(strings.TrimSpace(file))
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(dir, file)
+		}
+		files = append(files, filepath.Clean(file))
+	}
+	return files, scanner.Err()
+}
+
+// withoutPlaylistFiles removes the games which are the files of some playlist.
+func (lib *library) withoutPlaylistFiles(games []GameMetadata, base string) []GameMetadata {
+	files := map[string]struct{}{}
+	for _, g := range games {
+		if g.Type != playlistExt {
+			continue
+		}
+		list, err := Playlist(filepath.Join(base, g.Path))
+		if err != nil {
+			lib.log.Warn().Err(err).Str("playlist", g.Path).Msg("Lib bad playlist")
+			continue
+		}
+		for _, f := range list {
+			files[f] = struct{}{}
+		}
+	}
+	if len(files) == 0 {
+		return games
+	}
+	return slices.DeleteFunc(games, func(g GameMetadata) bool {
+		_, ok := files[filepath.Join(base, g.Path)]
+		return ok
+	})
+}
@@ -0,0 +1,59 @@
+package games
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/giongto35/cloud-game/v3/pkg/config"
+	"github.com/giongto35/cloud-game/v3/pkg/logger"
+)
+
+func TestPlaylist(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "Game.m3u")
+	m3u := "#EXTM3U\n\nGame (Disc 1).cue\ndiscs/Game (Disc 2).cue|Disc 2\n"
+	if err := os.WriteFile(path, []byte(m3u), 0644); err != nil {
+		t.Fatal(err)
+	}
+	files, err := Playlist(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{filepath.Join(dir, "Game (Disc 1).cue"), filepath.Join(dir, "discs", "Game (Disc 2).cue")}
+	if !slices.Equal(files, want) {
+		t.Errorf("got %v, want %v", files, want)
+	}
+}
+
+func TestLibraryScanPlaylist(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"Game (Disc 1).cue": "",
+		"Game (Disc 2).cue": "",
+		"Game.m3u":          "Game (Disc 1).cue\nGame (Disc 2).cue\n",
+		"Other.cue":         "",
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	emuConf := config.Emulator{Libretro: config.LibretroConfig{}}
+	emuConf.Libretro.Cores.List = map[string]config.LibretroCoreConfig{
+		"pcsx": {Roms: []string{"cue", "m3u"}},
+	}
+	library := NewLib(config.Library{BasePath: dir}, emuConf, logger.NewConsole(false, "w", false))
+	library.Scan()
+
+	var names []string
+	for _, g := range library.GetAll() {
+		names = append(names, g.Name)
+	}
+	slices.Sort(names)
+	if want := []string{"Game", "Other"}; !slices.Equal(names, want) {
+		t.Errorf("got %v, want %v", names, want)
+	}
+}
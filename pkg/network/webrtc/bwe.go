@@ -0,0 +1,118 @@
+package webrtc
+
+import (
+	"sync/atomic"
+
+	"github.com/pion/interceptor/pkg/cc"
+	"github.com/pion/rtcp"
+	"github.com/pion/webrtc/v4"
+)
+
+// Feedback is the network state of a peer
+// from the RTCP feedback of the remote side.
+type Feedback struct {
+	// Bitrate is the estimated available bitrate (bps), 0 if unknown.
+	Bitrate int
+	// Loss is the fraction of lost video packets (0..1).
+	Loss float64
+	// AudioLoss is the fraction of lost audio packets (0..1).
+	AudioLoss float64
+}
+
+// bandwidth collects the RTCP feedback of the media streams:
+// receiver reports (packet loss), REMB and TWCC (through the congestion control).
+type bandwidth struct {
+	cc    cc.BandwidthEstimator // optional
+	twcc  atomic.Bool           // the remote side sends TWCC feedback
+	remb  atomic.Int64
+	loss  atomic.Uint32 // the fraction of lost packets of the last report (x/256)
+	aloss atomic.Uint32 // of the audio
+}
+
+func (b *bandwidth) update(pkts []rtcp.Packet, ssrc uint32) {
+	for _, pkt := range pkts {
+		switch p := pkt.(type) {
+		case *rtcp.ReceiverReport:
+			for _, r := range p.Reports {
+				if r.SSRC == ssrc {
+					b.loss.Store(uint32(r.FractionLost))
+				}
+			}
+		case *rtcp.ReceiverEstimatedMaximumBitrate:
+			b.remb.Store(int64(p.Bitrate))
+		case *rtcp.TransportLayerCC:
+			b.twcc.Store(true)
+		}
+	}
+}
+
+// feedback returns the lowest of the bitrate estimates.
+func (b *bandwidth) feedback() Feedback {
+	f := Feedback{Loss: float64(b.loss.Load()) / 256, AudioLoss: float64(b.aloss.Load()) / 256}
+	if b.cc != nil && b.twcc.Load() {
+		f.Bitrate = b.cc.GetTargetBitrate()
+	}
+	if remb := int(b.remb.Load()); remb > 0 && (f.Bitrate == 0 || remb < f.Bitrate) {
+		f.Bitrate = remb
+	}
+	return f
+}
+
+// readFeedback reads the RTCP packets of the video stream
+// (the network feedback and keyframe requests) until the stream is closed.
+func (p *Peer) readFeedback(s *webrtc.RTPSender) {
+	ssrc := senderSSRC(s)
+	for {
+		pkts, _, err := s.ReadRTCP()
+		if err != nil {
+			return
+		}
+		p.bwe.update(pkts, ssrc)
+		if hasKeyframeRequest(pkts) {
+			if fn := p.onKeyframe.Load(); fn != nil {
+				(*fn)()
+			}
+		}
+	}
+}
+
+// readAudioFeedback reads the RTCP packets of the audio stream
+// (the packet loss) until the stream is closed.
+func (p *Peer) readAudioFeedback(s *webrtc.RTPSender) {
+	ssrc := senderSSRC(s)
+	for {
+		pkts, _, err := s.ReadRTCP()
+		if err != nil {
+			return
+		}
+		for _, pkt := range pkts {
+			if rr, ok := pkt.(*rtcp.ReceiverReport); ok {
+				for _, r := range rr.Reports {
+					if r.SSRC == ssrc {
+						p.bwe.aloss.Store(uint32(r.FractionLost))
+					}
+				}
+			}
+		}
+	}
+}
+
+func senderSSRC(s *webrtc.RTPSender) uint32 {
+	if enc := s.GetParameters().Encodings; len(enc) > 0 {
+		return uint32(enc[0].SSRC)
+	}
+	return 0
+}
+
+func hasKeyframeRequest(pkts []rtcp.Packet) bool {
+	for _, pkt := range pkts {
+		switch pkt.(type) {
+		case *rtcp.PictureLossIndication, *rtcp.FullIntraRequest:
+			return true
+		}
+	}
+	return false
+}
+
+// Feedback returns the current network state of the peer.
+func (p *Peer) Feedback() Feedback { return p.bwe.feedback() }
@@ -0,0 +1,81 @@
+package webrtc
+
+import (
+	"strings"
+
+	"github.com/pion/webrtc/v4"
+)
+
+// videoMime returns the mime type of the video codec or an empty string.
+func videoMime(codec string) string {
+	switch strings.ToLower(codec) {
+	case "h264":
+		return webrtc.MimeTypeH264
+	case "vpx", "vp8":
+		return webrtc.MimeTypeVP8
+	case "vp9":
+		return webrtc.MimeTypeVP9
+	case "av1":
+		return webrtc.MimeTypeAV1
+	}
+	return ""
+}
+
+// PickVideoCodec returns the first codec of the preference list supported by
+// the remote side or an empty string. The remote codecs are taken from its
+// SDP offer and the list of the codec mime types (i.e. video/VP8).
+func PickVideoCodec(prefs []string, offer string, remote []string) string {
+	if offer != "" {
+		remote = append(remote, offeredVideoCodecs(offer)...)
+	}
+	for _, c := range prefs {
+		mime := videoMime(c)
+		if mime == "" {
+			continue
+		}
+		for _, r := range remote {
+			if strings.EqualFold(r, mime) {
+				return c
+			}
+		}
+	}
+	return ""
+}
+
+// offeredVideoCodecs returns the mime types of the video codecs of the SDP offer.
+func offeredVideoCodecs(offer string) []string {
+	sdp, err := fromJson[webrtc.SessionDescription](offer)
+	if err != nil {
+		return nil
+	}
+	return SdpVideoCodecs(sdp.SDP)
+}
+
+// SdpVideoCodecs returns the mime types of the video codecs of the raw SDP.
+func SdpVideoCodecs(raw string) (mimes []string) {
+	sdp := webrtc.SessionDescription{Type: webrtc.SDPTypeOffer, SDP: raw}
+	desc, err := sdp.Unmarshal()
+	if err != nil {
+		return
+	}
+	for _, m := range desc.MediaDescriptions {
+		if m.MediaName.Media != "video" {
+			continue
+		}
+		for _, a := range m.Attributes {
+			// a=rtpmap:96 VP8/90000
+			if a.Key != "rtpmap" {
+				continue
+			}
+			if _, enc, ok := strings.Cut(a.Value, " "); ok {
+				if name, _, _ := strings.Cut(enc, "/"); name != "" {
+					mimes = append(mimes, "video/"+name)
+				}
+			}
+		}
+	}
+	return
+}
+
+// VideoCodec returns the video codec of the peer.
+func (p *Peer) VideoCodec() string { return p.vcodec }
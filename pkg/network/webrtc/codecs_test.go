@@ -0,0 +1,54 @@
+package webrtc
+
+import (
+	"testing"
+
+	"github.com/pion/webrtc/v4"
+)
+
+const testOffer = "v=0\r\n" +
+	"o=- 0 0 IN IP4 127.0.0.1\r\n" +
+	"s=-\r\n" +
+	"t=0 0\r\n" +
+	"m=video 9 UDP/TLS/RTP/SAVPF 96 98 102\r\n" +
+	"c=IN IP4 0.0.0.0\r\n" +
+	"a=mid:0\r\n" +
+	"a=recvonly\r\n" +
+	"a=rtpmap:96 VP8/90000\r\n" +
+	"a=rtpmap:98 VP9/90000\r\n" +
+	"a=rtpmap:102 H264/90000\r\n" +
+	"m=audio 9 UDP/TLS/RTP/SAVPF 111\r\n" +
+	"c=IN IP4 0.0.0.0\r\n" +
+	"a=mid:1\r\n" +
+	"a=recvonly\r\n" +
+	"a=rtpmap:111 opus/48000/2\r\n"
+
+func TestPickVideoCodec(t *testing.T) {
+	offer, err := toJson(webrtc.SessionDescription{Type: webrtc.SDPTypeOffer, SDP: testOffer})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		prefs  []string
+		offer  string
+		remote []string
+		want   string
+	}{
+		{name: "first of the offer", prefs: []string{"av1", "vp9", "h264"}, offer: offer, want: "vp9"},
+		{name: "vpx is vp8", prefs: []string{"vpx"}, offer: offer, want: "vpx"},
+		{name: "no common", prefs: []string{"av1"}, offer: offer},
+		{name: "no opus", prefs: []string{"opus"}, offer: offer},
+		{name: "remote list", prefs: []string{"av1", "h264"}, remote: []string{"video/H264", "video/AV1"}, want: "av1"},
+		{name: "no remote", prefs: []string{"h264"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := PickVideoCodec(test.prefs, test.offer, test.remote); got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -3,12 +3,15 @@ package webrtc
 import (
 	"fmt"
 	"net"
+	"sync"
 
 	"github.com/giongto35/cloud-game/v3/pkg/config"
 	"github.com/giongto35/cloud-game/v3/pkg/logger"
 	"github.com/giongto35/cloud-game/v3/pkg/network/socket"
 	"github.com/pion/ice/v4"
 	"github.com/pion/interceptor"
+	"github.com/pion/interceptor/pkg/cc"
+	"github.com/pion/interceptor/pkg/gcc"
 	"github.com/pion/interceptor/pkg/report"
 	"github.com/pion/webrtc/v4"
 )
@@ -16,11 +19,18 @@ import (
 type ApiFactory struct {
 	api  *webrtc.API
 	conf webrtc.Configuration
+
+	mu  sync.Mutex
+	bwe cc.BandwidthEstimator // the estimator of the last created connection
 }
 
+// bweInitialBitrate is the starting bitrate (bps) of the congestion control.
+const bweInitialBitrate = 1_000_000
+
 type ModApiFun func(m *webrtc.MediaEngine, i *interceptor.Registry, s *webrtc.SettingEngine)
 
 func NewApiFactory(conf config.Webrtc, log *logger.Logger, mod ModApiFun) (api *ApiFactory, err error) {
+	f := &ApiFactory{}
 	m := &webrtc.MediaEngine{}
 	if err = m.RegisterDefaultCodecs(); err != nil {
 		return
@@ -36,6 +46,26 @@ func NewApiFactory(conf config.Webrtc, log *logger.Logger, mod ModApiFun) (api *
 	} else if err = webrtc.RegisterDefaultInterceptors(m, i); err != nil {
 		return
 	}
+	if conf.CongestionControl {
+		if err = webrtc.ConfigureTWCCHeaderExtensionSender(m, i); err != nil {
+			return
+		}
+		var c *cc.InterceptorFactory
+		c, err = cc.NewInterceptor(func() (cc.BandwidthEstimator, error) {
+			// no pacer, the frames are sent as soon as possible
+			return gcc.NewSendSideBWE(
+				gcc.SendSideBWEInitialBitrate(bweInitialBitrate),
+				gcc.SendSideBWEPacer(gcc.NewNoOpPacer()),
+			)
+		})
+		if err != nil {
+			return
+		}
+		// called synchronously when a new connection is created
+		c.OnNewPeerConnection(func(_ string, e cc.BandwidthEstimator) { f.bwe = e })
+		i.Add(c)
+		log.Info().Msg("WebRTC congestion control is active")
+	}
 	customLogger := NewPionLogger(log, conf.LogLevel)
 	s := webrtc.SettingEngine{LoggerFactory: customLogger}
 	s.SetIncludeLoopbackCandidate(conf.IncludeLoopbackCandidate)
@@ -99,14 +129,19 @@ func NewApiFactory(conf config.Webrtc, log *logger.Logger, mod ModApiFun) (api *
 		})
 	}
 
-	return &ApiFactory{
-		api:  webrtc.NewAPI(webrtc.WithMediaEngine(m), webrtc.WithInterceptorRegistry(i), webrtc.WithSettingEngine(s)),
-		conf: c,
-	}, err
+	f.api = webrtc.NewAPI(webrtc.WithMediaEngine(m), webrtc.WithInterceptorRegistry(i), webrtc.WithSettingEngine(s))
+	f.conf = c
+	return f, err
 }
 
-func (a *ApiFactory) NewPeer() (*webrtc.PeerConnection, error) {
-	return a.api.NewPeerConnection(a.conf)
+// NewPeer creates a new connection and returns it with
+// its bandwidth estimator if the congestion control is enabled.
+func (a *ApiFactory) NewPeer() (*webrtc.PeerConnection, cc.BandwidthEstimator, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.bwe = nil
+	c, err := a.api.NewPeerConnection(a.conf)
+	return c, a.bwe, err
 }
 
 func filterIP(i net.IP, filter []string) bool {
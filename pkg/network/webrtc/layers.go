@@ -0,0 +1,22 @@
+package webrtc
+
+// SetVideoLayer sets the video layer the peer should switch to.
+func (p *Peer) SetVideoLayer(layer int) { p.layerWant.Store(int32(max(layer, 0))) }
+
+// VideoLayer returns the current and the wanted video layers of the peer.
+func (p *Peer) VideoLayer() (cur, want int) { return int(p.layer.Load()), int(p.layerWant.Load()) }
+
+// SendVideoLayers sends the frame of the video layer of the peer.
+// The layer is switched to the wanted one only on its keyframe,
+// so the decoder of the remote side could continue with the new frames.
+func (p *Peer) SendVideoLayers(frames [][]byte, keys []bool, dur int32) {
+	if len(frames) == 0 {
+		return
+	}
+	cur, want := p.VideoLayer()
+	if want != cur && want < len(keys) && keys[want] {
+		cur = want
+		p.layer.Store(int32(want))
+	}
+	p.SendVideo(frames[min(cur, len(frames)-1)], dur)
+}
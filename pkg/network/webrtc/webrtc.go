@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/giongto35/cloud-game/v3/pkg/logger"
@@ -22,7 +23,16 @@ type Peer struct {
 	v *webrtc.TrackLocalStaticSample
 	d *webrtc.DataChannel
 
-	onMessage func(data []byte)
+	vcodec string
+
+	bwe bandwidth
+
+	layer     atomic.Int32 // the current video layer
+	layerWant atomic.Int32
+
+	onMessage    func(data []byte)
+	onKeyframe   atomic.Pointer[func()]
+	onDisconnect atomic.Pointer[func()]
 }
 
 var samplePool sync.Pool
@@ -61,14 +71,19 @@ func (p *Peer) NewConnection(vCodec, aCodec string, signaller func(ice *string))
 
 	p.log.Debug().Msg("rtc start")
 
-	if p.c, err = p.api.NewPeer(); err != nil {
+	if p.c, p.bwe.cc, err = p.api.NewPeer(); err != nil {
 		return
 	}
 
 	p.c.OnConnectionStateChange(func(pcs webrtc.PeerConnectionState) {
 		p.log.Debug().Str("state", pcs.String()).Msg("rtc [connection] state change")
-		if pcs == webrtc.PeerConnectionStateConnected {
+		switch pcs {
+		case webrtc.PeerConnectionStateConnected:
 			p.log.Info().Str(logger.DirectionField, "rtc").Str(logger.ClientField, "").Msg("(connected)")
+		case webrtc.PeerConnectionStateFailed, webrtc.PeerConnectionStateClosed:
+			if fn := p.onDisconnect.Load(); fn != nil {
+				(*fn)()
+			}
 		}
 	})
 	p.c.OnICECandidate(func(ice *webrtc.ICECandidate) {
@@ -124,6 +139,22 @@ func (p *Peer) OfferAnswer(offer bool) (string, error) {
 	} else {
 		sdp, err = p.c.CreateAnswer(&DefaultAnswerOptions)
 	}
+	return p.setLocal(sdp, err)
+}
+
+// RestartICE returns a new offer with the new ICE credentials,
+// so the remote side could reconnect, i.e. after a network change.
+func (p *Peer) RestartICE() (string, error) {
+	if p.c == nil || p.c.SignalingState() != webrtc.SignalingStateStable {
+		return "", fmt.Errorf("no stable connection for ICE restart")
+	}
+	opts := DefaultOfferOptions
+	opts.ICERestart = true
+	p.log.Debug().Msg("rtc [ice] restart")
+	return p.setLocal(p.c.CreateOffer(&opts))
+}
+
+func (p *Peer) setLocal(sdp webrtc.SessionDescription, err error) (string, error) {
 	if err != nil {
 		return "", err
 	}
@@ -166,16 +197,13 @@ func newTrack(id string, label string, codec string) (*webrtc.TrackLocalStaticSa
 	codec = strings.ToLower(codec)
 	var mime string
 
-	switch id + "/" + codec {
-	case "audio/opus":
+	switch {
+	case id+"/"+codec == "audio/opus":
 		mime = webrtc.MimeTypeOpus
-	case "video/h264":
-		mime = webrtc.MimeTypeH264
-	case "video/vpx", "video/vp8":
-		mime = webrtc.MimeTypeVP8
-	case "video/vp9":
-		mime = webrtc.MimeTypeVP9
-	default:
+	case id == "video":
+		mime = videoMime(codec)
+	}
+	if mime == "" {
 		return nil, fmt.Errorf("unsupported codec %s:%s", id, codec)
 	}
 
@@ -188,11 +216,15 @@ func (p *Peer) handleICEState(state webrtc.ICEConnectionState) {
 	case webrtc.ICEConnectionStateChecking:
 		// nothing
 	case webrtc.ICEConnectionStateConnected:
+		// the video decoder of a (re)connected peer needs a keyframe
+		if fn := p.onKeyframe.Load(); fn != nil {
+			(*fn)()
+		}
 	case webrtc.ICEConnectionStateFailed:
 		p.log.Error().Msgf("WebRTC connection fail! connection: %v, ice: %v, gathering: %v, signalling: %v",
 			p.c.ConnectionState(), p.c.ICEConnectionState(), p.c.ICEGatheringState(),
 			p.c.SignalingState())
-		// make ICE restart
+		// the remote side requests ICE restart
 	case webrtc.ICEConnectionStateDisconnected:
 	case webrtc.ICEConnectionStateClosed:
 		p.Disconnect()
@@ -263,6 +295,7 @@ func (p *Peer) InitMedia(vCodec string, aCodec string) (err error) {
 	if p.v, err = newTrack("video", "video", vCodec); err != nil {
 		return err
 	}
+	p.vcodec = vCodec
 
 	if p.a, err = newTrack("audio", "audio", aCodec); err != nil {
 		return err
@@ -270,26 +303,22 @@ func (p *Peer) InitMedia(vCodec string, aCodec string) (err error) {
 
 	sendOnly := webrtc.RTPTransceiverInit{Direction: webrtc.RTPTransceiverDirectionSendonly}
 
-	if _, err = p.c.AddTransceiverFromTrack(p.v, sendOnly); err != nil {
+	vt, err := p.c.AddTransceiverFromTrack(p.v, sendOnly)
+	if err != nil {
 		return err
 	}
-	if _, err = p.c.AddTransceiverFromTrack(p.a, sendOnly); err != nil {
+	at, err := p.c.AddTransceiverFromTrack(p.a, sendOnly)
+	if err != nil {
 		return err
 	}
 
 	// Read incoming RTCP packets
 	// Before these packets are returned they are processed by interceptors. For things
 	// like NACK this needs to be called.
-	for _, rtpSender := range p.c.GetSenders() {
-		go func() {
-			rtcpBuf := make([]byte, 1500)
-			for {
-				if _, _, rtcpErr := rtpSender.Read(rtcpBuf); rtcpErr != nil {
-					return
-				}
-			}
-		}()
-	}
+	// The video feedback is used for the bandwidth estimation,
+	// the audio one for the expected packet loss of the audio encoder.
+	go p.readFeedback(vt.Sender())
+	go p.readAudioFeedback(at.Sender())
 
 	return nil
 }
@@ -298,6 +327,12 @@ func (p *Peer) OnMessage(fn func(data []byte)) {
 	p.onMessage = fn
 }
 
+// OnKeyframeRequest sets a callback for the keyframe requests (PLI/FIR) of the remote side.
+func (p *Peer) OnKeyframeRequest(fn func()) { p.onKeyframe.Store(&fn) }
+
+// OnDisconnect sets the callback of the failed or closed connection.
+func (p *Peer) OnDisconnect(fn func()) { p.onDisconnect.Store(&fn) }
+
 func (p *Peer) logx(err error) { p.log.Error().Err(err) }
 
 func fromJson[T any](data string) (T, error) {
@@ -0,0 +1,39 @@
+package webrtc
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pion/webrtc/v4"
+)
+
+// gatherTimeout is the max time of the ICE candidates gathering
+// for the signalling without the trickle ICE.
+const gatherTimeout = 5 * time.Second
+
+// AnswerSDP sets the raw SDP offer of the remote side and returns the raw SDP answer
+// with all the ICE candidates, i.e. for the WHEP signalling (RFC 9725).
+func (p *Peer) AnswerSDP(offer string) (string, error) {
+	if p.c == nil {
+		return "", fmt.Errorf("no connection")
+	}
+	err := p.c.SetRemoteDescription(webrtc.SessionDescription{Type: webrtc.SDPTypeOffer, SDP: offer})
+	if err != nil {
+		return "", err
+	}
+	answer, err := p.c.CreateAnswer(nil)
+	if err != nil {
+		return "", err
+	}
+	gathered := webrtc.GatheringCompletePromise(p.c)
+	if err = p.c.SetLocalDescription(answer); err != nil {
+		return "", err
+	}
+	select {
+	case <-gathered:
+	case <-time.After(gatherTimeout):
+		p.log.Warn().Msg("rtc [ice] gathering timeout")
+	}
+	p.log.Debug().Msg("rtc [sdp] (local) answer")
+	return p.c.LocalDescription().SDP, nil
+}
@@ -10,6 +10,7 @@ import (
 	"os/signal"
 	"os/user"
 	"syscall"
+	"time"
 )
 
 const ReadChunk = 1024
@@ -120,6 +121,14 @@ func StatSize(path string) (int64, error) {
 	return fi.Size(), nil
 }
 
+func StatTime(path string) (time.Time, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return fi.ModTime(), nil
+}
+
 func RemoveAll(path string) error {
 	return os.RemoveAll(path)
 }
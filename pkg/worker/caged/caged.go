@@ -8,6 +8,7 @@ import (
 	"github.com/giongto35/cloud-game/v3/pkg/logger"
 	"github.com/giongto35/cloud-game/v3/pkg/worker/caged/app"
 	"github.com/giongto35/cloud-game/v3/pkg/worker/caged/libretro"
+	"github.com/giongto35/cloud-game/v3/pkg/worker/caged/libretro/sandbox"
 )
 
 type Manager struct {
@@ -19,6 +20,8 @@ const (
 	RetroPad = libretro.RetroPad
 	Keyboard = libretro.Keyboard
 	Mouse    = libretro.Mouse
+	// RetroPadSync is RetroPad with frame numbers
+	RetroPadSync = libretro.RetroPadSync
 )
 
 type ModName string
@@ -31,6 +34,17 @@ func NewManager(log *logger.Logger) *Manager {
 
 func (m *Manager) Get(name ModName) app.App { return m.list[name] }
 
+// Spawn returns an app for a new room.
+// Apps that can run more than one instance (i.e. sandboxed)
+// return a new copy, the rest are shared between the rooms.
+func (m *Manager) Spawn(name ModName) app.App {
+	a := m.list[name]
+	if s, ok := a.(interface{ Spawn() app.App }); ok {
+		return s.Spawn()
+	}
+	return a
+}
+
 func (m *Manager) Load(name ModName, conf any) error {
 	if name == Libretro {
 		caged, err := m.loadLibretro(conf)
@@ -42,7 +56,7 @@ func (m *Manager) Load(name ModName, conf any) error {
 	return nil
 }
 
-func (m *Manager) loadLibretro(conf any) (*libretro.Caged, error) {
+func (m *Manager) loadLibretro(conf any) (app.App, error) {
 	s := reflect.ValueOf(conf)
 
 	e := s.FieldByName("Emulator")
@@ -63,5 +77,15 @@ func (m *Manager) loadLibretro(conf any) (*libretro.Caged, error) {
 	if err := caged.Init(); err != nil {
 		return nil, err
 	}
+
+	if c.Emulator.Sandbox.Enabled {
+		sc := sandbox.Conf{Emulator: c.Emulator, Recording: c.Recording}
+		if st := s.FieldByName("Storage"); st.IsValid() {
+			sc.Storage = st.Interface().(config.Storage)
+		}
+		m.log.Info().Msgf("Libretro runs in sandbox mode")
+		return sandbox.New(sc, m.log), nil
+	}
+
 	return &caged, nil
 }
@@ -1,11 +1,11 @@
 package libretro
 
 import (
+	"github.com/giongto35/cloud-game/v3/pkg/cloud"
 	"github.com/giongto35/cloud-game/v3/pkg/config"
 	"github.com/giongto35/cloud-game/v3/pkg/games"
 	"github.com/giongto35/cloud-game/v3/pkg/logger"
 	"github.com/giongto35/cloud-game/v3/pkg/worker/caged/libretro/manager"
-	"github.com/giongto35/cloud-game/v3/pkg/worker/cloud"
 )
 
 type Caged struct {
@@ -47,6 +47,7 @@ func (c *Caged) ReloadFrontend() {
 		c.log.Fatal().Err(err).Send()
 		return
 	}
+	frontend.EnableNetplay(c.conf.Emulator.Netplay)
 	c.Emulator = frontend
 	c.base = frontend
 }
@@ -62,6 +63,9 @@ func (c *Caged) Load(game games.GameMetadata, path string) error {
 	if err := c.Emulator.LoadGame(game.FullPath(path)); err != nil {
 		return err
 	}
+	if err := c.base.LoadCheats(game.CheatsPath(path)); err != nil {
+		c.log.Warn().Err(err).Msg("couldn't load cheats")
+	}
 	c.ViewportRecalculate()
 	return nil
 }
@@ -70,10 +74,24 @@ func (c *Caged) EnableRecording(nowait bool, user string, game string) {
 	if c.conf.Recording.Enabled {
 		// !to fix races with canvas pool when recording
 		c.base.DisableCanvasPool = true
-		c.Emulator = WithRecording(c.Emulator, nowait, user, game, c.conf.Recording, c.log)
+		var mr MovieRecorder
+		if c.conf.Recording.Movie {
+			mr = c.base
+		}
+		c.Emulator = WithRecording(c.Emulator, mr, nowait, user, game, c.conf.Recording, c.log)
+	}
+}
+
+// ToggleRecording switches the recording of the game, if it was enabled.
+func (c *Caged) ToggleRecording(active bool, user string) {
+	if rec, ok := c.Emulator.(*RecordingFrontend); ok {
+		rec.ToggleRecording(active, user)
 	}
 }
 
+// ReplayMovie replays the input movie instead of the live input of the users.
+func (c *Caged) ReplayMovie(path string) error { return c.base.ReplayMovie(path) }
+
 func (c *Caged) EnableCloudStorage(uid string, storage cloud.Storage) {
 	if storage == nil {
 		return
@@ -0,0 +1,71 @@
+package libretro
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/giongto35/cloud-game/v3/pkg/games"
+)
+
+var (
+	ErrNoCheat  = errors.New("no such cheat")
+	ErrNoCheats = errors.New("no cheats support")
+)
+
+// LoadCheats reads the cheats of the loaded game from the file
+// and applies the enabled ones.
+func (f *Frontend) LoadCheats(path string) error {
+	cheats, err := games.LoadCheats(path)
+	if err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.cheats = cheats
+	if len(cheats) > 0 {
+		f.log.Info().Msgf("Cheats: %v", len(cheats))
+		return f.applyCheats()
+	}
+	return nil
+}
+
+// Cheats returns the cheats of the game.
+func (f *Frontend) Cheats() []games.Cheat {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.cheats)
+}
+
+// SetCheat enables or disables the cheat with the index.
+func (f *Frontend) SetCheat(index int, enabled bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if index < 0 || index >= len(f.cheats) {
+		return ErrNoCheat
+	}
+	if !f.nano.HasCheats() {
+		return ErrNoCheats
+	}
+	if f.cheats[index].Enabled == enabled {
+		return nil
+	}
+	f.cheats[index].Enabled = enabled
+	return f.applyCheats()
+}
+
+// applyCheats sets all the enabled cheats anew, since
+// the cores can't disable cheats one by one.
+func (f *Frontend) applyCheats() error {
+	if !f.nano.HasCheats() {
+		return ErrNoCheats
+	}
+	f.nano.CheatReset()
+	i := 0
+	for _, c := range f.cheats {
+		if c.Enabled {
+			f.nano.CheatSet(i, true, c.Code)
+			i++
+		}
+	}
+	return nil
+}
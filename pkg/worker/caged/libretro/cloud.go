@@ -1,60 +1,204 @@
 package libretro
 
 import (
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/giongto35/cloud-game/v3/pkg/cloud"
 	"github.com/giongto35/cloud-game/v3/pkg/os"
-	"github.com/giongto35/cloud-game/v3/pkg/worker/cloud"
 )
 
 type CloudFrontend struct {
 	Emulator
 	uid     string
 	storage cloud.Storage // a cloud storage to store room state online
+	dir     []cloud.BundleFile
 }
 
+// Names of the files in the save bundle.
+const (
+	bundleState = "state"
+	bundleSRAM  = "sram"
+	bundleDir   = "dir/"
+)
+
 // WithCloud adds the ability to keep game states in the cloud storage like Amazon S3.
-// It supports only one file of main save state.
+// The main save is kept as a bundle of all the save files of the session
+// (the state, SRAM and the unique save directory of the core),
+// the save slots (with their thumbnails) are kept as is.
+// The autosave history isn't kept in the cloud, it's local to the worker.
 func WithCloud(fe Emulator, uid string, storage cloud.Storage) (*CloudFrontend, error) {
 	r := &CloudFrontend{Emulator: fe, uid: uid, storage: storage}
 
-	name := fe.SaveStateName()
+	if storage.Has(r.bundleName()) {
+		if err := r.restore(); err != nil {
+			return nil, err
+		}
+	} else {
+		// the main save state without the bundle
+		if err := r.fetch(fe.HashPath()); err != nil {
+			return nil, err
+		}
+	}
+	for _, slot := range fe.SlotNames() {
+		if isAutoSlot(slot) {
+			continue
+		}
+		path := fe.SlotPath(slot)
+		if err := r.fetch(path); err != nil {
+			return nil, err
+		}
+		if err := r.fetch(path + thumbExt); err != nil {
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+func (c *CloudFrontend) bundleName() string { return c.uid + cloud.BundleExt }
 
-	if r.storage.Has(name) {
-		data, err := r.storage.Load(fe.SaveStateName())
+// restore unpacks the save bundle from the cloud,
+// the save dir files are kept until the core is loaded.
+func (c *CloudFrontend) restore() error {
+	data, err := c.storage.Load(c.bundleName())
+	if err != nil {
+		return err
+	}
+	b, err := cloud.UnpackBundle(data)
+	if err != nil {
+		return err
+	}
+	for _, f := range b.Files {
+		switch {
+		case f.Name == bundleState:
+			err = os.WriteFile(c.HashPath(), f.Data, 0644)
+		case f.Name == bundleSRAM:
+			err = os.WriteFile(c.SRAMPath(), f.Data, 0644)
+		case strings.HasPrefix(f.Name, bundleDir):
+			c.dir = append(c.dir, cloud.BundleFile{Name: strings.TrimPrefix(f.Name, bundleDir), Data: f.Data})
+		}
 		if err != nil {
-			return nil, err
+			return err
 		}
-		// save the data fetched from the cloud to a local directory
-		if data != nil {
-			if err := os.WriteFile(fe.HashPath(), data, 0644); err != nil {
-				return nil, err
+	}
+	return nil
+}
+
+// bundle packs all the save files of the session.
+func (c *CloudFrontend) bundle() ([]byte, error) {
+	b := cloud.Bundle{Created: time.Now()}
+
+	state, err := os.ReadFile(c.HashPath())
+	if err != nil {
+		return nil, err
+	}
+	b.Files = append(b.Files, cloud.BundleFile{Name: bundleState, Data: state})
+	if sram, err := os.ReadFile(c.SRAMPath()); err == nil {
+		b.Files = append(b.Files, cloud.BundleFile{Name: bundleSRAM, Data: sram})
+	}
+
+	if dir := c.SaveDir(); dir != "" {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || !d.Type().IsRegular() {
+				return err
 			}
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			b.Files = append(b.Files, cloud.BundleFile{Name: bundleDir + filepath.ToSlash(rel), Data: data})
+			return nil
+		})
+		if err != nil {
+			return nil, err
 		}
 	}
+	return b.Pack()
+}
 
-	return r, nil
+// LoadCore restores the save dir files of the core from the bundle.
+func (c *CloudFrontend) LoadCore(emu string) {
+	c.Emulator.LoadCore(emu)
+	dir := c.SaveDir()
+	if dir == "" {
+		return
+	}
+	for _, f := range c.dir {
+		path := filepath.Join(dir, filepath.FromSlash(f.Name))
+		if err := os.MakeDirAll(filepath.Dir(path)); err == nil {
+			_ = os.WriteFile(path, f.Data, 0644)
+		}
+	}
+	c.dir = nil
+}
+
+// fetch saves the file from the cloud to a local directory.
+func (c *CloudFrontend) fetch(path string) error {
+	name := filepath.Base(path)
+	if !c.storage.Has(name) {
+		return nil
+	}
+	data, err := c.storage.Load(name)
+	if err != nil {
+		return err
+	}
+	if data != nil {
+		return os.WriteFile(path, data, 0644)
+	}
+	return nil
+}
+
+// push saves the local file into the cloud.
+func (c *CloudFrontend) push(path string, kind string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return c.storage.Save(filepath.Base(path), data, map[string]string{
+		"uid":  c.uid,
+		"type": kind,
+	})
 }
 
 // !to use emulator save/load calls instead of the storage
 
 func (c *CloudFrontend) HasSave() bool {
-	_, err := c.storage.Load(c.SaveStateName())
-	if err == nil {
-		return true
-	}
-	return c.Emulator.HasSave()
+	return c.storage.Has(c.bundleName()) || c.storage.Has(c.SaveStateName()) || c.Emulator.HasSave()
 }
 
 func (c *CloudFrontend) SaveGameState() error {
 	if err := c.Emulator.SaveGameState(); err != nil {
 		return err
 	}
-	path := c.Emulator.HashPath()
-	data, err := os.ReadFile(path)
+	data, err := c.bundle()
 	if err != nil {
 		return err
 	}
-	return c.storage.Save(c.SaveStateName(), data, map[string]string{
+	return c.storage.Save(c.bundleName(), data, map[string]string{
 		"uid":  c.uid,
-		"type": "cloudretro-main-save",
+		"type": "cloudretro-save-bundle",
 	})
 }
+
+func (c *CloudFrontend) SaveSlot(slot string) error {
+	if slot == "" {
+		return c.SaveGameState()
+	}
+	if err := c.Emulator.SaveSlot(slot); err != nil || isAutoSlot(slot) {
+		return err
+	}
+	path := c.Emulator.SlotPath(slot)
+	if err := c.push(path, "cloudretro-slot-save"); err != nil {
+		return err
+	}
+	// the thumbnail is optional
+	_ = c.push(path+thumbExt, "cloudretro-slot-thumb")
+	return nil
+}
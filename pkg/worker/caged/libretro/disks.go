@@ -0,0 +1,73 @@
+package libretro
+
+import "errors"
+
+var (
+	ErrNoDiskControl = errors.New("no disk control")
+	ErrNoDisk        = errors.New("no such disk")
+	ErrDiskControl   = errors.New("disk control failed")
+)
+
+// Disks is the state of the virtual disk tray of multi-disc games.
+type Disks struct {
+	Ejected bool
+	Index   int      // the current disk
+	Labels  []string // the names of the disks
+}
+
+// Disks returns the disks of the game.
+func (f *Frontend) Disks() (Disks, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.nano.HasDiskControl() {
+		return Disks{}, ErrNoDiskControl
+	}
+	return f.disks(), nil
+}
+
+// EjectDisk opens or closes the disk tray.
+func (f *Frontend) EjectDisk(ejected bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.nano.HasDiskControl() {
+		return ErrNoDiskControl
+	}
+	if f.nano.DiskEjected() != ejected && !f.nano.SetDiskEjected(ejected) {
+		return ErrDiskControl
+	}
+	return nil
+}
+
+// SetDisk changes the current disk. If the tray is closed,
+// it will be opened and closed with the new disk.
+func (f *Frontend) SetDisk(index int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.nano.HasDiskControl() {
+		return ErrNoDiskControl
+	}
+	if index < 0 || index >= f.nano.DiskCount() {
+		return ErrNoDisk
+	}
+	closed := !f.nano.DiskEjected()
+	if closed && !f.nano.SetDiskEjected(true) {
+		return ErrDiskControl
+	}
+	ok := f.nano.SetDiskIndex(index)
+	if closed && !f.nano.SetDiskEjected(false) {
+		ok = false
+	}
+	if !ok {
+		return ErrDiskControl
+	}
+	f.log.Info().Msgf("Disk: %v", index)
+	return nil
+}
+
+func (f *Frontend) disks() Disks {
+	d := Disks{Ejected: f.nano.DiskEjected(), Index: f.nano.DiskIndex()}
+	for i := range f.nano.DiskCount() {
+		d.Labels = append(d.Labels, f.nano.DiskLabel(i))
+	}
+	return d
+}
@@ -7,10 +7,12 @@ import (
 	"runtime"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 	"unsafe"
 
 	"github.com/giongto35/cloud-game/v3/pkg/config"
+	"github.com/giongto35/cloud-game/v3/pkg/games"
 	"github.com/giongto35/cloud-game/v3/pkg/logger"
 	"github.com/giongto35/cloud-game/v3/pkg/os"
 	"github.com/giongto35/cloud-game/v3/pkg/worker/caged/app"
@@ -43,6 +45,10 @@ type Emulator interface {
 	HashPath() string
 	// HasSave returns true if the current ROM was saved before
 	HasSave() bool
+	// SRAMPath returns the path emulator will save SRAM to
+	SRAMPath() string
+	// SaveDir returns the save directory of the core unique for the session or nothing
+	SaveDir() string
 	// Close will be called when the game is done
 	Close()
 	// Input passes input to the emulator
@@ -50,6 +56,38 @@ type Emulator interface {
 	// Scale returns set video scale factor
 	Scale() float64
 	Reset()
+	// SaveSlot saves the state into a save slot, "" is the main save
+	SaveSlot(slot string) error
+	// LoadSlot restores the state from a save slot
+	LoadSlot(slot string) error
+	// Slots returns the existing saves
+	Slots() []SaveSlot
+	// SlotNames returns the names of the additional save slots
+	SlotNames() []string
+	// SlotPath returns the path of the save slot file
+	SlotPath(slot string) string
+	// Cheats returns the cheats of the game
+	Cheats() []games.Cheat
+	// SetCheat enables or disables the cheat
+	SetCheat(index int, enabled bool) error
+	// Disks returns the disks of multi-disc games
+	Disks() (Disks, error)
+	// EjectDisk opens or closes the disk tray
+	EjectDisk(ejected bool) error
+	// SetDisk changes the current disk
+	SetDisk(index int) error
+	// Speed returns the speed of the emulation, 0 is pause
+	Speed() float64
+	// SetSpeed pauses, slows down or speeds up the emulation
+	SetSpeed(speed float64) error
+	// FrameAdvance runs one frame while paused
+	FrameAdvance() error
+	// Rewind goes back in time by the seconds, 0 stops it
+	Rewind(seconds float64) error
+	// CoreOptions returns the options of the core
+	CoreOptions() []CoreOption
+	// SetCoreOption changes the option of the core
+	SetCoreOption(key, value string) error
 }
 
 type Frontend struct {
@@ -70,6 +108,29 @@ type Frontend struct {
 
 	// skipVideo used when new frame was too late
 	skipVideo bool
+	// replay is set when the emulator replays frames after a rollback
+	replay atomic.Bool
+	// hideVideo is set for the skipped frames of the fast-forward
+	hideVideo bool
+	// rewound is set for the frames of the rewind
+	rewound bool
+	// slowAudio is the stretched audio of the slow motion
+	slowAudio []int16
+	// thumb is a copy of the last frame for the thumbnails of the save slots
+	thumb app.RawFrame
+
+	paused atomic.Bool
+	speed  atomic.Uint64 // float64 bits, 0 is the normal speed
+	steps  atomic.Int32  // the frames to advance during the pause
+	wake   chan struct{}
+
+	netplay *netplay
+	rewind  *rewind
+	movie   atomic.Pointer[movieState]
+	cheats  []games.Cheat
+
+	game   string // the name of the loaded game file
+	system string // the name of the loaded core
 
 	mu  sync.Mutex
 	mui sync.Mutex
@@ -86,6 +147,8 @@ const (
 	RetroPad = Device(nanoarch.RetroPad)
 	Keyboard = Device(nanoarch.Keyboard)
 	Mouse    = Device(nanoarch.Mouse)
+	// RetroPadSync is RetroPad input with the frame number (see netplay).
+	RetroPadSync = Device(nanoarch.Mouse + 1)
 )
 
 var (
@@ -136,6 +199,7 @@ func NewFrontend(conf config.Emulator, log *logger.Logger) (*Frontend, error) {
 		onVideo: noVideo,
 		storage: store,
 		th:      conf.Threads,
+		wake:    make(chan struct{}, 1),
 	}
 	f.linkNano(nano)
 
@@ -174,6 +238,7 @@ func (f *Frontend) LoadCore(emu string) {
 		LibExt:          libExt,
 	}
 	f.mu.Lock()
+	f.system = emu
 	f.SaveStateFs = conf.SaveStateFs
 	if conf.UniqueSaveDir {
 		f.UniqueSaveDir = true
@@ -188,17 +253,29 @@ func (f *Frontend) LoadCore(emu string) {
 	f.storage.SetNonBlocking(conf.NonBlockingSave)
 	f.scale = scale
 	f.isGL = conf.IsGlAllowed
+	f.rewind = newRewind(f.conf.Rewind, conf.RewindMemory)
 	f.nano.CoreLoad(meta)
 	f.mu.Unlock()
 }
 
 func (f *Frontend) handleAudio(audio unsafe.Pointer, samples int) {
+	// no audio of the replays, the rewind, the frame advance
+	// and the skipped frames of the fast-forward
+	speed := f.Speed()
+	if f.replay.Load() || f.rewound || f.hideVideo || speed == SpeedPause {
+		return
+	}
 	fr, _ := audioPool.Get().(*app.Audio)
 	if fr == nil {
 		fr = new(app.Audio)
 	}
 	// !to look if we need a copy
 	fr.Data = unsafe.Slice((*int16)(audio), samples)
+	// the slow motion audio fills the longer frame time
+	if speed < SpeedNormal {
+		f.slowAudio = stretch(f.slowAudio, fr.Data, speed)
+		fr.Data = f.slowAudio
+	}
 	// due to audio buffering for opus fixed frames and const duration up in the hierarchy,
 	// we skip Duration here
 	f.onAudio(*fr)
@@ -206,9 +283,13 @@ func (f *Frontend) handleAudio(audio unsafe.Pointer, samples int) {
 }
 
 func (f *Frontend) handleVideo(data []byte, delta int32, fi nanoarch.FrameInfo) {
-	if f.conf.SkipLateFrames && f.skipVideo {
+	if f.conf.SkipLateFrames && f.skipVideo || f.replay.Load() || f.hideVideo {
 		return
 	}
+	// the frames of the slow motion are longer
+	if s := f.Speed(); s > SpeedPause && s < SpeedNormal {
+		delta = int32(float64(delta) / s)
+	}
 
 	fr, _ := videoPool.Get().(*app.Video)
 	if fr == nil {
@@ -221,13 +302,23 @@ func (f *Frontend) handleVideo(data []byte, delta int32, fi nanoarch.FrameInfo)
 	fr.Duration = delta
 
 	lastFrame = fr
+	if f.conf.SaveSlots+f.conf.AutosaveHistory > 0 {
+		f.copyThumb(fr.Frame)
+	}
 	f.onVideo(*fr)
 
 	videoPool.Put(fr)
 }
 
+// copyThumb keeps the pixels of the frame,
+// because the core reuses its video buffer.
+func (f *Frontend) copyThumb(frame app.RawFrame) {
+	f.thumb.Data = append(f.thumb.Data[:0], frame.Data...)
+	f.thumb.W, f.thumb.H, f.thumb.Stride = frame.W, frame.H, frame.Stride
+}
+
 func (f *Frontend) handleDup() {
-	if lastFrame != nil {
+	if lastFrame != nil && !f.hideVideo {
 		f.onVideo(*lastFrame)
 	}
 }
@@ -238,6 +329,7 @@ func (f *Frontend) Shutdown() {
 	f.SetAudioCb(noAudio)
 	f.SetVideoCb(noVideo)
 	lastFrame = nil
+	f.thumb = app.RawFrame{}
 	f.mu.Unlock()
 	f.log.Debug().Msgf("frontend shutdown done")
 }
@@ -319,16 +411,37 @@ func (f *Frontend) Start() {
 
 	lastFrameStart := time.Now()
 
+	tick := f.Tick
+	if f.netplay != nil {
+		tick = f.netplayTick
+	} else if f.rewind != nil {
+		tick = f.rewindTick
+	}
+
 	for {
 		select {
 		case <-f.done:
 			return
 		default:
-			// run one tick of the emulation
-			f.Tick()
+			if f.Speed() == SpeedPause {
+				if !f.pause(tick) {
+					return
+				}
+				lastFrameStart = time.Now()
+				continue
+			}
+
+			// run the ticks of the emulation,
+			// only the last frame of the fast-forward is shown
+			frameTime, ticks := f.pace(targetFrameTime)
+			for i := range ticks {
+				f.hideVideo = i < ticks-1
+				tick()
+			}
+			f.hideVideo = false
 
 			elapsed := time.Since(lastFrameStart)
-			sleepTime := targetFrameTime - elapsed
+			sleepTime := frameTime - elapsed
 
 			if sleepTime > 0 {
 				// SLEEP
@@ -341,7 +454,7 @@ func (f *Frontend) Start() {
 				// SPIN
 				// if we are close to the target,
 				// burn CPU and check the clock with ns resolution
-				for time.Since(lastFrameStart) < targetFrameTime {
+				for time.Since(lastFrameStart) < frameTime {
 					// CPU burn!
 				}
 				f.skipVideo = false
@@ -356,15 +469,15 @@ func (f *Frontend) Start() {
 
 			// timer reset
 			//
-			// adding targetFrameTime to the previous start
+			// adding frameTime to the previous start
 			// prevents drift, if one frame was late,
 			// we try to catch up in the next frame
-			lastFrameStart = lastFrameStart.Add(targetFrameTime)
+			lastFrameStart = lastFrameStart.Add(frameTime)
 
 			// if execution was paused or heavily delayed,
 			// reset lastFrameStart so we don't try to run
 			// a bunch of frames instantly to catch up
-			if time.Since(lastFrameStart) > targetFrameTime*lateFramesThreshold {
+			if time.Since(lastFrameStart) > frameTime*lateFramesThreshold {
 				lastFrameStart = time.Now()
 			}
 		}
@@ -375,6 +488,7 @@ func (f *Frontend) LoadGame(path string) error {
 	if f.UniqueSaveDir {
 		f.copyFsMaybe(path)
 	}
+	f.game = filepath.Base(path)
 	return f.nano.LoadGame(path)
 }
 
@@ -388,7 +502,6 @@ func (f *Frontend) HashPath() string              { return f.storage.GetSavePath
 func (f *Frontend) IsPortrait() bool              { return f.nano.IsPortrait() }
 func (f *Frontend) KbMouseSupport() bool          { return f.nano.KbMouseSupport() }
 func (f *Frontend) PixFormat() uint32             { return f.nano.Video.PixFmt.C }
-func (f *Frontend) Reset()                        { f.mu.Lock(); defer f.mu.Unlock(); f.nano.Reset() }
 func (f *Frontend) RestoreGameState() error       { return f.Load() }
 func (f *Frontend) Rotation() uint                { return f.nano.Rot }
 func (f *Frontend) SRAMPath() string              { return f.storage.GetSRAMPath() }
@@ -399,11 +512,49 @@ func (f *Frontend) SetAudioCb(cb func(app.Audio)) { f.onAudio = cb }
 func (f *Frontend) SetSessionId(name string)      { f.storage.SetMainSaveName(name) }
 func (f *Frontend) SetDataCb(cb func([]byte))     { f.onData = cb }
 func (f *Frontend) SetVideoCb(ff func(app.Video)) { f.onVideo = ff }
-func (f *Frontend) Tick()                         { f.mu.Lock(); f.nano.Run(); f.mu.Unlock() }
+func (f *Frontend) Tick()                         { f.mu.Lock(); f.movieFrame(); f.nano.Run(); f.mu.Unlock() }
 func (f *Frontend) ViewportRecalculate()          { f.mu.Lock(); f.vw, f.vh = f.ViewportCalc(); f.mu.Unlock() }
 func (f *Frontend) ViewportSize() (int, int)      { return f.vw, f.vh }
 
+func (f *Frontend) Reset() {
+	// the reset should happen on the same frame of the movie
+	if ms := f.movie.Load(); ms != nil {
+		ms.push(0, movieReset, nil)
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.nano.Reset()
+	f.dropStates()
+}
+
 func (f *Frontend) Input(port int, device byte, data []byte) {
+	if ms := f.movie.Load(); ms != nil {
+		ms.push(port, device, data)
+		return
+	}
+	switch Device(device) {
+	case RetroPad:
+		if f.netplay != nil {
+			f.netplay.input(port, f.netplay.frame.Load(), data)
+			return
+		}
+	case RetroPadSync:
+		frame, d, ok := decodeFrameInput(data)
+		if !ok {
+			return
+		}
+		if f.netplay != nil {
+			f.netplay.input(port, frame, d)
+			return
+		}
+		device, data = byte(RetroPad), d
+	}
+	f.input(port, device, data)
+}
+
+// input passes the input directly to the emulator.
+func (f *Frontend) input(port int, device byte, data []byte) {
 	switch Device(device) {
 	case RetroPad:
 		f.nano.InputRetropad(port, data)
@@ -430,6 +581,7 @@ func (f *Frontend) ViewportCalc() (nw int, nh int) {
 func (f *Frontend) Close() {
 	f.log.Debug().Msgf("frontend close")
 	close(f.done)
+	f.StopMovie()
 
 	f.mui.Lock()
 	f.nano.Close()
@@ -448,7 +600,11 @@ func (f *Frontend) Close() {
 }
 
 // Save writes the current state to the filesystem.
-func (f *Frontend) Save() error {
+func (f *Frontend) Save() error { return f.save(f.HashPath()) }
+
+// save writes the current state into each of the paths
+// along with the thumbnails of the save slots.
+func (f *Frontend) save(paths ...string) error {
 	f.mu.Lock()
 	defer f.mu.Unlock()
 
@@ -456,8 +612,13 @@ func (f *Frontend) Save() error {
 	if err != nil {
 		return err
 	}
-	if err := f.storage.Save(f.HashPath(), ss); err != nil {
-		return err
+	for _, path := range paths {
+		if err := f.storage.Save(path, ss); err != nil {
+			return err
+		}
+		if path != f.HashPath() {
+			f.saveThumbnail(path)
+		}
 	}
 	ss = nil
 
@@ -470,29 +631,69 @@ func (f *Frontend) Save() error {
 	return nil
 }
 
+func (f *Frontend) saveThumbnail(path string) {
+	if len(f.thumb.Data) == 0 {
+		return
+	}
+	thumb, err := thumbnail(f.thumb, f.PixFormat(), f.Flipped())
+	if err != nil {
+		f.log.Warn().Err(err).Msg("no thumbnail")
+		return
+	}
+	if err := os.WriteFile(path+thumbExt, thumb, 0644); err != nil {
+		f.log.Warn().Err(err).Msg("thumbnail save")
+	}
+}
+
 // Load restores the state from the filesystem.
 func (f *Frontend) Load() error {
+	if err := f.load(f.HashPath()); err != nil {
+		return err
+	}
+
 	f.mu.Lock()
 	defer f.mu.Unlock()
 
-	ss, err := f.storage.Load(f.HashPath())
+	sram, err := f.storage.Load(f.SRAMPath())
 	if err != nil && !errors.Is(err, os.ErrNotExist) {
 		return err
 	}
-	if err := nanoarch.RestoreSaveState(ss); err != nil {
-		return err
+	if sram != nil {
+		nanoarch.RestoreSaveRAM(sram)
 	}
+	return nil
+}
 
-	sram, err := f.storage.Load(f.SRAMPath())
+// load restores the state from the path.
+func (f *Frontend) load(path string) error {
+	// the movie can't continue from some other state
+	f.StopMovie()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ss, err := f.storage.Load(path)
 	if err != nil && !errors.Is(err, os.ErrNotExist) {
 		return err
 	}
-	if sram != nil {
-		nanoarch.RestoreSaveRAM(sram)
+	if err := nanoarch.RestoreSaveState(ss); err != nil {
+		return err
 	}
+	f.dropStates()
 	return nil
 }
 
+// dropStates removes the rollback and rewind states of the frames before now.
+func (f *Frontend) dropStates() {
+	if f.netplay != nil && f.netplay.states != nil {
+		f.netplay.states.reset()
+	}
+	if f.rewind != nil {
+		f.rewind.pending.Store(0)
+		f.rewind.states.reset()
+	}
+}
+
 func (f *Frontend) IsSupported() error {
 	return graphics.TryInit()
 }
@@ -508,7 +709,11 @@ func (f *Frontend) autosave(periodSec int) {
 			if f.nano.IsStopped() {
 				return
 			}
-			if err := f.Save(); err != nil {
+			paths := []string{f.HashPath()}
+			if slot := f.nextAutoSlot(); slot != "" {
+				paths = append(paths, f.SlotPath(slot))
+			}
+			if err := f.save(paths...); err != nil {
 				f.log.Error().Msgf("Autosave failed: %v", err)
 			} else {
 				f.log.Debug().Msgf("Autosave done")
@@ -519,6 +724,14 @@ func (f *Frontend) autosave(periodSec int) {
 	}
 }
 
+// SaveDir returns the save directory of the core if it's unique for the session.
+func (f *Frontend) SaveDir() string {
+	if !f.UniqueSaveDir {
+		return ""
+	}
+	return f.nano.SaveDir()
+}
+
 func (f *Frontend) copyFsMaybe(path string) {
 	if f.SaveStateFs == "" {
 		return
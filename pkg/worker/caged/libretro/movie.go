@@ -0,0 +1,219 @@
+package libretro
+
+import (
+	"errors"
+	"io"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/giongto35/cloud-game/v3/pkg/worker/caged/libretro/nanoarch"
+	"github.com/giongto35/cloud-game/v3/pkg/worker/movie"
+)
+
+// movieReset is a pseudo device of the emulator reset events.
+const movieReset = 0xff
+
+var (
+	ErrMovieActive  = errors.New("movie is already active")
+	ErrMovieNetplay = errors.New("movies are not supported with netplay")
+)
+
+// movieState is an input movie being recorded or replayed.
+//
+// All the movie input is applied right before
+// the emulator runs a frame, so it lands on the same frame
+// when replayed.
+type movieState struct {
+	mu    sync.Mutex
+	frame uint64 // the number of the next frame since the start
+
+	w     *movie.Writer
+	queue []movie.Event
+
+	r    *movie.Reader
+	next *movie.Event
+}
+
+// push adds live input of the next frame to the recording.
+func (ms *movieState) push(port int, device byte, data []byte) {
+	if Device(device) == RetroPadSync {
+		_, d, ok := decodeFrameInput(data)
+		if !ok {
+			return
+		}
+		device, data = byte(RetroPad), d
+	}
+	ms.mu.Lock()
+	if ms.w != nil {
+		ms.queue = append(ms.queue, movie.Event{Port: uint8(port), Device: device, Data: slices.Clone(data)})
+	}
+	ms.mu.Unlock()
+}
+
+func (ms *movieState) close() (err error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	if ms.w != nil {
+		err = ms.w.Close()
+		ms.w = nil
+	}
+	if ms.r != nil {
+		err = errors.Join(err, ms.r.Close())
+		ms.r = nil
+	}
+	return
+}
+
+// RecordMovie starts recording of the input movie into the file.
+func (f *Frontend) RecordMovie(path string) error {
+	if f.netplay != nil {
+		return ErrMovieNetplay
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.movie.Load() != nil {
+		return ErrMovieActive
+	}
+	state, err := nanoarch.SaveState()
+	if err != nil {
+		return err
+	}
+	w, err := movie.Create(path, movie.Header{
+		Game:    f.game,
+		System:  f.system,
+		Fps:     float64(f.nano.VideoFramerate()),
+		Created: time.Now().Unix(),
+	}, state)
+	if err != nil {
+		return err
+	}
+	f.movie.Store(&movieState{w: w})
+	f.log.Info().Msgf("movie recording: %v", path)
+	return nil
+}
+
+// ReplayMovie restores the initial state of the movie and
+// replays its input instead of the live input of the users.
+func (f *Frontend) ReplayMovie(path string) error {
+	if f.netplay != nil {
+		return ErrMovieNetplay
+	}
+
+	r, err := movie.Open(path)
+	if err != nil {
+		return err
+	}
+	ms := &movieState{r: r}
+	if err := ms.readNext(); err != nil {
+		_ = r.Close()
+		return err
+	}
+	if r.Header.Game != f.game || r.Header.System != f.system {
+		f.log.Warn().Msgf("movie of %v (%v) with %v (%v)", r.Header.Game, r.Header.System, f.game, f.system)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.movie.Load() != nil {
+		_ = r.Close()
+		return ErrMovieActive
+	}
+	if err := nanoarch.RestoreSaveState(r.State); err != nil {
+		_ = r.Close()
+		return err
+	}
+	f.dropStates()
+	f.movie.Store(ms)
+	f.log.Info().Msgf("movie replay: %v", path)
+	return nil
+}
+
+// StopMovie stops the recording or replay of the current movie.
+func (f *Frontend) StopMovie() {
+	ms := f.movie.Swap(nil)
+	if ms == nil {
+		return
+	}
+	if err := ms.close(); err != nil {
+		f.log.Error().Err(err).Msg("movie close")
+	}
+	f.log.Info().Msgf("movie has stopped at the frame %v", ms.frame)
+}
+
+// IsReplaying returns true when the input comes from a movie.
+func (f *Frontend) IsReplaying() bool {
+	ms := f.movie.Load()
+	if ms == nil {
+		return false
+	}
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.r != nil
+}
+
+// movieFrame applies the movie input of the next frame.
+// Should be called under the lock before the frame is run.
+func (f *Frontend) movieFrame() {
+	ms := f.movie.Load()
+	if ms == nil {
+		return
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if ms.w != nil {
+		for _, e := range ms.queue {
+			e.Frame = ms.frame
+			f.movieInput(e)
+			if err := ms.w.Write(e); err != nil {
+				f.log.Error().Err(err).Msg("movie write")
+			}
+		}
+		ms.queue = ms.queue[:0]
+	}
+
+	if ms.r != nil {
+		for ms.next != nil && ms.next.Frame == ms.frame {
+			f.movieInput(*ms.next)
+			if err := ms.readNext(); err != nil {
+				f.log.Error().Err(err).Msg("movie read")
+			}
+		}
+		if ms.next == nil {
+			f.log.Info().Msgf("movie replay has finished at the frame %v", ms.frame)
+			_ = ms.r.Close()
+			ms.r = nil
+			f.movie.CompareAndSwap(ms, nil)
+		}
+	}
+
+	ms.frame++
+}
+
+func (f *Frontend) movieInput(e movie.Event) {
+	if e.Device == movieReset {
+		f.nano.Reset()
+		return
+	}
+	f.input(int(e.Port), e.Device, e.Data)
+}
+
+// readNext reads the next event of the replayed movie,
+// next is nil at the end of the movie.
+func (ms *movieState) readNext() error {
+	e, err := ms.r.Next()
+	if err != nil {
+		ms.next = nil
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		return err
+	}
+	ms.next = &e
+	return nil
+}
@@ -0,0 +1,66 @@
+package nanoarch
+
+import (
+	"strconv"
+	"unsafe"
+)
+
+/*
+#include <stdbool.h>
+#include <stdlib.h>
+
+void disk_control_set(const void *cb, bool ext);
+bool disk_control_enabled(void);
+bool disk_set_eject_state(bool ejected);
+bool disk_get_eject_state(void);
+unsigned disk_get_image_index(void);
+bool disk_set_image_index(unsigned index);
+unsigned disk_get_num_images(void);
+bool disk_get_image_label(unsigned index, char *s, size_t len);
+*/
+import "C"
+
+// The disk control of the multi-disc games (i.e. .m3u playlists).
+// The calls shouldn't run along with the frames of the core,
+// the frontend makes them under its lock, as the save states.
+// See: RETRO_ENVIRONMENT_SET_DISK_CONTROL_EXT_INTERFACE
+
+const maxDiskLabel = 256
+
+func setDiskControl(cb unsafe.Pointer, ext bool) { C.disk_control_set(cb, C.bool(ext)) }
+
+// HasDiskControl checks if the core can swap disks.
+func (n *Nanoarch) HasDiskControl() bool { return bool(C.disk_control_enabled()) }
+
+// DiskCount returns the number of disks of the game.
+func (n *Nanoarch) DiskCount() int { return int(C.disk_get_num_images()) }
+
+// DiskIndex returns the index of the current disk,
+// it's equal to the number of disks when there is no disk.
+func (n *Nanoarch) DiskIndex() int { return int(C.disk_get_image_index()) }
+
+// DiskEjected checks if the virtual disk tray is open.
+func (n *Nanoarch) DiskEjected() bool { return bool(C.disk_get_eject_state()) }
+
+// SetDiskEjected opens or closes the virtual disk tray.
+func (n *Nanoarch) SetDiskEjected(ejected bool) bool {
+	return bool(C.disk_set_eject_state(C.bool(ejected)))
+}
+
+// SetDiskIndex changes the disk, the tray should be open.
+func (n *Nanoarch) SetDiskIndex(index int) bool {
+	return bool(C.disk_set_image_index(C.unsigned(index)))
+}
+
+// DiskLabel returns the name of the disk or its number
+// if the core doesn't have the names.
+func (n *Nanoarch) DiskLabel(index int) string {
+	label := (*C.char)(C.calloc(maxDiskLabel, 1))
+	defer C.free(unsafe.Pointer(label))
+	if C.disk_get_image_label(C.unsigned(index), label, maxDiskLabel) {
+		if s := C.GoString(label); s != "" {
+			return s
+		}
+	}
+	return "Disk " + strconv.Itoa(index+1)
+}
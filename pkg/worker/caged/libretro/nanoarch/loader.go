@@ -17,15 +17,20 @@ import (
 import "C"
 
 func loadFunction(handle unsafe.Pointer, name string) unsafe.Pointer {
-	cs := C.CString(name)
-	defer C.free(unsafe.Pointer(cs))
-	ptr := C.dlsym(handle, cs)
+	ptr := loadOptFunction(handle, name)
 	if ptr == nil {
 		panic("lib function not found: " + name)
 	}
 	return ptr
 }
 
+// loadOptFunction returns nil if the lib doesn't have the function.
+func loadOptFunction(handle unsafe.Pointer, name string) unsafe.Pointer {
+	cs := C.CString(name)
+	defer C.free(unsafe.Pointer(cs))
+	return C.dlsym(handle, cs)
+}
+
 func loadLib(filepath string) (handle unsafe.Pointer, err error) {
 	handle = open(filepath)
 	if handle == nil {
@@ -2,6 +2,7 @@
 #include <pthread.h>
 #include <stdbool.h>
 #include <stdarg.h>
+#include <stdatomic.h>
 #include <stdio.h>
 #include <string.h>
 
@@ -82,6 +83,49 @@ void input_cache_set_keyboard_bulk(const uint8_t *keys, size_t count) {
     memcpy(input_cache.keyboard, keys, count);
 }
 
+// Disk Control
+
+static struct retro_disk_control_ext_callback disk_control = {0};
+
+// Copy the disk control callbacks of the core (ext or the basic ones)
+void disk_control_set(const void *cb, bool ext) {
+    memset(&disk_control, 0, sizeof(disk_control));
+    if (cb != NULL) {
+        memcpy(&disk_control, cb, ext ?
+            sizeof(struct retro_disk_control_ext_callback) : sizeof(struct retro_disk_control_callback));
+    }
+}
+
+bool disk_control_enabled(void) {
+    return disk_control.set_eject_state != NULL && disk_control.get_eject_state != NULL &&
+           disk_control.get_image_index != NULL && disk_control.set_image_index != NULL &&
+           disk_control.get_num_images != NULL;
+}
+
+bool disk_set_eject_state(bool ejected) {
+    return disk_control.set_eject_state != NULL && disk_control.set_eject_state(ejected);
+}
+
+bool disk_get_eject_state(void) {
+    return disk_control.get_eject_state != NULL && disk_control.get_eject_state();
+}
+
+unsigned disk_get_image_index(void) {
+    return disk_control.get_image_index != NULL ? disk_control.get_image_index() : 0;
+}
+
+bool disk_set_image_index(unsigned index) {
+    return disk_control.set_image_index != NULL && disk_control.set_image_index(index);
+}
+
+unsigned disk_get_num_images(void) {
+    return disk_control.get_num_images != NULL ? disk_control.get_num_images() : 0;
+}
+
+bool disk_get_image_label(unsigned index, char *s, size_t len) {
+    return disk_control.get_image_label != NULL && disk_control.get_image_label(index, s, len);
+}
+
 void core_log_cgo(enum retro_log_level level, const char *fmt, ...) {
     char msg[2048] = {0};
     va_list va;
@@ -144,6 +188,10 @@ bool bridge_retro_unserialize(void *f, void *data, size_t size) {
     return ((bool (*)(void *, size_t)) f)(data, size);
 }
 
+void bridge_retro_cheat_set(void *f, unsigned index, bool enabled, const char *code) {
+    ((void (*)(unsigned, bool, const char *)) f)(index, enabled, code);
+}
+
 void bridge_retro_set_controller_port_device(void *f, unsigned port, unsigned device) {
     ((void (*)(unsigned, unsigned)) f)(port, device);
 }
@@ -157,14 +205,22 @@ void bridge_retro_keyboard_callback(void *cb, bool down, unsigned keycode, uint3
     (*(retro_keyboard_event_t *) cb)(down, keycode, character, keyModifiers);
 }
 
+// set when the core options were changed after the last check of the core,
+// the frontend and the core may use it from different threads
+static atomic_bool core_options_updated = false;
+
+void core_options_update(void) { atomic_store(&core_options_updated, true); }
+
 bool core_environment_cgo(unsigned cmd, void *data) {
     bool coreEnvironment(unsigned, void *);
 
     switch (cmd)
     {
-        case RETRO_ENVIRONMENT_GET_VARIABLE_UPDATE:
-          return false;
-          break;
+        case RETRO_ENVIRONMENT_GET_VARIABLE_UPDATE: {
+          bool updated = atomic_exchange(&core_options_updated, false);
+          if (data != NULL) *(bool *)data = updated;
+          return true;
+        }
         case RETRO_ENVIRONMENT_GET_AUDIO_VIDEO_ENABLE:
           return false;
           break;
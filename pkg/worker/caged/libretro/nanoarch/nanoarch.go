@@ -44,6 +44,7 @@ type Nanoarch struct {
 	meta          Metadata
 	options       map[string]string
 	options4rom   map[string]map[string]string
+	coreOptions   []CoreOption
 	reserved      chan struct{} // limits concurrent use
 	Rot           uint
 	serializeSize C.size_t
@@ -200,6 +201,7 @@ func (n *Nanoarch) CoreLoad(meta Metadata) {
 
 	n.options = maps.Clone(meta.Options)
 	n.options4rom = meta.Options4rom
+	n.coreOptions = nil
 
 	corePath := meta.LibPath + meta.LibExt
 	coreLib, err = loadLib(corePath)
@@ -233,6 +235,9 @@ func (n *Nanoarch) CoreLoad(meta Metadata) {
 	retroSetControllerPortDevice = loadFunction(coreLib, "retro_set_controller_port_device")
 	retroGetMemorySize = loadFunction(coreLib, "retro_get_memory_size")
 	retroGetMemoryData = loadFunction(coreLib, "retro_get_memory_data")
+	// some cores don't have cheats
+	retroCheatReset = loadOptFunction(coreLib, "retro_cheat_reset")
+	retroCheatSet = loadOptFunction(coreLib, "retro_cheat_set")
 
 	C.bridge_retro_set_environment(retroSetEnvironment, C.core_environment_cgo)
 	C.bridge_retro_set_input_state(retroSetInputState, C.core_input_state_cgo)
@@ -381,12 +386,15 @@ func (n *Nanoarch) Shutdown() {
 	}
 
 	setRotation(0)
+	setDiskControl(nil, false)
 	Nan0.sys.av = C.struct_retro_system_av_info{}
 	if err := closeLib(coreLib); err != nil {
 		n.log.Error().Err(err).Msg("lib close failed")
 	}
+	retroCheatReset, retroCheatSet = nil, nil
 	n.options = nil
 	n.options4rom = nil
+	n.coreOptions = nil
 	C.free(unsafe.Pointer(n.cUserName))
 	C.free(unsafe.Pointer(n.cSaveDirectory))
 	C.free(unsafe.Pointer(n.cSystemDirectory))
@@ -396,6 +404,27 @@ func (n *Nanoarch) Reset() {
 	C.bridge_call(retroReset)
 }
 
+// HasCheats checks if the core can apply cheats.
+func (n *Nanoarch) HasCheats() bool { return retroCheatReset != nil && retroCheatSet != nil }
+
+// CheatReset disables all the cheats.
+func (n *Nanoarch) CheatReset() {
+	if retroCheatReset == nil {
+		return
+	}
+	C.bridge_call(retroCheatReset)
+}
+
+// CheatSet enables or disables the cheat code with the index.
+func (n *Nanoarch) CheatSet(index int, enabled bool, code string) {
+	if retroCheatSet == nil {
+		return
+	}
+	cCode := C.CString(code)
+	defer C.free(unsafe.Pointer(cCode))
+	C.bridge_retro_cheat_set(retroCheatSet, C.uint(index), C.bool(enabled), cCode)
+}
+
 func (n *Nanoarch) syncInputToCache() {
 	n.retropad.SyncToCache()
 	if n.keyboardCb != nil {
@@ -610,6 +639,8 @@ func (m Metadata) HasHack(h string) bool {
 
 var (
 	retroAPIVersion              unsafe.Pointer
+	retroCheatReset              unsafe.Pointer
+	retroCheatSet                unsafe.Pointer
 	retroDeinit                  unsafe.Pointer
 	retroGetSystemAVInfo         unsafe.Pointer
 	retroGetSystemInfo           unsafe.Pointer
@@ -793,6 +824,24 @@ func coreEnvironment(cmd C.unsigned, data unsafe.Pointer) C.bool {
 			return true
 		}
 		return false
+	case C.RETRO_ENVIRONMENT_GET_CORE_OPTIONS_VERSION:
+		*(*C.unsigned)(data) = coreOptionsVersion
+		return true
+	case C.RETRO_ENVIRONMENT_SET_VARIABLES:
+		setVariables(data)
+		return true
+	case C.RETRO_ENVIRONMENT_SET_CORE_OPTIONS:
+		setCoreOptions(data)
+		return true
+	case C.RETRO_ENVIRONMENT_SET_CORE_OPTIONS_INTL:
+		setCoreOptions(unsafe.Pointer((*C.struct_retro_core_options_intl)(data).us))
+		return true
+	case C.RETRO_ENVIRONMENT_SET_CORE_OPTIONS_V2:
+		setCoreOptionsV2(data)
+		return true
+	case C.RETRO_ENVIRONMENT_SET_CORE_OPTIONS_V2_INTL:
+		setCoreOptionsV2(unsafe.Pointer((*C.struct_retro_core_options_v2_intl)(data).us))
+		return true
 	case C.RETRO_ENVIRONMENT_SET_HW_RENDER:
 		if Nan0.Video.gl.enabled {
 			Nan0.Video.hw = (*C.struct_retro_hw_render_callback)(data)
@@ -826,6 +875,17 @@ func coreEnvironment(cmd C.unsigned, data unsafe.Pointer) C.bool {
 			//Nan0.log.Debug().Msgf("%v", cInfo.String())
 		}
 		return true
+	case C.RETRO_ENVIRONMENT_GET_DISK_CONTROL_INTERFACE_VERSION:
+		*(*C.unsigned)(data) = 1
+		return true
+	case C.RETRO_ENVIRONMENT_SET_DISK_CONTROL_INTERFACE:
+		Nan0.log.Debug().Msgf("Disk control interface was set")
+		setDiskControl(data, false)
+		return true
+	case C.RETRO_ENVIRONMENT_SET_DISK_CONTROL_EXT_INTERFACE:
+		Nan0.log.Debug().Msgf("Disk control ext interface was set")
+		setDiskControl(data, true)
+		return true
 	case C.RETRO_ENVIRONMENT_SET_KEYBOARD_CALLBACK:
 		Nan0.log.Debug().Msgf("Keyboard event callback was set")
 		Nan0.keyboardCb = (*C.struct_retro_keyboard_callback)(data)
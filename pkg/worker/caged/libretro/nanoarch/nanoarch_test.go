@@ -1,6 +1,7 @@
 package nanoarch
 
 import (
+	"slices"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -20,3 +21,14 @@ func TestLimit(t *testing.T) {
 		t.Errorf("should be just 1")
 	}
 }
+
+func TestParseVariable(t *testing.T) {
+	o := parseVariable("core_frameskip", "Frameskip; disabled|auto|manual")
+	if o.Key != "core_frameskip" || o.Desc != "Frameskip" || o.Default != "disabled" ||
+		!slices.Equal(o.Values, []string{"disabled", "auto", "manual"}) {
+		t.Errorf("wrong option: %+v", o)
+	}
+	if o = parseVariable("k", "No values"); o.Desc != "No values" || o.Values != nil {
+		t.Errorf("wrong option: %+v", o)
+	}
+}
@@ -0,0 +1,120 @@
+package nanoarch
+
+import (
+	"strings"
+	"unsafe"
+)
+
+/*
+#include "libretro.h"
+
+void core_options_update(void);
+*/
+import "C"
+
+// The core options (settings) defined by the cores,
+// the values are changed with the Options map of the core config
+// or during the play, then the core is told about the update.
+// See: RETRO_ENVIRONMENT_SET_CORE_OPTIONS_V2
+
+// coreOptionsVersion is the latest supported version of the core options API.
+const coreOptionsVersion = 2
+
+// CoreOption is a setting of the core.
+type CoreOption struct {
+	Key     string
+	Desc    string
+	Info    string
+	Values  []string // the allowed values
+	Default string
+}
+
+// CoreOptions returns the options defined by the core.
+func (n *Nanoarch) CoreOptions() []CoreOption { return n.coreOptions }
+
+// CoreOption returns the value of the core option,
+// false if the value isn't set and the core uses its default.
+func (n *Nanoarch) CoreOption(key string) (string, bool) {
+	v, ok := n.options[key]
+	// see RETRO_ENVIRONMENT_GET_VARIABLE
+	return strings.TrimRight(v, "\x00"), ok
+}
+
+// SetCoreOption changes the value of the core option,
+// the core applies it on the next frame.
+func (n *Nanoarch) SetCoreOption(key, value string) {
+	if n.options == nil {
+		n.options = make(map[string]string)
+	}
+	n.options[key] = value
+	C.core_options_update()
+}
+
+// setVariables keeps the options of the v0 core options API.
+// The value of each variable is its description and allowed values:
+//
+//	"Description; value1|value2|value3", the first value is the default.
+func setVariables(data unsafe.Pointer) {
+	var opts []CoreOption
+	for v := (*C.struct_retro_variable)(data); v != nil && v.key != nil; v = next(v) {
+		opts = append(opts, parseVariable(C.GoString(v.key), C.GoString(v.value)))
+	}
+	Nan0.coreOptions = opts
+}
+
+func parseVariable(key, value string) CoreOption {
+	desc, values, _ := strings.Cut(value, "; ")
+	o := CoreOption{Key: key, Desc: desc}
+	if values != "" {
+		o.Values = strings.Split(values, "|")
+		o.Default = o.Values[0]
+	}
+	return o
+}
+
+// setCoreOptions keeps the options of the v1 core options API.
+func setCoreOptions(data unsafe.Pointer) {
+	var opts []CoreOption
+	for d := (*C.struct_retro_core_option_definition)(data); d != nil && d.key != nil; d = next(d) {
+		opts = append(opts, CoreOption{
+			Key:     C.GoString(d.key),
+			Desc:    C.GoString(d.desc),
+			Info:    C.GoString(d.info),
+			Values:  optionValues(d.values[:]),
+			Default: C.GoString(d.default_value),
+		})
+	}
+	Nan0.coreOptions = opts
+}
+
+// setCoreOptionsV2 keeps the options of the v2 core options API,
+// the categories of the options are skipped.
+func setCoreOptionsV2(data unsafe.Pointer) {
+	var opts []CoreOption
+	if o := (*C.struct_retro_core_options_v2)(data); o != nil {
+		for d := o.definitions; d != nil && d.key != nil; d = next(d) {
+			opts = append(opts, CoreOption{
+				Key:     C.GoString(d.key),
+				Desc:    C.GoString(d.desc),
+				Info:    C.GoString(d.info),
+				Values:  optionValues(d.values[:]),
+				Default: C.GoString(d.default_value),
+			})
+		}
+	}
+	Nan0.coreOptions = opts
+}
+
+func optionValues(values []C.struct_retro_core_option_value) []string {
+	var vv []string
+	for _, v := range values {
+		if v.value == nil {
+			break
+		}
+		vv = append(vv, C.GoString(v.value))
+	}
+	return vv
+}
+
+// next returns the next element of the C array.
+func next[T any](p *T) *T { return (*T)(unsafe.Add(unsafe.Pointer(p), unsafe.Sizeof(*p))) }
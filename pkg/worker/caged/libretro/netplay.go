@@ -0,0 +1,284 @@
+package libretro
+
+import (
+	"encoding/binary"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"github.com/giongto35/cloud-game/v3/pkg/api"
+	"github.com/giongto35/cloud-game/v3/pkg/config"
+	"github.com/giongto35/cloud-game/v3/pkg/worker/caged/libretro/nanoarch"
+)
+
+// netplayPorts is the number of input ports with timed input.
+const netplayPorts = 4
+
+// frameHeaderSize is the size of the frame number before
+// the retropad data of RetroPadSync input.
+//
+//	[FRAME:8][RETROPAD DATA]
+const frameHeaderSize = 8
+
+// netplay keeps the frame-numbered input of all players,
+// so their inputs land on the same emulated frame no matter the RTT.
+//
+// Each input is applied on the frame it was made at (by the user)
+// plus some fixed delay. Inputs that came later than that are either
+// applied on the current frame or, with rollback, the emulator
+// restores the state of that frame and replays the frames since then.
+type netplay struct {
+	frame  atomic.Uint64 // the number of the next frame to run
+	log    inputLog
+	states *stateRing
+}
+
+func newNetplay(conf config.Netplay) *netplay {
+	np := &netplay{log: newInputLog(conf.InputDelay, conf.Rollback)}
+	if conf.Rollback > 0 {
+		np.states = newStateRing(conf.Rollback)
+	}
+	return np
+}
+
+// input adds the user input made at the frame,
+// the current frame is used for the input without frame numbers.
+func (np *netplay) input(port int, frame uint64, data []byte) {
+	np.log.push(port, frame, np.frame.Load(), data)
+}
+
+// inputLog is a per-port list of timed inputs.
+type inputLog struct {
+	delay  uint64
+	window uint64
+
+	mu       sync.Mutex
+	ports    [netplayPorts][]timedInput
+	rollback uint64
+	late     bool
+}
+
+type timedInput struct {
+	frame uint64
+	data  []byte
+}
+
+func newInputLog(delay, window int) inputLog {
+	return inputLog{delay: uint64(max(delay, 0)), window: uint64(max(window, 0))}
+}
+
+// push adds the input made at the frame, now is the frame about to run.
+func (l *inputLog) push(port int, frame, now uint64, data []byte) {
+	if port < 0 || port >= netplayPorts || len(data) == 0 {
+		return
+	}
+
+	// the users can't be ahead of the emulator
+	target := min(frame, now) + l.delay
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if target < now {
+		if l.window > 0 && now-target <= l.window {
+			if !l.late || target < l.rollback {
+				l.rollback = target
+			}
+			l.late = true
+		} else {
+			target = now
+		}
+	}
+
+	in := timedInput{frame: target, data: slices.Clone(data)}
+	list := l.ports[port]
+	// the inputs are mostly in order, so we search from the end
+	i := len(list)
+	for i > 0 && list[i-1].frame > target {
+		i--
+	}
+	l.ports[port] = slices.Insert(list, i, in)
+}
+
+// at returns the input of the port on the frame,
+// that is the last input made before or on that frame.
+func (l *inputLog) at(port int, frame uint64) []byte {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	list := l.ports[port]
+	for i := len(list) - 1; i >= 0; i-- {
+		if list[i].frame <= frame {
+			return list[i].data
+		}
+	}
+	return nil
+}
+
+// takeRollback returns the earliest frame changed by late inputs.
+func (l *inputLog) takeRollback() (uint64, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.late {
+		return 0, false
+	}
+	l.late = false
+	return l.rollback, true
+}
+
+// trim removes the inputs that can't be replayed anymore,
+// keeping the last one of them as the current state of the port.
+func (l *inputLog) trim(now uint64) {
+	if now <= l.window {
+		return
+	}
+	edge := now - l.window
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for p, list := range l.ports {
+		i := 0
+		for i+1 < len(list) && list[i+1].frame <= edge {
+			i++
+		}
+		if i > 0 {
+			l.ports[p] = slices.Delete(list, 0, i)
+		}
+	}
+}
+
+// stateRing keeps the emulator states of the last frames.
+type stateRing struct {
+	frames []uint64
+	states [][]byte
+	ok     []bool
+}
+
+func newStateRing(size int) *stateRing {
+	size++ // the current frame
+	return &stateRing{
+		frames: make([]uint64, size),
+		states: make([][]byte, size),
+		ok:     make([]bool, size),
+	}
+}
+
+func (r *stateRing) put(frame uint64, state []byte) {
+	i := frame % uint64(len(r.states))
+	r.frames[i], r.ok[i] = frame, true
+	// reuse the old buffers, states of the same game have the same size
+	r.states[i] = append(r.states[i][:0], state...)
+}
+
+func (r *stateRing) get(frame uint64) ([]byte, bool) {
+	i := frame % uint64(len(r.states))
+	if !r.ok[i] || r.frames[i] != frame {
+		return nil, false
+	}
+	return r.states[i], true
+}
+
+// reset drops all the states,
+// i.e. when the state of the game was changed outside the main loop.
+func (r *stateRing) reset() { clear(r.ok) }
+
+// EnableNetplay switches the emulator input into the frame-synchronized mode.
+func (f *Frontend) EnableNetplay(conf config.Netplay) {
+	if !conf.Enabled {
+		return
+	}
+	f.netplay = newNetplay(conf)
+	f.log.Info().Msgf("netplay input delay: %v, rollback: %v", conf.InputDelay, conf.Rollback)
+}
+
+// netplayTick runs one frame of the emulation with the inputs of that frame.
+func (f *Frontend) netplayTick() {
+	np := f.netplay
+	frame := np.frame.Load()
+
+	if from, ok := np.log.takeRollback(); ok {
+		f.rollback(from, frame)
+	}
+
+	f.netplayRun(frame)
+	np.frame.Store(frame + 1)
+	np.log.trim(frame)
+
+	// let the users know the frame numbers once per second
+	if fps := uint64(f.nano.VideoFramerate()); fps > 0 && frame%fps == 0 {
+		f.sendFrame(frame)
+	}
+}
+
+// netplayRun saves the state before the frame (for rollbacks),
+// applies its inputs and runs it.
+func (f *Frontend) netplayRun(frame uint64) {
+	np := f.netplay
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if np.states != nil {
+		if state, err := nanoarch.SaveState(); err == nil {
+			np.states.put(frame, state)
+		}
+	}
+	for port := range netplayPorts {
+		if data := np.log.at(port, frame); data != nil {
+			f.nano.InputRetropad(port, data)
+		}
+	}
+	f.nano.Run()
+}
+
+// rollback restores the emulator state at the frame
+// and silently replays all the frames until now.
+func (f *Frontend) rollback(from, now uint64) {
+	np := f.netplay
+	if np.states == nil || from >= now {
+		return
+	}
+	f.mu.Lock()
+	state, ok := np.states.get(from)
+	if !ok {
+		f.mu.Unlock()
+		f.log.Debug().Msgf("no state for rollback to %v", from)
+		return
+	}
+	err := nanoarch.RestoreSaveState(state)
+	f.mu.Unlock()
+	if err != nil {
+		f.log.Error().Err(err).Msgf("rollback fail")
+		return
+	}
+
+	f.replay.Store(true)
+	for frame := from; frame < now; frame++ {
+		f.netplayRun(frame)
+	}
+	f.replay.Store(false)
+}
+
+// sendFrame sends the current frame number to the users.
+func (f *Frontend) sendFrame(frame uint64) {
+	data, err := api.Wrap(api.Out{
+		T: uint8(api.AppFrame),
+		Payload: api.AppFrameInfo{
+			Frame: frame,
+			Fps:   f.nano.VideoFramerate(),
+			Delay: int(f.netplay.log.delay),
+		}})
+	if err != nil {
+		f.log.Error().Err(err).Msgf("wrap")
+		return
+	}
+	f.onData(data)
+}
+
+// decodeFrameInput splits RetroPadSync input into the frame number and data.
+func decodeFrameInput(data []byte) (uint64, []byte, bool) {
+	if len(data) <= frameHeaderSize {
+		return 0, nil, false
+	}
+	return binary.BigEndian.Uint64(data), data[frameHeaderSize:], true
+}
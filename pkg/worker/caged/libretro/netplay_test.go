@@ -0,0 +1,109 @@
+package libretro
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestInputLogDelay(t *testing.T) {
+	l := newInputLog(2, 0)
+
+	l.push(0, 10, 10, []byte{1})
+	l.push(1, 8, 10, []byte{2}) // late, no rollback
+
+	if l.at(0, 11) != nil {
+		t.Errorf("an input before the delay")
+	}
+	if d := l.at(0, 12); !bytes.Equal(d, []byte{1}) {
+		t.Errorf("wrong input: %v", d)
+	}
+	if d := l.at(1, 10); !bytes.Equal(d, []byte{2}) {
+		t.Errorf("late input should be applied now, got: %v", d)
+	}
+	if _, ok := l.takeRollback(); ok {
+		t.Errorf("a rollback without the window")
+	}
+}
+
+func TestInputLogRollback(t *testing.T) {
+	l := newInputLog(1, 5)
+
+	l.push(0, 6, 10, []byte{1})
+	l.push(1, 4, 10, []byte{2})
+	l.push(2, 1, 10, []byte{3}) // too late
+
+	from, ok := l.takeRollback()
+	if !ok || from != 5 {
+		t.Errorf("wrong rollback: %v %v", from, ok)
+	}
+	if _, ok := l.takeRollback(); ok {
+		t.Errorf("a rollback twice")
+	}
+	if d := l.at(1, 5); !bytes.Equal(d, []byte{2}) {
+		t.Errorf("wrong input: %v", d)
+	}
+	if d := l.at(2, 9); d != nil {
+		t.Errorf("too late input in the past: %v", d)
+	}
+}
+
+func TestInputLogOrder(t *testing.T) {
+	l := newInputLog(0, 10)
+
+	l.push(0, 5, 5, []byte{1})
+	l.push(0, 3, 5, []byte{2})
+	l.push(0, 7, 7, []byte{3})
+
+	for _, tc := range []struct {
+		frame uint64
+		want  []byte
+	}{{2, nil}, {3, []byte{2}}, {4, []byte{2}}, {5, []byte{1}}, {9, []byte{3}}} {
+		if d := l.at(0, tc.frame); !bytes.Equal(d, tc.want) {
+			t.Errorf("wrong input at %v: %v != %v", tc.frame, d, tc.want)
+		}
+	}
+}
+
+func TestInputLogTrim(t *testing.T) {
+	l := newInputLog(0, 2)
+
+	for i := range uint64(10) {
+		l.push(0, i, i, []byte{byte(i)})
+	}
+	l.trim(10)
+
+	if n := len(l.ports[0]); n != 2 {
+		t.Errorf("wrong number of inputs after trim: %v", n)
+	}
+	if d := l.at(0, 100); !bytes.Equal(d, []byte{9}) {
+		t.Errorf("wrong last input: %v", d)
+	}
+}
+
+func TestStateRing(t *testing.T) {
+	r := newStateRing(2)
+
+	for i := range uint64(5) {
+		r.put(i, []byte{byte(i)})
+	}
+	if _, ok := r.get(1); ok {
+		t.Errorf("an overwritten state")
+	}
+	if s, ok := r.get(3); !ok || !bytes.Equal(s, []byte{3}) {
+		t.Errorf("wrong state: %v %v", s, ok)
+	}
+	r.reset()
+	if _, ok := r.get(4); ok {
+		t.Errorf("a state after reset")
+	}
+}
+
+func TestDecodeFrameInput(t *testing.T) {
+	frame, data, ok := decodeFrameInput([]byte{0, 0, 0, 1, 0, 0, 1, 0, 42, 0})
+	if !ok || frame != 1<<32+256 || !bytes.Equal(data, []byte{42, 0}) {
+		t.Errorf("wrong frame input: %v %v %v", frame, data, ok)
+	}
+	if _, _, ok := decodeFrameInput([]byte{0, 0, 0, 0, 0, 0, 1, 0}); ok {
+		t.Errorf("no error for empty input")
+	}
+}
@@ -0,0 +1,62 @@
+package libretro
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/giongto35/cloud-game/v3/pkg/worker/caged/libretro/nanoarch"
+)
+
+var (
+	ErrNoOption    = errors.New("no such core option")
+	ErrOptionValue = errors.New("unsupported core option value")
+)
+
+// CoreOption is a setting of the core with its current value.
+type CoreOption struct {
+	Key     string
+	Desc    string
+	Info    string
+	Values  []string // the allowed values
+	Default string
+	Value   string
+}
+
+// CoreOptions returns the options of the core.
+func (f *Frontend) CoreOptions() []CoreOption {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var opts []CoreOption
+	for _, o := range f.nano.CoreOptions() {
+		v, ok := f.nano.CoreOption(o.Key)
+		if !ok {
+			v = o.Default
+		}
+		opts = append(opts, CoreOption{
+			Key:     o.Key,
+			Desc:    o.Desc,
+			Info:    o.Info,
+			Values:  o.Values,
+			Default: o.Default,
+			Value:   v,
+		})
+	}
+	return opts
+}
+
+// SetCoreOption changes the option of the core during the play.
+func (f *Frontend) SetCoreOption(key, value string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	opts := f.nano.CoreOptions()
+	i := slices.IndexFunc(opts, func(o nanoarch.CoreOption) bool { return o.Key == key })
+	if i < 0 {
+		return ErrNoOption
+	}
+	if vv := opts[i].Values; len(vv) > 0 && !slices.Contains(vv, value) {
+		return ErrOptionValue
+	}
+	f.nano.SetCoreOption(key, value)
+	f.log.Info().Msgf("Core option: %v=%v", key, value)
+	return nil
+}
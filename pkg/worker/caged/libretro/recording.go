@@ -1,21 +1,34 @@
 package libretro
 
 import (
+	"errors"
+	"path/filepath"
 	"time"
 
 	"github.com/giongto35/cloud-game/v3/pkg/config"
 	"github.com/giongto35/cloud-game/v3/pkg/logger"
 	"github.com/giongto35/cloud-game/v3/pkg/worker/caged/app"
+	"github.com/giongto35/cloud-game/v3/pkg/worker/movie"
 	"github.com/giongto35/cloud-game/v3/pkg/worker/recorder"
 )
 
+// MovieRecorder records input movies of the games.
+type MovieRecorder interface {
+	RecordMovie(path string) error
+	StopMovie()
+}
+
 type RecordingFrontend struct {
 	Emulator
-	rec *recorder.Recording
+	rec   *recorder.Recording
+	movie MovieRecorder
+	log   *logger.Logger
 }
 
-func WithRecording(fe Emulator, rec bool, user string, game string, conf config.Recording, log *logger.Logger) *RecordingFrontend {
-	rr := &RecordingFrontend{Emulator: fe, rec: recorder.NewRecording(
+// WithRecording adds A/V recording to the emulator,
+// and input movie recording if mr is not nil.
+func WithRecording(fe Emulator, mr MovieRecorder, rec bool, user string, game string, conf config.Recording, log *logger.Logger) *RecordingFrontend {
+	rr := &RecordingFrontend{Emulator: fe, movie: mr, log: log, rec: recorder.NewRecording(
 		recorder.Meta{UserName: user},
 		log,
 		recorder.Options{
@@ -63,8 +76,19 @@ func (r *RecordingFrontend) LoadGame(path string) error {
 }
 
 func (r *RecordingFrontend) ToggleRecording(active bool, user string) {
-	if r.rec != nil {
-		r.rec.Set(active, user)
+	if r.rec == nil {
+		return
+	}
+	// the movie goes into the recording dir, so it should be closed before
+	if !active && r.movie != nil {
+		r.movie.StopMovie()
+	}
+	r.rec.Set(active, user)
+	if active && r.movie != nil {
+		path := filepath.Join(r.rec.Dir(), "input"+movie.Ext)
+		if err := r.movie.RecordMovie(path); err != nil && !errors.Is(err, ErrMovieActive) {
+			r.log.Error().Err(err).Msg("movie recording fail")
+		}
 	}
 }
 
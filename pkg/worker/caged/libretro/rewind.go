@@ -0,0 +1,225 @@
+package libretro
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"sync/atomic"
+
+	"github.com/giongto35/cloud-game/v3/pkg/config"
+	"github.com/giongto35/cloud-game/v3/pkg/worker/caged/libretro/nanoarch"
+)
+
+var (
+	ErrNoRewind = errors.New("rewind is disabled")
+	errDelta    = errors.New("bad state delta")
+)
+
+// rewind saves the state of the game every few frames,
+// so the game could go back in time one state per frame.
+type rewind struct {
+	interval int
+	frames   int
+	pending  atomic.Int32 // the number of states to go back
+	states   *rewindBuffer
+}
+
+func newRewind(conf config.Rewind, memory int) *rewind {
+	if !conf.Enabled {
+		return nil
+	}
+	if memory <= 0 {
+		memory = conf.Memory
+	}
+	return &rewind{interval: max(1, conf.Interval), states: newRewindBuffer(memory << 20)}
+}
+
+// Rewind goes back in time by the seconds, one saved state per frame,
+// 0 stops it (i.e. when the rewind button is released).
+func (f *Frontend) Rewind(seconds float64) error {
+	rw := f.rewind
+	if rw == nil || f.netplay != nil {
+		return ErrNoRewind
+	}
+	// the movie can't go back in time
+	if f.movie.Load() != nil {
+		return ErrMovieActive
+	}
+	states := 0
+	if seconds > 0 {
+		states = int(math.Ceil(seconds * float64(f.nano.VideoFramerate()) / float64(rw.interval)))
+	}
+	rw.pending.Store(int32(states))
+	return nil
+}
+
+// rewindTick runs one frame of the emulation from the previous saved state
+// when rewinding, otherwise it saves the state every few frames.
+func (f *Frontend) rewindTick() {
+	rw := f.rewind
+	if rw.pending.Load() > 0 {
+		f.mu.Lock()
+		state, ok := rw.states.pop()
+		if ok {
+			if err := nanoarch.RestoreSaveState(state); err != nil {
+				f.log.Error().Err(err).Msg("rewind fail")
+				ok = false
+			}
+		}
+		f.mu.Unlock()
+		if !ok {
+			rw.pending.Store(0)
+		} else {
+			rw.pending.Add(-1)
+		}
+		f.rewound = true
+		f.Tick()
+		f.rewound = false
+		return
+	}
+
+	f.Tick()
+	rw.frames++
+	if rw.frames%rw.interval != 0 {
+		return
+	}
+	f.mu.Lock()
+	if state, err := nanoarch.SaveState(); err == nil {
+		rw.states.push(state)
+	}
+	f.mu.Unlock()
+}
+
+// rewindBuffer keeps the states of the game within the memory budget.
+// Only the newest state is kept as is, the older ones are the XOR deltas
+// between the consecutive states, so the oldest states are dropped first.
+type rewindBuffer struct {
+	budget int
+	size   int      // the memory of all the states
+	last   []byte   // the newest state
+	deltas [][]byte // the oldest first
+	out    []byte
+}
+
+func newRewindBuffer(budget int) *rewindBuffer { return &rewindBuffer{budget: budget} }
+
+func (b *rewindBuffer) push(state []byte) {
+	// the states of the same game have the same size
+	if len(state) > b.budget || len(b.last) != len(state) {
+		b.reset()
+	}
+	if len(state) > b.budget {
+		return
+	}
+	if b.last == nil {
+		b.last = append([]byte(nil), state...)
+		b.size = len(state)
+		return
+	}
+	d := xorDelta(nil, b.last, state)
+	b.deltas = append(b.deltas, d)
+	b.size += len(d)
+	copy(b.last, state)
+
+	for b.size > b.budget && len(b.deltas) > 0 {
+		b.size -= len(b.deltas[0])
+		b.deltas[0] = nil
+		b.deltas = b.deltas[1:]
+	}
+}
+
+// pop removes the newest state, the state is valid until the next call.
+func (b *rewindBuffer) pop() ([]byte, bool) {
+	if b.last == nil {
+		return nil, false
+	}
+	b.out = append(b.out[:0], b.last...)
+	n := len(b.deltas)
+	if n == 0 {
+		b.reset()
+		return b.out, true
+	}
+	d := b.deltas[n-1]
+	b.deltas[n-1] = nil
+	b.deltas = b.deltas[:n-1]
+	b.size -= len(d)
+	if err := xorApply(b.last, d); err != nil {
+		b.reset()
+	}
+	return b.out, true
+}
+
+// reset drops all the states.
+func (b *rewindBuffer) reset() {
+	clear(b.deltas)
+	b.last, b.deltas, b.size = nil, b.deltas[:0], 0
+}
+
+// minSame is the min number of the same bytes between the changed ones
+// to split the delta, the shorter runs are cheaper to keep as changed.
+const minSame = 8
+
+// xorDelta appends the difference of the states of the same size to dst
+// as a list of runs of the same bytes and the XOR-ed changed bytes.
+//
+//	[SAME:uvarint][CHANGED:uvarint][CHANGED bytes of a^b]...
+func xorDelta(dst, a, b []byte) []byte {
+	for i := 0; i < len(a); {
+		j := i
+		for j < len(a) && a[j] == b[j] {
+			j++
+		}
+		if j == len(a) {
+			break
+		}
+		k := j
+		for k < len(a) {
+			if a[k] != b[k] {
+				k++
+				continue
+			}
+			e := k
+			for e < len(a) && e-k < minSame && a[e] == b[e] {
+				e++
+			}
+			if e-k == minSame || e == len(a) {
+				break
+			}
+			k = e
+		}
+		dst = binary.AppendUvarint(dst, uint64(j-i))
+		dst = binary.AppendUvarint(dst, uint64(k-j))
+		for n := j; n < k; n++ {
+			dst = append(dst, a[n]^b[n])
+		}
+		i = k
+	}
+	return dst
+}
+
+// xorApply turns one state of the delta into the other.
+func xorApply(state, delta []byte) error {
+	i := uint64(0)
+	for len(delta) > 0 {
+		same, n := binary.Uvarint(delta)
+		if n <= 0 {
+			return errDelta
+		}
+		delta = delta[n:]
+		changed, n := binary.Uvarint(delta)
+		if n <= 0 {
+			return errDelta
+		}
+		delta = delta[n:]
+		i += same
+		if changed > uint64(len(delta)) || i+changed > uint64(len(state)) {
+			return errDelta
+		}
+		for k, x := range delta[:changed] {
+			state[i+uint64(k)] ^= x
+		}
+		i += changed
+		delta = delta[changed:]
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package libretro
+
+import (
+	"bytes"
+	"math/rand/v2"
+	"testing"
+)
+
+func TestXorDelta(t *testing.T) {
+	a := make([]byte, 1000)
+	b := make([]byte, 1000)
+	for i := range a {
+		a[i] = byte(i)
+	}
+	copy(b, a)
+	b[0], b[3], b[500], b[999] = 1, 2, 3, 4
+
+	d := xorDelta(nil, a, b)
+	if len(d) >= 20 {
+		t.Errorf("a big delta: %v", len(d))
+	}
+	if err := xorApply(a, d); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(a, b) {
+		t.Errorf("wrong state after the delta")
+	}
+	if d := xorDelta(nil, a, b); len(d) != 0 {
+		t.Errorf("a delta of the same states: %v", d)
+	}
+	if err := xorApply(a, d[:len(d)-1]); err == nil {
+		t.Errorf("no error of a bad delta")
+	}
+}
+
+func TestRewindBuffer(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 2))
+	state := make([]byte, 100)
+	var states [][]byte
+	b := newRewindBuffer(1 << 20)
+	for range 10 {
+		for range 5 {
+			state[r.IntN(len(state))] = byte(r.Uint32())
+		}
+		states = append(states, bytes.Clone(state))
+		b.push(state)
+	}
+
+	for i := len(states) - 1; i >= 0; i-- {
+		s, ok := b.pop()
+		if !ok || !bytes.Equal(s, states[i]) {
+			t.Fatalf("wrong state %v", i)
+		}
+	}
+	if _, ok := b.pop(); ok {
+		t.Errorf("a state of the empty buffer")
+	}
+}
+
+func TestRewindBufferBudget(t *testing.T) {
+	b := newRewindBuffer(110)
+	state := make([]byte, 100)
+	for i := range 10 {
+		state[0] = byte(i + 1)
+		b.push(state)
+	}
+	if b.size > b.budget {
+		t.Errorf("over the budget: %v", b.size)
+	}
+	n := 0
+	for {
+		s, ok := b.pop()
+		if !ok {
+			break
+		}
+		if s[0] != byte(10-n) {
+			t.Errorf("wrong state: %v", s[0])
+		}
+		n++
+	}
+	if n < 2 || n == 10 {
+		t.Errorf("wrong number of states: %v", n)
+	}
+
+	b.push(make([]byte, 200))
+	if _, ok := b.pop(); ok {
+		t.Errorf("a state over the budget")
+	}
+}
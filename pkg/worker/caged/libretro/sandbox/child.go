@@ -0,0 +1,261 @@
+package sandbox
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+
+	"github.com/giongto35/cloud-game/v3/pkg/cloud"
+	"github.com/giongto35/cloud-game/v3/pkg/logger"
+	"github.com/giongto35/cloud-game/v3/pkg/worker/caged/app"
+	"github.com/giongto35/cloud-game/v3/pkg/worker/caged/libretro"
+)
+
+// envChild marks sandboxed emulator processes.
+const envChild = "CLOUD_GAME_SANDBOX"
+
+// Child process IPC file descriptors (see exec.Cmd.ExtraFiles).
+const (
+	fdIn  = 3
+	fdOut = 4
+)
+
+// IsChild checks if the current process is a sandboxed emulator.
+func IsChild() bool { return os.Getenv(envChild) != "" }
+
+// Run runs an emulator controlled by the parent worker process.
+// It should be called instead of the main function of the worker
+// and will exit the process when done.
+func Run() {
+	in, out := os.NewFile(fdIn, "sandbox-in"), os.NewFile(fdOut, "sandbox-out")
+	if in == nil || out == nil {
+		_, _ = fmt.Fprintln(os.Stderr, "sandbox: no IPC pipes")
+		os.Exit(2)
+	}
+	if err := serve(newConn(in, out)); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "sandbox: %v\n", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func serve(c *conn) error {
+	t, p, err := c.read()
+	if err != nil {
+		return err
+	}
+	if t != msgInit {
+		return fmt.Errorf("unexpected message: %v", t)
+	}
+	var rq initRequest
+	if err := json.Unmarshal(p, &rq); err != nil {
+		return err
+	}
+
+	log := logger.NewConsole(rq.Debug, "w", false)
+	log = log.Extend(log.With().Str("m", "sandbox").Int("pid", os.Getpid()))
+
+	emu, err := load(rq, c, log)
+	if err != nil {
+		_ = c.write(msgResult, result(err))
+		return err
+	}
+	info, err := json.Marshal(infoOf(emu))
+	if err != nil {
+		return err
+	}
+	if err := c.write(msgResult, resultData(info)); err != nil {
+		return err
+	}
+
+	for {
+		t, p, err := c.read()
+		if err != nil {
+			// the parent is gone, nothing to wait for
+			emu.Close()
+			return err
+		}
+		switch t {
+		case msgStart:
+			emu.Start()
+			err = c.write(msgResult, result(nil))
+		case msgInput:
+			if len(p) >= 2 {
+				emu.Input(int(p[0]), p[1], p[2:])
+			}
+		case msgSave:
+			err = c.write(msgResult, result(emu.SaveGameState()))
+		case msgLoad:
+			err = c.write(msgResult, result(emu.RestoreGameState()))
+		case msgSaveSlot:
+			err = c.write(msgResult, result(emu.SaveSlot(string(p))))
+		case msgLoadSlot:
+			err = c.write(msgResult, result(emu.LoadSlot(string(p))))
+		case msgSlots:
+			var slots []byte
+			if slots, err = json.Marshal(emu.Slots()); err == nil {
+				err = c.write(msgResult, resultData(slots))
+			} else {
+				err = c.write(msgResult, result(err))
+			}
+		case msgCheats:
+			var cheats []byte
+			if cheats, err = json.Marshal(emu.Cheats()); err == nil {
+				err = c.write(msgResult, resultData(cheats))
+			} else {
+				err = c.write(msgResult, result(err))
+			}
+		case msgSetCheat:
+			if len(p) < 5 {
+				err = c.write(msgResult, result(libretro.ErrNoCheat))
+				break
+			}
+			index := int(binary.LittleEndian.Uint32(p[1:]))
+			err = c.write(msgResult, result(emu.SetCheat(index, p[0] == 1)))
+		case msgDisks:
+			var disks []byte
+			d, derr := emu.Disks()
+			if derr == nil {
+				disks, derr = json.Marshal(d)
+			}
+			if derr == nil {
+				err = c.write(msgResult, resultData(disks))
+			} else {
+				err = c.write(msgResult, result(derr))
+			}
+		case msgEject:
+			err = c.write(msgResult, result(emu.EjectDisk(len(p) > 0 && p[0] == 1)))
+		case msgSetDisk:
+			if len(p) < 4 {
+				err = c.write(msgResult, result(libretro.ErrNoDisk))
+				break
+			}
+			err = c.write(msgResult, result(emu.SetDisk(int(binary.LittleEndian.Uint32(p)))))
+		case msgSpeed:
+			speed := binary.LittleEndian.AppendUint64(nil, math.Float64bits(emu.Speed()))
+			err = c.write(msgResult, resultData(speed))
+		case msgSetSpeed:
+			if len(p) < 8 {
+				err = c.write(msgResult, result(libretro.ErrSpeed))
+				break
+			}
+			err = c.write(msgResult, result(emu.SetSpeed(math.Float64frombits(binary.LittleEndian.Uint64(p)))))
+		case msgAdvance:
+			err = c.write(msgResult, result(emu.FrameAdvance()))
+		case msgRewind:
+			if len(p) < 8 {
+				err = c.write(msgResult, result(libretro.ErrNoRewind))
+				break
+			}
+			err = c.write(msgResult, result(emu.Rewind(math.Float64frombits(binary.LittleEndian.Uint64(p)))))
+		case msgOptions:
+			var opts []byte
+			if opts, err = json.Marshal(emu.CoreOptions()); err == nil {
+				err = c.write(msgResult, resultData(opts))
+			} else {
+				err = c.write(msgResult, result(err))
+			}
+		case msgOption:
+			key, value, ok := bytes.Cut(p, []byte{0})
+			if !ok {
+				err = c.write(msgResult, result(libretro.ErrNoOption))
+				break
+			}
+			err = c.write(msgResult, result(emu.SetCoreOption(string(key), string(value))))
+		case msgReset:
+			emu.Reset()
+			err = c.write(msgResult, result(nil))
+		case msgRecord:
+			if len(p) > 0 {
+				emu.ToggleRecording(p[0] == 1, string(p[1:]))
+			}
+			err = c.write(msgResult, result(nil))
+		case msgClose:
+			emu.Close()
+			log.Debug().Msg("closed")
+			return c.write(msgResult, result(nil))
+		default:
+			err = c.write(msgResult, result(fmt.Errorf("unknown message: %v", t)))
+		}
+		if err != nil {
+			emu.Close()
+			return err
+		}
+	}
+}
+
+// load runs the game in the same way the worker does with in-process emulators.
+func load(rq initRequest, c *conn, log *logger.Logger) (*libretro.Caged, error) {
+	caged := libretro.Cage(libretro.CagedConf{Emulator: rq.Emulator, Recording: rq.Recording}, log)
+	caged.ReloadFrontend()
+	caged.SetSessionId(rq.SessionId)
+	caged.SetSaveOnClose(rq.SaveOnClose)
+	if rq.CloudId != "" {
+		st, err := cloud.Store(rq.Storage, nil, log)
+		if err != nil || st == nil {
+			log.Warn().Err(err).Msgf("cloud storage fail, using no storage")
+		} else {
+			caged.EnableCloudStorage(rq.CloudId, st)
+		}
+	}
+	if rq.Record.Enabled {
+		caged.EnableRecording(rq.Record.NoWait, rq.Record.User, rq.Record.Game)
+	}
+
+	caged.VideoChangeCb(func() {
+		caged.ViewportRecalculate()
+		info, err := json.Marshal(infoOf(&caged))
+		if err != nil {
+			log.Error().Err(err).Msg("info")
+			return
+		}
+		if err := c.write(msgInfo, info); err != nil {
+			log.Error().Err(err).Msg("info send")
+		}
+	})
+
+	if err := caged.Load(rq.Game, rq.Path); err != nil {
+		return nil, err
+	}
+
+	caged.SetDataCb(func(data []byte) { _ = c.write(msgData, data) })
+
+	var hdr [videoHeaderSize]byte
+	caged.SetVideoCb(func(v app.Video) {
+		videoHeader{
+			W:        uint32(v.Frame.W),
+			H:        uint32(v.Frame.H),
+			Stride:   uint32(v.Frame.Stride),
+			Duration: v.Duration,
+		}.encode(hdr[:])
+		_ = c.write(msgVideo, hdr[:], v.Frame.Data)
+	})
+	var pcm []byte
+	caged.SetAudioCb(func(a app.Audio) {
+		if cap(pcm) < len(a.Data)*2 {
+			pcm = make([]byte, len(a.Data)*2)
+		}
+		_ = c.write(msgAudio, encodeSamples(a.Data, pcm))
+	})
+
+	return &caged, nil
+}
+
+func infoOf(c *libretro.Caged) Info {
+	w, h := c.ViewportSize()
+	return Info{
+		AspectEnabled:   c.AspectEnabled(),
+		AspectRatio:     c.AspectRatio(),
+		AudioSampleRate: c.AudioSampleRate(),
+		Flipped:         c.Flipped(),
+		KbMouse:         c.KbMouseSupport(),
+		PixFormat:       c.PixFormat(),
+		Rotation:        c.Rotation(),
+		Scale:           c.Scale(),
+		W:               w,
+		H:               h,
+	}
+}
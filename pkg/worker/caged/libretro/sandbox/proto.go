@@ -0,0 +1,230 @@
+package sandbox
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/giongto35/cloud-game/v3/pkg/config"
+	"github.com/giongto35/cloud-game/v3/pkg/games"
+)
+
+// The IPC protocol between the worker (parent) and sandboxed emulators (children).
+//
+// Each message is a binary frame of the following structure:
+//
+//	t - (1 byte) one of the predefined message types;
+//	n - (4 bytes, little-endian) the length of the payload;
+//	p - (n bytes) the payload.
+//
+// Control messages (init, save, load, etc.) are synchronous: the parent waits
+// for the msgResult reply before sending another one. Media (video, audio) and
+// the info change messages are sent by the child asynchronously.
+type msgType uint8
+
+const (
+	// parent -> child
+	msgInit     msgType = iota + 1 // JSON initRequest, replies with Info
+	msgStart                       // starts the main loop of the emulator
+	msgInput                       // port (1), device (1), data (n)
+	msgSave                        // saves the current state
+	msgLoad                        // restores the current state
+	msgReset                       // resets the emulator
+	msgRecord                      // active (1), user (n)
+	msgClose                       // stops the emulator, the child exits after the reply
+	msgResult                      // child -> parent: status (1), error text or data (n)
+	msgVideo                       // child -> parent: w (4), h (4), stride (4), duration (4), frame data (n)
+	msgAudio                       // child -> parent: 16-bit PCM samples (n)
+	msgInfo                        // child -> parent: JSON Info, sent on the video params change
+	msgData                        // child -> parent: app data for the users (n)
+	msgSaveSlot                    // saves the current state into the slot (n)
+	msgLoadSlot                    // restores the state from the slot (n)
+	msgSlots                       // replies with JSON of the existing save slots
+	msgCheats                      // replies with JSON of the cheats of the game
+	msgSetCheat                    // enabled (1), index (4)
+	msgDisks                       // replies with JSON of the disks of the game
+	msgEject                       // ejected (1)
+	msgSetDisk                     // index (4)
+	msgSpeed                       // replies with the speed (8)
+	msgSetSpeed                    // speed (8)
+	msgAdvance                     // runs one frame of the paused emulator
+	msgRewind                      // seconds (8)
+	msgOptions                     // replies with JSON of the core options
+	msgOption                      // key (n), 0 (1), value (n)
+)
+
+const (
+	statusOk  = 0
+	statusErr = 1
+
+	// maxMessageSize limits the size of incoming messages (32 MiB).
+	maxMessageSize = 32 << 20
+)
+
+var ErrMessageSize = errors.New("message is too big")
+
+// initRequest contains everything needed to run a game in the child process.
+type initRequest struct {
+	Emulator    config.Emulator
+	Recording   config.Recording
+	Storage     config.Storage
+	Debug       bool
+	Game        games.GameMetadata
+	Path        string
+	SessionId   string
+	SaveOnClose bool
+	// CloudId is a uid of the room state in the cloud storage,
+	// empty if the storage is disabled.
+	CloudId string
+	Record  struct {
+		Enabled bool
+		NoWait  bool
+		User    string
+		Game    string
+	}
+}
+
+// Info is a snapshot of the emulator params needed by the worker.
+type Info struct {
+	AspectEnabled   bool
+	AspectRatio     float32
+	AudioSampleRate int
+	Flipped         bool
+	KbMouse         bool
+	PixFormat       uint32
+	Rotation        uint
+	Scale           float64
+	W, H            int
+}
+
+// conn is a framed duplex IPC connection.
+type conn struct {
+	r   *bufio.Reader
+	w   *bufio.Writer
+	buf []byte // reusable read buffer
+	hdr [5]byte
+	mu  sync.Mutex
+}
+
+func newConn(r io.Reader, w io.Writer) *conn {
+	return &conn{r: bufio.NewReaderSize(r, 64<<10), w: bufio.NewWriterSize(w, 64<<10)}
+}
+
+// write sends a message with the payload made of all the parts.
+// It's safe to call from multiple goroutines.
+func (c *conn) write(t msgType, parts ...[]byte) error {
+	n := 0
+	for _, p := range parts {
+		n += len(p)
+	}
+	if n > maxMessageSize {
+		return ErrMessageSize
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var hdr [5]byte
+	hdr[0] = byte(t)
+	binary.LittleEndian.PutUint32(hdr[1:], uint32(n))
+	if _, err := c.w.Write(hdr[:]); err != nil {
+		return err
+	}
+	for _, p := range parts {
+		if _, err := c.w.Write(p); err != nil {
+			return err
+		}
+	}
+	return c.w.Flush()
+}
+
+// read returns the next message.
+// The payload is valid only until the next read call.
+// It's not safe to call from multiple goroutines.
+func (c *conn) read() (msgType, []byte, error) {
+	if _, err := io.ReadFull(c.r, c.hdr[:]); err != nil {
+		return 0, nil, err
+	}
+	n := binary.LittleEndian.Uint32(c.hdr[1:])
+	if n > maxMessageSize {
+		return 0, nil, fmt.Errorf("%w: %v", ErrMessageSize, n)
+	}
+	if cap(c.buf) < int(n) {
+		c.buf = make([]byte, n)
+	}
+	c.buf = c.buf[:n]
+	if _, err := io.ReadFull(c.r, c.buf); err != nil {
+		return 0, nil, err
+	}
+	return msgType(c.hdr[0]), c.buf, nil
+}
+
+func result(err error) []byte {
+	if err != nil {
+		return append([]byte{statusErr}, err.Error()...)
+	}
+	return []byte{statusOk}
+}
+
+func resultData(data []byte) []byte { return append([]byte{statusOk}, data...) }
+
+func unwrapResult(p []byte) ([]byte, error) {
+	if len(p) == 0 {
+		return nil, errors.New("empty result")
+	}
+	if p[0] != statusOk {
+		return nil, errors.New(string(p[1:]))
+	}
+	return p[1:], nil
+}
+
+type videoHeader struct {
+	W, H, Stride uint32
+	Duration     int32
+}
+
+const videoHeaderSize = 16
+
+func (v videoHeader) encode(b []byte) {
+	binary.LittleEndian.PutUint32(b[0:], v.W)
+	binary.LittleEndian.PutUint32(b[4:], v.H)
+	binary.LittleEndian.PutUint32(b[8:], v.Stride)
+	binary.LittleEndian.PutUint32(b[12:], uint32(v.Duration))
+}
+
+func decodeVideoHeader(b []byte) (v videoHeader, err error) {
+	if len(b) < videoHeaderSize {
+		return v, errors.New("bad video frame")
+	}
+	v.W = binary.LittleEndian.Uint32(b[0:])
+	v.H = binary.LittleEndian.Uint32(b[4:])
+	v.Stride = binary.LittleEndian.Uint32(b[8:])
+	v.Duration = int32(binary.LittleEndian.Uint32(b[12:]))
+	return v, nil
+}
+
+// encodeSamples writes PCM samples into the buffer b
+// (should be at least of 2*len(s) size) as little-endian bytes.
+func encodeSamples(s []int16, b []byte) []byte {
+	b = b[:len(s)*2]
+	for i, v := range s {
+		binary.LittleEndian.PutUint16(b[i*2:], uint16(v))
+	}
+	return b
+}
+
+// decodeSamples reads little-endian PCM samples from b into the buffer s.
+func decodeSamples(b []byte, s []int16) []int16 {
+	n := len(b) / 2
+	if cap(s) < n {
+		s = make([]int16, n)
+	}
+	s = s[:n]
+	for i := range n {
+		s[i] = int16(binary.LittleEndian.Uint16(b[i*2:]))
+	}
+	return s
+}
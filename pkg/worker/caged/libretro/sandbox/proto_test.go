@@ -0,0 +1,99 @@
+package sandbox
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"slices"
+	"testing"
+)
+
+func TestConn(t *testing.T) {
+	var buf bytes.Buffer
+	c := newConn(&buf, &buf)
+
+	msgs := []struct {
+		t     msgType
+		parts [][]byte
+	}{
+		{t: msgStart},
+		{t: msgInput, parts: [][]byte{{1, 2}, {3, 4, 5}}},
+		{t: msgResult, parts: [][]byte{result(errors.New("oops"))}},
+		{t: msgAudio, parts: [][]byte{make([]byte, 100_000)}},
+	}
+
+	for _, m := range msgs {
+		if err := c.write(m.t, m.parts...); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+
+	for _, m := range msgs {
+		tt, p, err := c.read()
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		if tt != m.t {
+			t.Errorf("wrong type: %v != %v", tt, m.t)
+		}
+		if want := bytes.Join(m.parts, nil); !bytes.Equal(p, want) {
+			t.Errorf("wrong payload of %v: %v != %v", m.t, len(p), len(want))
+		}
+	}
+
+	if _, _, err := c.read(); !errors.Is(err, io.EOF) {
+		t.Errorf("expected EOF, got: %v", err)
+	}
+}
+
+func TestConnMessageSize(t *testing.T) {
+	var buf bytes.Buffer
+	c := newConn(&buf, &buf)
+
+	if err := c.write(msgVideo, make([]byte, maxMessageSize+1)); !errors.Is(err, ErrMessageSize) {
+		t.Errorf("expected size error, got: %v", err)
+	}
+
+	buf.Write([]byte{byte(msgVideo), 0xff, 0xff, 0xff, 0xff})
+	if _, _, err := c.read(); !errors.Is(err, ErrMessageSize) {
+		t.Errorf("expected size error, got: %v", err)
+	}
+}
+
+func TestResult(t *testing.T) {
+	if data, err := unwrapResult(resultData([]byte("data"))); err != nil || string(data) != "data" {
+		t.Errorf("wrong result: %s, %v", data, err)
+	}
+	if _, err := unwrapResult(result(errors.New("oops"))); err == nil || err.Error() != "oops" {
+		t.Errorf("wrong error: %v", err)
+	}
+	if _, err := unwrapResult(nil); err == nil {
+		t.Errorf("no error for empty result")
+	}
+}
+
+func TestVideoHeader(t *testing.T) {
+	h := videoHeader{W: 320, H: 240, Stride: 1280, Duration: -16_666_667}
+	var b [videoHeaderSize]byte
+	h.encode(b[:])
+
+	got, err := decodeVideoHeader(b[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != h {
+		t.Errorf("wrong header: %+v != %+v", got, h)
+	}
+
+	if _, err := decodeVideoHeader(b[:3]); err == nil {
+		t.Errorf("no error for short header")
+	}
+}
+
+func TestSamples(t *testing.T) {
+	s := []int16{0, 1, -1, 32767, -32768, 12345}
+	b := encodeSamples(s, make([]byte, len(s)*2))
+	if got := decodeSamples(b, nil); !slices.Equal(got, s) {
+		t.Errorf("wrong samples: %v != %v", got, s)
+	}
+}
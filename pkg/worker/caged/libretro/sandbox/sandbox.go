@@ -0,0 +1,559 @@
+// Package sandbox runs Libretro emulators in supervised child processes.
+//
+// Each sandboxed emulator is a copy of the worker executable (see IsChild and Run)
+// that loads one game and talks with the worker over a pair of pipes (see proto.go).
+// A crash inside a core kills only the child process, the worker detects it,
+// notifies the crash callback and may restart the game from the last save.
+package sandbox
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/giongto35/cloud-game/v3/pkg/cloud"
+	"github.com/giongto35/cloud-game/v3/pkg/config"
+	"github.com/giongto35/cloud-game/v3/pkg/games"
+	"github.com/giongto35/cloud-game/v3/pkg/logger"
+	"github.com/giongto35/cloud-game/v3/pkg/worker/caged/app"
+	"github.com/giongto35/cloud-game/v3/pkg/worker/caged/libretro"
+)
+
+const (
+	callTimeout  = 30 * time.Second
+	closeTimeout = 10 * time.Second
+)
+
+var (
+	ErrDied      = errors.New("emulator process has died")
+	ErrNoProcess = errors.New("no emulator process")
+	ErrTimeout   = errors.New("emulator process timeout")
+)
+
+type Conf struct {
+	Emulator  config.Emulator
+	Recording config.Recording
+	Storage   config.Storage
+}
+
+// Caged is a proxy to an emulator running in a child process.
+// It implements the app.App interface along with the controls of libretro.Caged.
+type Caged struct {
+	conf Conf
+	rq   initRequest
+	log  *logger.Logger
+
+	proc *process
+	info Info
+	mu   sync.Mutex
+
+	callMu sync.Mutex
+
+	onAudio       func(app.Audio)
+	onData        func([]byte)
+	onVideo       func(app.Video)
+	onVideoChange func()
+	onCrash       func(restarting bool)
+
+	closed   atomic.Bool
+	started  atomic.Bool
+	restarts int
+}
+
+// process is a running child process of the emulator.
+type process struct {
+	cmd     *exec.Cmd
+	conn    *conn
+	done    chan struct{} // closed when the process exits
+	err     error
+	replies chan []byte
+}
+
+func New(conf Conf, log *logger.Logger) *Caged {
+	return &Caged{
+		conf:    conf,
+		log:     log.Extend(log.With().Str("m", "sandbox")),
+		onAudio: func(app.Audio) {},
+		onData:  func([]byte) {},
+		onVideo: func(app.Video) {},
+		onCrash: func(bool) {},
+	}
+}
+
+func (c *Caged) Name() string { return "libretro" }
+
+// Spawn returns a new unique sandbox for each room.
+func (c *Caged) Spawn() app.App { return New(c.conf, c.log) }
+
+func (c *Caged) Init() error { return nil }
+
+// ReloadFrontend resets the game params before the next Load call.
+func (c *Caged) ReloadFrontend() {
+	c.rq = initRequest{
+		Emulator:  c.conf.Emulator,
+		Recording: c.conf.Recording,
+		Storage:   c.conf.Storage,
+		Debug:     c.log.GetLevel() <= logger.DebugLevel,
+	}
+}
+
+func (c *Caged) SetSessionId(name string) { c.rq.SessionId = name }
+func (c *Caged) SetSaveOnClose(v bool)    { c.rq.SaveOnClose = v }
+
+// EnableCloudStorage enables the cloud storage for the child process.
+// The child creates its own connection to the storage with the worker config.
+func (c *Caged) EnableCloudStorage(uid string, storage cloud.Storage) {
+	if storage == nil {
+		return
+	}
+	c.rq.CloudId = uid
+}
+
+func (c *Caged) EnableRecording(nowait bool, user string, game string) {
+	if !c.conf.Recording.Enabled {
+		return
+	}
+	c.rq.Record.Enabled = true
+	c.rq.Record.NoWait = nowait
+	c.rq.Record.User = user
+	c.rq.Record.Game = game
+}
+
+// Load starts a new child process with the game.
+func (c *Caged) Load(game games.GameMetadata, path string) error {
+	c.rq.Game = game
+	c.rq.Path = path
+	p, err := c.launch()
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.proc = p
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *Caged) Start() {
+	if _, err := c.call(msgStart); err != nil {
+		c.log.Error().Err(err).Msg("start fail")
+		return
+	}
+	c.started.Store(true)
+}
+
+func (c *Caged) Close() {
+	if c.closed.Swap(true) {
+		return
+	}
+	c.stop(c.process())
+	c.log.Debug().Msg("sandbox closed")
+}
+
+// stop gracefully stops the child process or kills it after some time.
+func (c *Caged) stop(p *process) {
+	if p == nil {
+		return
+	}
+	if _, err := c.callp(p, msgClose); err != nil && !errors.Is(err, ErrDied) {
+		c.log.Warn().Err(err).Msg("close fail")
+	}
+	select {
+	case <-p.done:
+	case <-time.After(closeTimeout):
+		c.log.Warn().Msgf("kill the emulator process [%v]", p.cmd.Process.Pid)
+		_ = p.cmd.Process.Kill()
+		<-p.done
+	}
+}
+
+func (c *Caged) Input(port int, device byte, data []byte) {
+	p := c.process()
+	if p == nil {
+		return
+	}
+	if err := p.conn.write(msgInput, []byte{byte(port), device}, data); err != nil {
+		c.log.Warn().Err(err).Msg("input")
+	}
+}
+
+func (c *Caged) Reset() {
+	if _, err := c.call(msgReset); err != nil {
+		c.log.Error().Err(err).Msg("reset fail")
+	}
+}
+
+func (c *Caged) SaveGameState() error {
+	_, err := c.call(msgSave)
+	return err
+}
+
+func (c *Caged) RestoreGameState() error {
+	_, err := c.call(msgLoad)
+	return err
+}
+
+func (c *Caged) SaveSlot(slot string) error {
+	_, err := c.call(msgSaveSlot, []byte(slot))
+	return err
+}
+
+func (c *Caged) LoadSlot(slot string) error {
+	_, err := c.call(msgLoadSlot, []byte(slot))
+	return err
+}
+
+func (c *Caged) Slots() []libretro.SaveSlot {
+	data, err := c.call(msgSlots)
+	if err != nil {
+		c.log.Error().Err(err).Msg("slots fail")
+		return nil
+	}
+	var slots []libretro.SaveSlot
+	if err := json.Unmarshal(data, &slots); err != nil {
+		c.log.Error().Err(err).Msg("slots fail")
+		return nil
+	}
+	return slots
+}
+
+func (c *Caged) Cheats() []games.Cheat {
+	data, err := c.call(msgCheats)
+	if err != nil {
+		c.log.Error().Err(err).Msg("cheats fail")
+		return nil
+	}
+	var cheats []games.Cheat
+	if err := json.Unmarshal(data, &cheats); err != nil {
+		c.log.Error().Err(err).Msg("cheats fail")
+		return nil
+	}
+	return cheats
+}
+
+func (c *Caged) SetCheat(index int, enabled bool) error {
+	p := []byte{0}
+	if enabled {
+		p[0] = 1
+	}
+	_, err := c.call(msgSetCheat, binary.LittleEndian.AppendUint32(p, uint32(index)))
+	return err
+}
+
+func (c *Caged) Disks() (libretro.Disks, error) {
+	var disks libretro.Disks
+	data, err := c.call(msgDisks)
+	if err != nil {
+		return disks, err
+	}
+	err = json.Unmarshal(data, &disks)
+	return disks, err
+}
+
+func (c *Caged) EjectDisk(ejected bool) error {
+	p := []byte{0}
+	if ejected {
+		p[0] = 1
+	}
+	_, err := c.call(msgEject, p)
+	return err
+}
+
+func (c *Caged) SetDisk(index int) error {
+	_, err := c.call(msgSetDisk, binary.LittleEndian.AppendUint32(nil, uint32(index)))
+	return err
+}
+
+func (c *Caged) Speed() float64 {
+	data, err := c.call(msgSpeed)
+	if err != nil || len(data) < 8 {
+		c.log.Error().Err(err).Msg("speed fail")
+		return libretro.SpeedNormal
+	}
+	return math.Float64frombits(binary.LittleEndian.Uint64(data))
+}
+
+func (c *Caged) SetSpeed(speed float64) error {
+	_, err := c.call(msgSetSpeed, binary.LittleEndian.AppendUint64(nil, math.Float64bits(speed)))
+	return err
+}
+
+func (c *Caged) FrameAdvance() error {
+	_, err := c.call(msgAdvance)
+	return err
+}
+
+func (c *Caged) Rewind(seconds float64) error {
+	_, err := c.call(msgRewind, binary.LittleEndian.AppendUint64(nil, math.Float64bits(seconds)))
+	return err
+}
+
+func (c *Caged) CoreOptions() []libretro.CoreOption {
+	data, err := c.call(msgOptions)
+	if err != nil {
+		c.log.Error().Err(err).Msg("core options fail")
+		return nil
+	}
+	var opts []libretro.CoreOption
+	if err := json.Unmarshal(data, &opts); err != nil {
+		c.log.Error().Err(err).Msg("core options fail")
+		return nil
+	}
+	return opts
+}
+
+func (c *Caged) SetCoreOption(key, value string) error {
+	_, err := c.call(msgOption, []byte(key), []byte{0}, []byte(value))
+	return err
+}
+
+func (c *Caged) ToggleRecording(active bool, user string) {
+	a := byte(0)
+	if active {
+		a = 1
+	}
+	c.rq.Record.NoWait, c.rq.Record.User = active, user
+	if _, err := c.call(msgRecord, []byte{a}, []byte(user)); err != nil {
+		c.log.Error().Err(err).Msg("record fail")
+	}
+}
+
+// ViewportRecalculate does nothing, the child process
+// recalculates the viewport before sending the info.
+func (c *Caged) ViewportRecalculate() {}
+
+func (c *Caged) AspectEnabled() bool      { return c.Info().AspectEnabled }
+func (c *Caged) AspectRatio() float32     { return c.Info().AspectRatio }
+func (c *Caged) AudioSampleRate() int     { return c.Info().AudioSampleRate }
+func (c *Caged) Flipped() bool            { return c.Info().Flipped }
+func (c *Caged) KbMouseSupport() bool     { return c.Info().KbMouse }
+func (c *Caged) PixFormat() uint32        { return c.Info().PixFormat }
+func (c *Caged) Rotation() uint           { return c.Info().Rotation }
+func (c *Caged) Scale() float64           { return c.Info().Scale }
+func (c *Caged) ViewportSize() (int, int) { i := c.Info(); return i.W, i.H }
+
+func (c *Caged) SetAudioCb(cb func(app.Audio)) { c.onAudio = cb }
+func (c *Caged) SetDataCb(cb func([]byte))     { c.onData = cb }
+func (c *Caged) SetVideoCb(cb func(app.Video)) { c.onVideo = cb }
+
+// VideoChangeCb adds a callback when video params are changed by the app.
+func (c *Caged) VideoChangeCb(fn func()) { c.onVideoChange = fn }
+
+// SetCrashCb sets a callback for unexpected exits of the child process.
+// The restarting param tells if the game will be restarted.
+func (c *Caged) SetCrashCb(fn func(restarting bool)) { c.onCrash = fn }
+
+func (c *Caged) Info() Info { c.mu.Lock(); defer c.mu.Unlock(); return c.info }
+
+func (c *Caged) setInfo(i Info) { c.mu.Lock(); c.info = i; c.mu.Unlock() }
+
+func (c *Caged) process() *process { c.mu.Lock(); defer c.mu.Unlock(); return c.proc }
+
+// launch starts a new child process and loads the game there.
+func (c *Caged) launch() (*process, error) {
+	p, err := c.spawn()
+	if err != nil {
+		return nil, err
+	}
+	rq, err := json.Marshal(c.rq)
+	if err != nil {
+		_ = p.cmd.Process.Kill()
+		return nil, err
+	}
+	data, err := c.callp(p, msgInit, rq)
+	if err != nil {
+		_ = p.cmd.Process.Kill()
+		return nil, fmt.Errorf("sandbox init: %w", err)
+	}
+	var info Info
+	if err := json.Unmarshal(data, &info); err != nil {
+		_ = p.cmd.Process.Kill()
+		return nil, err
+	}
+	c.setInfo(info)
+	return p, nil
+}
+
+func (c *Caged) spawn() (*process, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	// parent -> child
+	cr, pw, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	// child -> parent
+	pr, cw, err := os.Pipe()
+	if err != nil {
+		_, _ = cr.Close(), pw.Close()
+		return nil, err
+	}
+
+	cmd := exec.Command(exe)
+	cmd.Env = append(os.Environ(), envChild+"=1")
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.ExtraFiles = []*os.File{cr, cw} // fdIn, fdOut
+	err = cmd.Start()
+	// the child ends are not needed in the parent anymore
+	_, _ = cr.Close(), cw.Close()
+	if err != nil {
+		_, _ = pr.Close(), pw.Close()
+		return nil, err
+	}
+
+	p := &process{
+		cmd:     cmd,
+		conn:    newConn(pr, pw),
+		done:    make(chan struct{}),
+		replies: make(chan []byte, 1),
+	}
+	c.log.Debug().Msgf("emulator process [%v] has started", cmd.Process.Pid)
+
+	go c.read(p)
+	go func() {
+		p.err = cmd.Wait()
+		_, _ = pr.Close(), pw.Close()
+		close(p.done)
+		c.handleExit(p)
+	}()
+	return p, nil
+}
+
+// read handles incoming messages of the child process.
+func (c *Caged) read(p *process) {
+	var samples []int16
+	for {
+		t, data, err := p.conn.read()
+		if err != nil {
+			return
+		}
+		switch t {
+		case msgVideo:
+			h, err := decodeVideoHeader(data)
+			if err != nil {
+				c.log.Warn().Err(err).Send()
+				continue
+			}
+			c.onVideo(app.Video{
+				Frame: app.RawFrame{
+					Data:   data[videoHeaderSize:],
+					Stride: int(h.Stride),
+					W:      int(h.W),
+					H:      int(h.H),
+				},
+				Duration: h.Duration,
+			})
+		case msgAudio:
+			samples = decodeSamples(data, samples)
+			c.onAudio(app.Audio{Data: samples})
+		case msgData:
+			c.onData(data)
+		case msgInfo:
+			var info Info
+			if err := json.Unmarshal(data, &info); err != nil {
+				c.log.Warn().Err(err).Msg("bad info")
+				continue
+			}
+			c.setInfo(info)
+			if c.onVideoChange != nil {
+				c.onVideoChange()
+			}
+		case msgResult:
+			// the reader never waits, nobody may wait for the reply
+			select {
+			case p.replies <- bytes.Clone(data):
+			default:
+				c.log.Warn().Msg("unexpected reply of the emulator process")
+			}
+		}
+	}
+}
+
+// call sends a message to the current child process and waits for the reply.
+func (c *Caged) call(t msgType, payload ...[]byte) ([]byte, error) {
+	p := c.process()
+	if p == nil {
+		return nil, ErrNoProcess
+	}
+	return c.callp(p, t, payload...)
+}
+
+func (c *Caged) callp(p *process, t msgType, payload ...[]byte) ([]byte, error) {
+	c.callMu.Lock()
+	defer c.callMu.Unlock()
+
+	// a late reply of some previous call
+	select {
+	case <-p.replies:
+		c.log.Warn().Msg("dropped a late reply of the emulator process")
+	default:
+	}
+	if err := p.conn.write(t, payload...); err != nil {
+		select {
+		case <-p.done:
+			return nil, ErrDied
+		default:
+			return nil, err
+		}
+	}
+	select {
+	case r := <-p.replies:
+		return unwrapResult(r)
+	case <-p.done:
+		return nil, ErrDied
+	case <-time.After(callTimeout):
+		// a hung emulator is as good as a dead one
+		c.log.Error().Msgf("emulator process [%v] is not responding", p.cmd.Process.Pid)
+		_ = p.cmd.Process.Kill()
+		return nil, ErrTimeout
+	}
+}
+
+// handleExit checks unexpected exits of the child process
+// and restarts the game if needed.
+func (c *Caged) handleExit(p *process) {
+	if c.closed.Load() || c.process() != p {
+		return
+	}
+	c.log.Error().Err(p.err).Msgf("emulator process [%v] has died", p.cmd.Process.Pid)
+
+	sb := c.conf.Emulator.Sandbox
+	restart := sb.Restart && c.started.Load() && c.restarts < sb.MaxRestarts
+	c.onCrash(restart)
+	if !restart {
+		return
+	}
+	c.restarts++
+	c.log.Info().Msgf("restart the emulator (%v/%v)", c.restarts, sb.MaxRestarts)
+
+	// the game state will be restored from the last save on start
+	np, err := c.launch()
+	if err == nil {
+		c.mu.Lock()
+		c.proc = np
+		c.mu.Unlock()
+		if c.closed.Load() {
+			// the room was closed during the restart
+			c.stop(np)
+			return
+		}
+		if c.onVideoChange != nil {
+			c.onVideoChange()
+		}
+		_, err = c.call(msgStart)
+	}
+	if err != nil {
+		c.log.Error().Err(err).Msg("restart fail")
+		c.onCrash(false)
+	}
+}
@@ -0,0 +1,160 @@
+package libretro
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/giongto35/cloud-game/v3/pkg/os"
+	"github.com/giongto35/cloud-game/v3/pkg/worker/caged/app"
+)
+
+// Save slots of a game session:
+//   - "" is the main save (which is loaded on start),
+//   - "1".."N" are the user slots,
+//   - "auto1".."autoN" are the last autosaves.
+const (
+	autoSlot   = "auto"
+	thumbExt   = ".png"
+	thumbWidth = 160
+)
+
+var ErrNoSlot = errors.New("no such save slot")
+
+// SaveSlot is a saved state of the game session.
+type SaveSlot struct {
+	Name  string
+	Auto  bool
+	Time  time.Time
+	Thumb []byte // PNG image of the game screen
+}
+
+// SlotNames returns the names of all the save slots besides the main save.
+func (f *Frontend) SlotNames() []string {
+	var names []string
+	for i := range f.conf.SaveSlots {
+		names = append(names, strconv.Itoa(i+1))
+	}
+	for i := range f.conf.AutosaveHistory {
+		names = append(names, autoSlot+strconv.Itoa(i+1))
+	}
+	return names
+}
+
+func (f *Frontend) SlotPath(slot string) string { return f.storage.GetSlotPath(slot) }
+
+// SaveSlot saves the current state into the slot.
+func (f *Frontend) SaveSlot(slot string) error {
+	if !f.hasSlot(slot) {
+		return ErrNoSlot
+	}
+	return f.save(f.SlotPath(slot))
+}
+
+// LoadSlot restores the state from the slot,
+// the main save comes with its SRAM.
+func (f *Frontend) LoadSlot(slot string) error {
+	if !f.hasSlot(slot) {
+		return ErrNoSlot
+	}
+	if slot == "" {
+		return f.Load()
+	}
+	return f.load(f.SlotPath(slot))
+}
+
+// Slots returns all the saved slots of the session.
+func (f *Frontend) Slots() []SaveSlot {
+	var slots []SaveSlot
+	for _, name := range append([]string{""}, f.SlotNames()...) {
+		path := f.SlotPath(name)
+		t, err := os.StatTime(path)
+		if err != nil {
+			continue
+		}
+		thumb, _ := os.ReadFile(path + thumbExt)
+		slots = append(slots, SaveSlot{Name: name, Auto: isAutoSlot(name), Time: t, Thumb: thumb})
+	}
+	return slots
+}
+
+func (f *Frontend) hasSlot(slot string) bool {
+	return slot == "" || slices.Contains(f.SlotNames(), slot)
+}
+
+// nextAutoSlot returns an empty or the oldest autosave slot,
+// or nothing if the history is disabled.
+func (f *Frontend) nextAutoSlot() string {
+	var slot string
+	var oldest time.Time
+	for i := range f.conf.AutosaveHistory {
+		name := autoSlot + strconv.Itoa(i+1)
+		t, err := os.StatTime(f.SlotPath(name))
+		if err != nil {
+			return name
+		}
+		if slot == "" || t.Before(oldest) {
+			slot, oldest = name, t
+		}
+	}
+	return slot
+}
+
+func isAutoSlot(name string) bool { return strings.HasPrefix(name, autoSlot) }
+
+// thumbnail returns a downscaled PNG image of the video frame.
+func thumbnail(frame app.RawFrame, pixFmt uint32, flip bool) ([]byte, error) {
+	if frame.W <= 0 || frame.H <= 0 {
+		return nil, errors.New("no frame")
+	}
+	bpp := 2
+	if pixFmt == 1 {
+		bpp = 4
+	}
+	if frame.Stride < frame.W*bpp || len(frame.Data) < frame.Stride*(frame.H-1)+frame.W*bpp {
+		return nil, errors.New("bad frame")
+	}
+
+	w, h := frame.W, frame.H
+	if w > thumbWidth {
+		w, h = thumbWidth, max(frame.H*thumbWidth/frame.W, 1)
+	}
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		sy := y * frame.H / h
+		if flip {
+			sy = (h - 1 - y) * frame.H / h
+		}
+		for x := range w {
+			i := sy*frame.Stride + x*frame.W/w*bpp
+			img.SetRGBA(x, y, pixel(frame.Data[i:i+bpp], pixFmt))
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// pixel converts a Libretro pixel into RGBA.
+func pixel(p []byte, pixFmt uint32) color.RGBA {
+	switch pixFmt {
+	case 1: // XRGB8888
+		return color.RGBA{R: p[2], G: p[1], B: p[0], A: 0xff}
+	case 2: // RGB565
+		v := binary.LittleEndian.Uint16(p)
+		return color.RGBA{R: uint8(v>>11) << 3, G: uint8(v>>5&0x3f) << 2, B: uint8(v&0x1f) << 3, A: 0xff}
+	default: // 0RGB1555
+		v := binary.LittleEndian.Uint16(p)
+		return color.RGBA{R: uint8(v>>10&0x1f) << 3, G: uint8(v>>5&0x1f) << 3, B: uint8(v&0x1f) << 3, A: 0xff}
+	}
+}
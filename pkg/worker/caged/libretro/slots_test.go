@@ -0,0 +1,43 @@
+package libretro
+
+import (
+	"bytes"
+	"image/png"
+	"testing"
+
+	"github.com/giongto35/cloud-game/v3/pkg/worker/caged/app"
+)
+
+func TestThumbnail(t *testing.T) {
+	w, h := 320, 240
+	frame := app.RawFrame{Data: make([]byte, w*h*4), Stride: w * 4, W: w, H: h}
+	// the first row is red in BGRX
+	for x := range w {
+		frame.Data[x*4+2] = 0xff
+	}
+
+	data, err := thumbnail(frame, 1, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != thumbWidth || b.Dy() != 120 {
+		t.Errorf("wrong thumbnail size: %v", b)
+	}
+	if r, g, b, _ := img.At(0, 0).RGBA(); r>>8 != 0xff || g != 0 || b != 0 {
+		t.Errorf("wrong color: %v %v %v", r, g, b)
+	}
+
+	flipped, _ := thumbnail(frame, 1, true)
+	img, _ = png.Decode(bytes.NewReader(flipped))
+	if r, _, _, _ := img.At(0, 119).RGBA(); r>>8 != 0xff {
+		t.Errorf("not flipped")
+	}
+
+	if _, err := thumbnail(app.RawFrame{Data: []byte{1}, Stride: 2, W: 1, H: 1}, 2, false); err == nil {
+		t.Errorf("no error for a bad frame")
+	}
+}
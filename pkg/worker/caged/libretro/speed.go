@@ -0,0 +1,124 @@
+package libretro
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"time"
+)
+
+// The speed of the emulation: pause, slow motion and fast-forward.
+// The fast-forward runs a few frames per frame time and plays only the last one,
+// the slow motion makes the frame time longer and stretches its audio,
+// so the sound is pitched down.
+const (
+	SpeedPause  = 0.0
+	SpeedNormal = 1.0
+)
+
+// Speeds is the list of the supported speeds.
+var Speeds = []float64{SpeedPause, 0.5, SpeedNormal, 2, 4}
+
+var (
+	ErrSpeed     = errors.New("unsupported speed")
+	ErrNotPaused = errors.New("not paused")
+)
+
+// Speed returns the current speed of the emulation.
+func (f *Frontend) Speed() float64 {
+	if f.paused.Load() {
+		return SpeedPause
+	}
+	if s := f.speed.Load(); s != 0 {
+		return math.Float64frombits(s)
+	}
+	return SpeedNormal
+}
+
+// SetSpeed changes the speed of the emulation, 0 is pause.
+func (f *Frontend) SetSpeed(speed float64) error {
+	if !slices.Contains(Speeds, speed) {
+		return ErrSpeed
+	}
+	// the netplay users follow the frames of the normal speed
+	if f.netplay != nil && speed != SpeedPause && speed != SpeedNormal {
+		return ErrSpeed
+	}
+	if speed != SpeedPause {
+		f.speed.Store(math.Float64bits(speed))
+	}
+	f.paused.Store(speed == SpeedPause)
+	f.wakeUp()
+	f.log.Debug().Msgf("Speed: %v", speed)
+	return nil
+}
+
+// FrameAdvance runs one frame of the paused emulation.
+func (f *Frontend) FrameAdvance() error {
+	if f.Speed() != SpeedPause {
+		return ErrNotPaused
+	}
+	f.steps.Add(1)
+	f.wakeUp()
+	return nil
+}
+
+func (f *Frontend) wakeUp() {
+	select {
+	case f.wake <- struct{}{}:
+	default:
+	}
+}
+
+// pace returns the time of one frame and the number of frames
+// to run in that time with the current speed.
+func (f *Frontend) pace(frameTime time.Duration) (time.Duration, int) {
+	speed := f.Speed()
+	if speed > SpeedNormal {
+		return frameTime, int(speed)
+	}
+	return time.Duration(float64(frameTime) / speed), 1
+}
+
+// pause waits until the emulation is resumed, or runs one frame
+// if asked, false when the emulator is closed.
+func (f *Frontend) pause(tick func()) bool {
+	select {
+	case <-f.done:
+		return false
+	case <-f.wake:
+	}
+	for f.Speed() == SpeedPause && f.steps.Load() > 0 {
+		f.steps.Add(-1)
+		tick()
+	}
+	return true
+}
+
+// stretch makes the stereo samples longer by 1/speed into dst,
+// the missing samples are linearly interpolated.
+func stretch(dst, src []int16, speed float64) []int16 {
+	in := len(src) / 2
+	out := int(float64(in) / speed)
+	if cap(dst) < out*2 {
+		dst = make([]int16, out*2)
+	}
+	dst = dst[:out*2]
+	if in == 0 {
+		return dst[:0]
+	}
+	for i := range out {
+		pos := float64(i) * speed
+		k := int(pos)
+		if k >= in-1 {
+			dst[i*2], dst[i*2+1] = src[(in-1)*2], src[(in-1)*2+1]
+			continue
+		}
+		frac := pos - float64(k)
+		for c := range 2 {
+			a, b := float64(src[k*2+c]), float64(src[(k+1)*2+c])
+			dst[i*2+c] = int16(a + (b-a)*frac)
+		}
+	}
+	return dst
+}
@@ -0,0 +1,26 @@
+package libretro
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestStretch(t *testing.T) {
+	tests := []struct {
+		src   []int16
+		speed float64
+		want  []int16
+	}{
+		{src: []int16{0, 100, 10, 200}, speed: 0.5, want: []int16{0, 100, 5, 150, 10, 200, 10, 200}},
+		{src: []int16{0, 0, 40, -40}, speed: 0.25, want: []int16{0, 0, 10, -10, 20, -20, 30, -30, 40, -40, 40, -40, 40, -40, 40, -40}},
+		{src: []int16{1, 2, 3, 4}, speed: 1, want: []int16{1, 2, 3, 4}},
+		{src: nil, speed: 0.5, want: []int16{}},
+	}
+	var dst []int16
+	for _, test := range tests {
+		dst = stretch(dst, test.src, test.speed)
+		if !slices.Equal(dst, test.want) {
+			t.Errorf("stretch %v x%v = %v, want %v", test.src, test.speed, dst, test.want)
+		}
+	}
+}
@@ -12,6 +12,8 @@ type (
 	Storage interface {
 		MainPath() string
 		GetSavePath() string
+		// GetSlotPath returns the path of the slot save, "" is the main save
+		GetSlotPath(slot string) string
 		GetSRAMPath() string
 		SetMainSaveName(name string)
 		SetNonBlocking(v bool)
@@ -48,8 +50,16 @@ func (s *StateStorage) Save(path string, dat []byte) error {
 	return os.WriteFile(path, dat, 0644)
 }
 
-func (z *ZipStorage) GetSavePath() string { return z.Storage.GetSavePath() + zip.Ext }
-func (z *ZipStorage) GetSRAMPath() string { return z.Storage.GetSRAMPath() + zip.Ext }
+func (s *StateStorage) GetSlotPath(slot string) string {
+	if slot == "" {
+		return s.GetSavePath()
+	}
+	return filepath.Join(s.Path, s.MainSave+"."+slot+".dat")
+}
+
+func (z *ZipStorage) GetSavePath() string         { return z.Storage.GetSavePath() + zip.Ext }
+func (z *ZipStorage) GetSRAMPath() string         { return z.Storage.GetSRAMPath() + zip.Ext }
+func (z *ZipStorage) GetSlotPath(s string) string { return z.Storage.GetSlotPath(s) + zip.Ext }
 
 // Load loads a zip file with the path specified.
 func (z *ZipStorage) Load(path string) ([]byte, error) {
@@ -34,3 +34,24 @@ func TestZipStorage(t *testing.T) {
 		t.Errorf("Zip storage got = %v, want %v", d, expect)
 	}
 }
+
+func TestSlotPath(t *testing.T) {
+	s := &StateStorage{Path: "saves", MainSave: "game"}
+	z := &ZipStorage{Storage: s}
+
+	tests := []struct {
+		st   Storage
+		slot string
+		want string
+	}{
+		{st: s, slot: "", want: filepath.Join("saves", "game.dat")},
+		{st: s, slot: "1", want: filepath.Join("saves", "game.1.dat")},
+		{st: z, slot: "", want: filepath.Join("saves", "game.dat.zip")},
+		{st: z, slot: "auto2", want: filepath.Join("saves", "game.auto2.dat.zip")},
+	}
+	for _, test := range tests {
+		if got := test.st.GetSlotPath(test.slot); got != test.want {
+			t.Errorf("slot %q path = %v, want %v", test.slot, got, test.want)
+		}
+	}
+}
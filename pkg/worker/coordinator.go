@@ -28,6 +28,10 @@ type coordinator struct {
 
 var connector com.Client
 
+// maxMessageSize allows big enough messages
+// for the files of the coordinator storage.
+const maxMessageSize = 32 << 20
+
 func newCoordinatorConnection(host string, conf config.Worker, addr string, log *logger.Logger) (*coordinator, error) {
 	scheme := "ws"
 	if conf.Network.Secure {
@@ -52,6 +56,7 @@ func newCoordinatorConnection(host string, conf config.Worker, addr string, log
 	if err != nil {
 		return nil, err
 	}
+	conn.SetMaxReadSize(maxMessageSize)
 
 	clog := log.Extend(log.With().Str(logger.ClientField, "c"))
 	client := com.NewConnection[api.PT, api.In[com.Uid], api.Out, *api.Out](conn, id, clog)
@@ -80,8 +85,16 @@ func (c *coordinator) HandleRequests(w *Worker) chan struct{} {
 			err = api.Do(x, func(d api.SaveGameRequest) { out = c.HandleSaveGame(d, w) })
 		case api.LoadGame:
 			err = api.Do(x, func(d api.LoadGameRequest) { out = c.HandleLoadGame(d, w) })
+		case api.SaveSlot:
+			err = api.Do(x, func(d api.SaveSlotRequest) { out = c.HandleSaveSlot(d, w) })
+		case api.LoadSlot:
+			err = api.Do(x, func(d api.LoadSlotRequest) { out = c.HandleLoadSlot(d, w) })
+		case api.ListSlots:
+			err = api.Do(x, func(d api.ListSlotsRequest) { out = c.HandleListSlots(d, w) })
 		case api.ChangePlayer:
 			err = api.Do(x, func(d api.ChangePlayerRequest) { out = c.HandleChangePlayer(d, w) })
+		case api.VideoLayer:
+			err = api.Do(x, func(d api.VideoLayerRequest) { out = c.HandleVideoLayer(d, w) })
 		case api.RecordGame:
 			err = api.Do(x, func(d api.RecordGameRequest) { out = c.HandleRecordGame(d, w) })
 		case api.WebrtcSignal:
@@ -92,6 +105,40 @@ func (c *coordinator) HandleRequests(w *Worker) chan struct{} {
 			err = api.Do(x, func(d api.GameQuitRequest) { c.HandleQuitGame(d, w) })
 		case api.ResetGame:
 			err = api.Do(x, func(d api.ResetGameRequest) { c.HandleResetGame(d, w) })
+		case api.RoomUsers:
+			err = api.Do(x, func(d api.RoomUsersRequest) { out = c.HandleRoomUsers(d, w) })
+		case api.KickUser:
+			err = api.Do(x, func(d api.KickUserRequest) { out = c.HandleKickUser(d, w) })
+		case api.TransferHost:
+			err = api.Do(x, func(d api.TransferHostRequest) { out = c.HandleTransferHost(d, w) })
+		case api.LockRoom:
+			err = api.Do(x, func(d api.LockRoomRequest) { out = c.HandleLockRoom(d, w) })
+		case api.VideoFilter:
+			err = api.Do(x, func(d api.VideoFilterRequest) { out = c.HandleVideoFilter(d, w) })
+		case api.RoomAudio:
+			err = api.Do(x, func(d api.RoomAudioRequest) { out = c.HandleRoomAudio(d, w) })
+		case api.Cheats:
+			err = api.Do(x, func(d api.CheatsRequest) { out = c.HandleCheats(d, w) })
+		case api.SetCheat:
+			err = api.Do(x, func(d api.SetCheatRequest) { out = c.HandleSetCheat(d, w) })
+		case api.Disks:
+			err = api.Do(x, func(d api.DisksRequest) { out = c.HandleDisks(d, w) })
+		case api.EjectDisk:
+			err = api.Do(x, func(d api.EjectDiskRequest) { out = c.HandleEjectDisk(d, w) })
+		case api.SetDisk:
+			err = api.Do(x, func(d api.SetDiskRequest) { out = c.HandleSetDisk(d, w) })
+		case api.Speed:
+			err = api.Do(x, func(d api.SpeedRequest) { out = c.HandleSpeed(d, w) })
+		case api.FrameAdvance:
+			err = api.Do(x, func(d api.FrameAdvanceRequest) { out = c.HandleFrameAdvance(d, w) })
+		case api.Rewind:
+			err = api.Do(x, func(d api.RewindRequest) { out = c.HandleRewind(d, w) })
+		case api.CoreOptions:
+			err = api.Do(x, func(d api.CoreOptionsRequest) { out = c.HandleCoreOptions(d, w) })
+		case api.SetCoreOption:
+			err = api.Do(x, func(d api.SetCoreOptionRequest) { out = c.HandleSetCoreOption(d, w) })
+		case api.WhepToken:
+			err = api.Do(x, func(d api.WhepTokenRequest) { out = c.HandleWhepToken(d, w) })
 		default:
 			c.log.Warn().Msgf("unhandled packet type %v", x.T)
 		}
@@ -105,8 +152,30 @@ func (c *coordinator) HandleRequests(w *Worker) chan struct{} {
 
 func (c *coordinator) RegisterRoom(id string) { c.Notify(api.RegisterRoom, id) }
 
+// StorageRoom lets the room use its files in the coordinator storage.
+func (c *coordinator) StorageRoom(id string) { c.Notify(api.StorageRoom, id) }
+
 // CloseRoom sends a signal to coordinator which will remove that room from its list.
 func (c *coordinator) CloseRoom(id string) { c.Notify(api.CloseRoom, id) }
+
+// HostChanged tells the coordinator that the user is the new host of the room.
+func (c *coordinator) HostChanged(id string, rid string) {
+	c.Notify(api.HostChanged, api.HostChangedRequest{Id: id, Rid: rid})
+}
+
+// Kicked tells the coordinator that the user has been kicked out of the room.
+func (c *coordinator) Kicked(id string, rid string) {
+	c.Notify(api.Kicked, api.KickedRequest{Id: id, Rid: rid})
+}
+
+// WebrtcOffer sends a new SDP offer of the worker to the user, i.e. for ICE restart.
+func (c *coordinator) WebrtcOffer(sdp string, sessionId string) {
+	c.Notify(api.WebrtcSignal, api.WebrtcSignalRequest{
+		Stateful: api.Stateful{Id: sessionId},
+		Sdp:      &sdp,
+	})
+}
+
 func (c *coordinator) IceCandidate(candidate string, sessionId string) {
 	c.Notify(api.WebrtcSignal, api.WebrtcSignalRequest{
 		Stateful: api.Stateful{Id: sessionId},
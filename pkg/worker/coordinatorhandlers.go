@@ -1,7 +1,9 @@
 package worker
 
 import (
+	"encoding/base64"
 	"encoding/json"
+	"time"
 
 	"github.com/giongto35/cloud-game/v3/pkg/api"
 	"github.com/giongto35/cloud-game/v3/pkg/com"
@@ -13,6 +15,11 @@ import (
 	"github.com/giongto35/cloud-game/v3/pkg/worker/room"
 )
 
+const (
+	maxFilterScale = 8
+	maxFilterSize  = 4096
+)
+
 // buildConnQuery builds initial connection data query to a coordinator.
 func buildConnQuery(id com.Uid, conf config.Worker, address string) (string, error) {
 	addr := conf.GetPingAddr(address)
@@ -22,6 +29,7 @@ func buildConnQuery(id com.Uid, conf config.Worker, address string) (string, err
 		IsHTTPS: conf.Server.Https,
 		PingURL: addr.String(),
 		Port:    conf.GetPort(address),
+		Slots:   conf.Slots,
 		Tag:     conf.Tag,
 		Zone:    conf.Network.Zone,
 	})
@@ -37,8 +45,24 @@ func (c *coordinator) HandleInitWebrtcStream(rq api.InitWebrtcStreamRequest, w *
 
 	peer := webrtc.New(c.log, factory)
 
+	// the best video codec supported by both sides
+	vc := w.conf.Encoder.Video
+	prefs := vc.Codecs
+	if len(prefs) == 0 {
+		prefs = []string{vc.Codec}
+	}
+	var offer string
+	if rq.Initiator {
+		offer = rq.Sdp
+	}
+	codec := webrtc.PickVideoCodec(prefs, offer, rq.Codecs)
+	if codec == "" {
+		codec = vc.Codec
+	}
+	c.log.Debug().Str("peer", rq.Id).Msgf("video codec: %v", codec)
+
 	if err = peer.NewConnection(
-		w.conf.Encoder.Video.Codec,
+		codec,
 		"opus",
 		func(ice *string) { c.IceCandidate(*ice, rq.Id) },
 	); err != nil {
@@ -59,6 +83,21 @@ func (c *coordinator) HandleInitWebrtcStream(rq api.InitWebrtcStreamRequest, w *
 
 	user := room.NewGameSession(rq.Id, peer) // use user uid from the coordinator
 	c.log.Info().Msgf("Peer connection: %s", user.Id())
+	if old := w.router.FindUser(rq.Id); old != nil {
+		// the same user is back, its new peer takes the place of the old one
+		user.SetIndex(old.Index())
+		user.SetSpectator(old.Spectator())
+		user.FixedLayer = old.FixedLayer
+		if r := w.router.Reattach(rq.Id, user); r != nil {
+			c.log.Info().Msgf("Resumed session: %s, room: %v", user.Id(), r.Id())
+		} else if w.router.FindUser(rq.Id) == old {
+			// not detached, so the old session should leave its room
+			r := w.router.Replace(old, user)
+			c.reassignHost(r)
+			old.Disconnect()
+			c.log.Info().Msgf("Replaced session: %s", user.Id())
+		}
+	}
 	w.router.AddUser(user)
 
 	return api.Out{Payload: sdp}
@@ -71,6 +110,15 @@ func (c *coordinator) HandleWebrtcSignal(rq api.WebrtcSignalRequest, w *Worker)
 	}
 
 	if webrtc := room.WithWebRTC(user.Session); webrtc != nil {
+		if rq.Restart {
+			sdp, err := webrtc.RestartICE()
+			if err != nil {
+				c.log.Error().Err(err).Msgf("cannot restart ICE of [%v]", rq.Id)
+				return
+			}
+			c.WebrtcOffer(sdp, rq.Id)
+			return
+		}
 		if err := webrtc.HandleSignal(rq.Ice, rq.Sdp); err != nil {
 			c.log.Error().Err(err).Msgf("cannot handle the signal from [%v]", rq.Id)
 		}
@@ -83,10 +131,26 @@ func (c *coordinator) HandleGameStart(rq api.StartGameRequest, w *Worker) api.Ou
 		c.log.Error().Msgf("no user [%v]", rq.Id)
 		return api.EmptyPacket
 	}
-	user.Index = rq.PlayerIndex
 
 	r := w.router.FindRoom(rq.Rid)
 
+	if rq.Spectator && r == nil {
+		c.log.Warn().Msgf("no room [%v] to watch", rq.Rid)
+		return api.EmptyPacket
+	}
+	if r != nil && r.IsKicked(rq.Id) {
+		c.log.Warn().Msgf("the user [%v] has been kicked out of the room [%v]", rq.Id, r.Id())
+		return api.Out{Payload: api.StartGameResponse{Full: true}}
+	}
+	if r != nil && r.IsLocked() && !r.Users().Contains(user) {
+		c.log.Warn().Msgf("the room [%v] is locked", r.Id())
+		return api.Out{Payload: api.StartGameResponse{Full: true}}
+	}
+	if r != nil && !hasPlace(r, user, rq.Spectator, w.conf.Worker) {
+		c.log.Warn().Msgf("the room [%v] is full, spectator: %v", r.Id(), rq.Spectator)
+		return api.Out{Payload: api.StartGameResponse{Full: true}}
+	}
+
 	// +injects game data into the original game request
 	// the name of the game either in the `room id` field or
 	// it's in the initial request
@@ -113,31 +177,62 @@ func (c *coordinator) HandleGameStart(rq api.StartGameRequest, w *Worker) api.Ou
 		}
 		game := games.GameMetadata(gameInfo)
 
-		r = room.NewRoom[*room.GameSession](uid, nil, w.router.Users(), nil)
+		r = room.NewRoom[*room.GameSession](uid, nil, room.NewGameSessions(), nil)
+		closed := make(chan struct{})
 		r.HandleClose = func() {
+			close(closed)
+			closeViewers(r)
 			c.CloseRoom(uid)
 			c.log.Debug().Msgf("room close request %v sent", uid)
 		}
 
-		if other := w.router.Room(); other != nil {
-			c.log.Error().Msgf("concurrent room creation: %v / %v", uid, w.router.Room().Id())
+		if !w.router.AddRoom(r) {
+			c.log.Error().Msgf("no free slots for the room: %v (%v/%v)", uid, w.router.RoomsLen(), w.router.Capacity())
 			return api.EmptyPacket
 		}
-
-		w.router.SetRoom(r)
 		c.log.Info().Str("room", r.Id()).Str("game", game.Name).Msg("New room")
 
 		// start the emulator
-		app := room.WithEmulator(w.mana.Get(caged.Libretro))
+		app := room.WithEmulator(w.mana.Spawn(caged.Libretro))
 		app.ReloadFrontend()
 		app.SetSessionId(uid)
 		app.SetSaveOnClose(true)
+		// the coordinator storage keeps only the files of the known rooms
+		c.StorageRoom(uid)
 		app.EnableCloudStorage(uid, w.storage)
 		app.EnableRecording(rq.Record, rq.RecordUser, gameName)
 
 		r.SetApp(app)
 
-		m := media.NewWebRtcMediaPipe(w.conf.Encoder.Audio, w.conf.Encoder.Video, w.log)
+		if sb := room.WithSandbox(app); sb != nil {
+			sb.SetCrashCb(func(restarting bool) {
+				data, err := api.Wrap(api.Out{
+					T:       uint8(api.AppCrash),
+					Payload: api.AppCrashInfo{Restarting: restarting},
+				})
+				if err != nil {
+					c.log.Error().Err(err).Msgf("wrap")
+				}
+				r.Send(data)
+				// the restarted app runs with the normal speed
+				if restarting {
+					setSpeed(r, 1)
+				}
+				if !restarting {
+					c.log.Warn().Msgf("the room %v has crashed", uid)
+					w.router.CloseRoom(uid)
+				}
+			})
+		}
+
+		// netplay frame numbers and such
+		app.SetDataCb(r.Send)
+
+		// the video filter of the core
+		vc := w.conf.Encoder.Video
+		vc.Filter = w.conf.Emulator.GetLibretroCoreConfig(game.System).Filter
+
+		m := media.NewWebRtcMediaPipe(w.conf.Encoder.Audio, vc, w.log)
 
 		// recreate the video encoder
 		app.VideoChangeCb(func() {
@@ -169,8 +264,7 @@ func (c *coordinator) HandleGameStart(rq api.StartGameRequest, w *Worker) api.Ou
 		w.log.Info().Msgf("Starting the game: %v", gameName)
 		if err := app.Load(game, w.conf.Library.BasePath); err != nil {
 			c.log.Error().Err(err).Msgf("couldn't load the game %v", game)
-			r.Close()
-			w.router.SetRoom(nil)
+			w.router.CloseRoom(r.Id())
 			return api.EmptyPacket
 		}
 
@@ -183,8 +277,7 @@ func (c *coordinator) HandleGameStart(rq api.StartGameRequest, w *Worker) api.Ou
 
 		if err := m.Init(); err != nil {
 			c.log.Error().Err(err).Msgf("couldn't init the media")
-			r.Close()
-			w.router.SetRoom(nil)
+			w.router.CloseRoom(r.Id())
 			return api.EmptyPacket
 		}
 
@@ -193,25 +286,76 @@ func (c *coordinator) HandleGameStart(rq api.StartGameRequest, w *Worker) api.Ou
 
 		r.BindAppMedia()
 		r.StartApp()
+
+		if w.conf.Encoder.Video.Adaptive.Enabled || m.Layers() > 1 || w.conf.Encoder.Audio.Opus.FEC {
+			go adaptMedia(r, m, closed)
+		}
+	}
+
+	// move the user into the room
+	if other := w.router.FindRoomOf(user); other != r {
+		w.router.Leave(user)
+		c.reassignHost(other)
+		w.router.Join(user, r)
 	}
 
 	c.log.Debug().Msg("Start session input poll")
 
-	needsKbMouse := r.App().KbMouseSupport()
+	user.SetIndex(rq.PlayerIndex)
+	user.SetSpectator(rq.Spectator)
+
+	// the first player of the room becomes its host
+	uid := user.Id().String()
+	if user.Spectator() {
+		if r.IsHost(uid) {
+			r.SetHost("")
+			c.reassignHost(r)
+		}
+	} else if r.Users().Find(r.Host()) == nil {
+		r.SetHost(uid)
+	}
+	needsKbMouse := r.App().KbMouseSupport() && !user.Spectator()
 
 	s := room.WithWebRTC(user.Session)
-	s.OnMessage(func(data []byte) { r.App().Input(user.Index, byte(caged.RetroPad), data) })
-	if needsKbMouse {
-		_, _ = s.Channel("keyboard", nil, func(data []byte) { r.App().Input(user.Index, byte(caged.Keyboard), data) })
-		_, _ = s.Channel("mouse", nil, func(data []byte) { r.App().Input(user.Index, byte(caged.Mouse), data) })
+	// the new user needs a keyframe to start the video,
+	// as well as the users with lost packets
+	s.OnKeyframeRequest(r.KeyFrame)
+	r.KeyFrame()
+	if user.Spectator() {
+		// spectators get only audio/video of the room
+		s.OnMessage(func([]byte) {})
+	} else {
+		// the user may become a spectator or be kicked later,
+		// so we check the role on each input
+		input := func(device byte) func([]byte) {
+			return func(data []byte) {
+				if !user.Spectator() && r.Users().Contains(user) {
+					r.App().Input(user.Index(), device, data)
+				}
+			}
+		}
+		s.OnMessage(input(byte(caged.RetroPad)))
+		if w.conf.Emulator.Netplay.Enabled {
+			// the input with the frame numbers
+			_, _ = s.Channel("netplay", nil, input(byte(caged.RetroPadSync)))
+		}
+		if needsKbMouse {
+			_, _ = s.Channel("keyboard", nil, input(byte(caged.Keyboard)))
+			_, _ = s.Channel("mouse", nil, input(byte(caged.Mouse)))
+		}
 	}
 
 	c.RegisterRoom(r.Id())
 
 	response := api.StartGameResponse{
-		Room:    api.Room{Rid: r.Id()},
-		Record:  w.conf.Recording.Enabled,
-		KbMouse: needsKbMouse,
+		Room:      api.Room{Rid: r.Id()},
+		Record:    w.conf.Recording.Enabled,
+		KbMouse:   needsKbMouse,
+		Spectator: user.Spectator(),
+		Host:      r.IsHost(uid),
+	}
+	if m, ok := r.Media().(*media.WebrtcMediaPipe); ok && m.Layers() > 1 {
+		response.Layers = m.Layers()
 	}
 	if r.App().AspectEnabled() {
 		ww, hh := r.App().ViewportSize()
@@ -228,26 +372,43 @@ func (c *coordinator) HandleGameStart(rq api.StartGameRequest, w *Worker) api.Ou
 }
 
 // HandleTerminateSession handles cases when a user has been disconnected from the websocket of coordinator.
+// The user of a room keeps its place there for a while, so it could resume the session.
 func (c *coordinator) HandleTerminateSession(rq api.TerminateSessionRequest, w *Worker) {
-	if user := w.router.FindUser(rq.Id); user != nil {
-		w.router.Remove(user)
+	user := w.router.FindUser(rq.Id)
+	if user == nil {
+		return
+	}
+	r := w.router.FindRoomOf(user)
+	if grace := w.conf.Webrtc.ResumeTimeout; grace > 0 && r != nil {
 		user.Disconnect()
+		w.router.Detach(rq.Id, time.Duration(grace)*time.Second, func() {
+			c.log.Debug().Msgf("session [%v] has expired", rq.Id)
+			w.router.Remove(user)
+			c.reassignHost(r)
+		})
+		return
 	}
+	w.router.Remove(user)
+	c.reassignHost(r)
+	user.Disconnect()
 }
 
 // HandleQuitGame handles cases when a user manually exits the game.
 func (c *coordinator) HandleQuitGame(rq api.GameQuitRequest, w *Worker) {
 	if user := w.router.FindUser(rq.Id); user != nil {
+		r := w.router.FindRoomOf(user)
 		w.router.Remove(user)
+		c.reassignHost(r)
 	}
 }
 
 func (c *coordinator) HandleResetGame(rq api.ResetGameRequest, w *Worker) api.Out {
-	if r := w.router.FindRoom(rq.Rid); r != nil {
-		room.WithEmulator(r.App()).Reset()
-		return api.OkPacket
+	r := w.router.FindRoom(rq.Rid)
+	if r == nil || !r.IsHost(rq.Id) {
+		return api.ErrPacket
 	}
-	return api.ErrPacket
+	room.WithEmulator(r.App()).Reset()
+	return api.OkPacket
 }
 
 func (c *coordinator) HandleSaveGame(rq api.SaveGameRequest, w *Worker) api.Out {
@@ -264,7 +425,7 @@ func (c *coordinator) HandleSaveGame(rq api.SaveGameRequest, w *Worker) api.Out
 
 func (c *coordinator) HandleLoadGame(rq api.LoadGameRequest, w *Worker) api.Out {
 	r := w.router.FindRoom(rq.Rid)
-	if r == nil {
+	if r == nil || !r.IsHost(rq.Id) {
 		return api.ErrPacket
 	}
 	if err := room.WithEmulator(r.App()).RestoreGameState(); err != nil {
@@ -274,12 +435,57 @@ func (c *coordinator) HandleLoadGame(rq api.LoadGameRequest, w *Worker) api.Out
 	return api.OkPacket
 }
 
+func (c *coordinator) HandleSaveSlot(rq api.SaveSlotRequest, w *Worker) api.Out {
+	r := w.router.FindRoom(rq.Rid)
+	if r == nil {
+		return api.ErrPacket
+	}
+	if err := room.WithEmulator(r.App()).SaveSlot(rq.Slot); err != nil {
+		c.log.Error().Err(err).Msgf("cannot save game state into the slot [%v]", rq.Slot)
+		return api.ErrPacket
+	}
+	return api.OkPacket
+}
+
+func (c *coordinator) HandleLoadSlot(rq api.LoadSlotRequest, w *Worker) api.Out {
+	r := w.router.FindRoom(rq.Rid)
+	if r == nil || !r.IsHost(rq.Id) {
+		return api.ErrPacket
+	}
+	if err := room.WithEmulator(r.App()).LoadSlot(rq.Slot); err != nil {
+		c.log.Error().Err(err).Msgf("cannot load game state from the slot [%v]", rq.Slot)
+		return api.ErrPacket
+	}
+	return api.OkPacket
+}
+
+// HandleListSlots returns the saves of the room with their thumbnails.
+func (c *coordinator) HandleListSlots(rq api.ListSlotsRequest, w *Worker) api.Out {
+	r := w.router.FindRoom(rq.Rid)
+	if r == nil {
+		return api.ErrPacket
+	}
+	list := api.ListSlotsResponse{}
+	for _, s := range room.WithEmulator(r.App()).Slots() {
+		slot := api.SlotInfo{Slot: s.Name, Auto: s.Auto, Time: s.Time.UnixMilli()}
+		if len(s.Thumb) > 0 {
+			slot.Thumb = "data:image/png;base64," + base64.StdEncoding.EncodeToString(s.Thumb)
+		}
+		list = append(list, slot)
+	}
+	return api.Out{Payload: list}
+}
+
 func (c *coordinator) HandleChangePlayer(rq api.ChangePlayerRequest, w *Worker) api.Out {
-	user := w.router.FindUser(rq.Id)
-	if user == nil || w.router.FindRoom(rq.Rid) == nil {
+	r := w.router.FindRoom(rq.Rid)
+	if r == nil {
 		return api.Out{Payload: -1} // semi-predicates
 	}
-	user.Index = rq.Index
+	user := r.Users().Find(rq.Id)
+	if user == nil || user.Spectator() {
+		return api.Out{Payload: -1}
+	}
+	user.SetIndex(rq.Index)
 	w.log.Info().Msgf("Updated player index to: %d", rq.Index)
 	return api.Out{Payload: rq.Index}
 }
@@ -292,10 +498,378 @@ func (c *coordinator) HandleRecordGame(rq api.RecordGameRequest, w *Worker) api.
 	if r == nil {
 		return api.ErrPacket
 	}
-	room.WithRecorder(r.App()).ToggleRecording(rq.Active, rq.User)
+	room.WithEmulator(r.App()).ToggleRecording(rq.Active, rq.User)
+	return api.OkPacket
+}
+
+// HandleVideoLayer sets the video layer of the user, -1 for the automatic selection.
+func (c *coordinator) HandleVideoLayer(rq api.VideoLayerRequest, w *Worker) api.Out {
+	r := w.router.FindRoom(rq.Rid)
+	if r == nil {
+		return api.ErrPacket
+	}
+	user := r.Users().Find(rq.Id)
+	if user == nil {
+		return api.ErrPacket
+	}
+	m, ok := r.Media().(*media.WebrtcMediaPipe)
+	if !ok || rq.Layer >= m.Layers() {
+		return api.ErrPacket
+	}
+	user.FixedLayer = rq.Layer >= 0
+	if user.FixedLayer {
+		room.WithWebRTC(user.Session).SetVideoLayer(rq.Layer)
+	}
+	return api.Out{Payload: rq.Layer}
+}
+
+// HandleRoomUsers returns the list of participants of the room.
+func (c *coordinator) HandleRoomUsers(rq api.RoomUsersRequest, w *Worker) api.Out {
+	r := w.router.FindRoom(rq.Rid)
+	if r == nil {
+		return api.ErrPacket
+	}
+	list := api.RoomUsersResponse{}
+	for u := range r.Users().Values() {
+		role := api.RolePlayer
+		if u.Spectator() {
+			role = api.RoleSpectator
+		}
+		list = append(list, api.RoomUser{Id: u.Id().String(), Index: u.Index(), Role: role})
+	}
+	return api.Out{Payload: list}
+}
+
+// HandleKickUser removes a user from the room by the request of the host.
+func (c *coordinator) HandleKickUser(rq api.KickUserRequest, w *Worker) api.Out {
+	r := w.router.FindRoom(rq.Rid)
+	if r == nil || !r.IsHost(rq.Id) || rq.Target == rq.Id {
+		return api.ErrPacket
+	}
+	user := r.Users().Find(rq.Target)
+	if user == nil {
+		return api.ErrPacket
+	}
+	r.Kick(rq.Target)
+	w.router.Leave(user)
+	c.log.Info().Msgf("user [%v] has been kicked out of the room [%v]", rq.Target, r.Id())
+	c.Kicked(rq.Target, r.Id())
+	return api.OkPacket
+}
+
+// HandleTransferHost passes the host role to another player of the room.
+func (c *coordinator) HandleTransferHost(rq api.TransferHostRequest, w *Worker) api.Out {
+	r := w.router.FindRoom(rq.Rid)
+	if r == nil || !r.IsHost(rq.Id) {
+		return api.ErrPacket
+	}
+	user := r.Users().Find(rq.Target)
+	if user == nil || user.Spectator() {
+		return api.ErrPacket
+	}
+	r.SetHost(rq.Target)
+	c.HostChanged(rq.Target, r.Id())
+	return api.OkPacket
+}
+
+// HandleLockRoom (un)locks the room for new users.
+func (c *coordinator) HandleLockRoom(rq api.LockRoomRequest, w *Worker) api.Out {
+	r := w.router.FindRoom(rq.Rid)
+	if r == nil || !r.IsHost(rq.Id) {
+		return api.ErrPacket
+	}
+	r.SetLocked(rq.Locked)
+	return api.OkPacket
+}
+
+// HandleVideoFilter changes the video filter of the room.
+func (c *coordinator) HandleVideoFilter(rq api.VideoFilterRequest, w *Worker) api.Out {
+	r := w.router.FindRoom(rq.Rid)
+	if r == nil || !r.IsHost(rq.Id) {
+		return api.ErrPacket
+	}
+	m, ok := r.Media().(*media.WebrtcMediaPipe)
+	f := rq.Filter
+	if !ok || f.Scale > maxFilterScale || f.Width > maxFilterSize || f.Height > maxFilterSize {
+		return api.ErrPacket
+	}
+	m.SetVideoFilter(config.VideoFilter(f))
+	return api.Out{Payload: f}
+}
+
+// HandleRoomAudio changes the audio processing (gain, mute, normalization) of the room.
+func (c *coordinator) HandleRoomAudio(rq api.RoomAudioRequest, w *Worker) api.Out {
+	r := w.router.FindRoom(rq.Rid)
+	if r == nil || !r.IsHost(rq.Id) {
+		return api.ErrPacket
+	}
+	m, ok := r.Media().(*media.WebrtcMediaPipe)
+	if !ok {
+		return api.ErrPacket
+	}
+	s := m.AudioSettings()
+	if rq.Gain != nil {
+		s.Gain = *rq.Gain
+	}
+	if rq.Mute != nil {
+		s.Mute = *rq.Mute
+	}
+	if rq.Normalize != nil {
+		s.Normalize = *rq.Normalize
+	}
+	m.SetAudioSettings(s)
+	s = m.AudioSettings()
+	return api.Out{Payload: api.RoomAudioResponse{Gain: s.Gain, Mute: s.Mute, Normalize: s.Normalize}}
+}
+
+// HandleCheats returns the cheats of the game in the room.
+func (c *coordinator) HandleCheats(rq api.CheatsRequest, w *Worker) api.Out {
+	r := w.router.FindRoom(rq.Rid)
+	if r == nil {
+		return api.ErrPacket
+	}
+	return api.Out{Payload: cheatList(room.WithEmulator(r.App()))}
+}
+
+// HandleSetCheat enables or disables the cheat of the game in the room.
+func (c *coordinator) HandleSetCheat(rq api.SetCheatRequest, w *Worker) api.Out {
+	r := w.router.FindRoom(rq.Rid)
+	if r == nil || !r.IsHost(rq.Id) {
+		return api.ErrPacket
+	}
+	emu := room.WithEmulator(r.App())
+	if err := emu.SetCheat(rq.Index, rq.Enabled); err != nil {
+		c.log.Error().Err(err).Msgf("cannot set the cheat [%v]", rq.Index)
+		return api.ErrPacket
+	}
+	return api.Out{Payload: api.SetCheatResponse(cheatList(emu))}
+}
+
+func cheatList(emu room.Emulator) api.CheatsResponse {
+	list := api.CheatsResponse{}
+	for i, ch := range emu.Cheats() {
+		list = append(list, api.CheatInfo{Index: i, Desc: ch.Desc, Code: ch.Code, Enabled: ch.Enabled})
+	}
+	return list
+}
+
+// HandleDisks returns the disks of the game in the room.
+func (c *coordinator) HandleDisks(rq api.DisksRequest, w *Worker) api.Out {
+	r := w.router.FindRoom(rq.Rid)
+	if r == nil {
+		return api.ErrPacket
+	}
+	return diskList(room.WithEmulator(r.App()))
+}
+
+// HandleEjectDisk opens or closes the disk tray of the game in the room.
+func (c *coordinator) HandleEjectDisk(rq api.EjectDiskRequest, w *Worker) api.Out {
+	r := w.router.FindRoom(rq.Rid)
+	if r == nil || !r.IsHost(rq.Id) {
+		return api.ErrPacket
+	}
+	emu := room.WithEmulator(r.App())
+	if err := emu.EjectDisk(rq.Ejected); err != nil {
+		c.log.Error().Err(err).Msgf("cannot eject the disk [%v]", rq.Ejected)
+		return api.ErrPacket
+	}
+	return diskList(emu)
+}
+
+// HandleSetDisk changes the disk of the game in the room.
+func (c *coordinator) HandleSetDisk(rq api.SetDiskRequest, w *Worker) api.Out {
+	r := w.router.FindRoom(rq.Rid)
+	if r == nil || !r.IsHost(rq.Id) {
+		return api.ErrPacket
+	}
+	emu := room.WithEmulator(r.App())
+	if err := emu.SetDisk(rq.Index); err != nil {
+		c.log.Error().Err(err).Msgf("cannot change the disk [%v]", rq.Index)
+		return api.ErrPacket
+	}
+	return diskList(emu)
+}
+
+func diskList(emu room.Emulator) api.Out {
+	d, err := emu.Disks()
+	if err != nil {
+		return api.ErrPacket
+	}
+	return api.Out{Payload: api.DisksResponse{Ejected: d.Ejected, Index: d.Index, Disks: d.Labels}}
+}
+
+// HandleSpeed pauses, slows down or speeds up the game in the room.
+// All users of the room are told about the new speed.
+func (c *coordinator) HandleSpeed(rq api.SpeedRequest, w *Worker) api.Out {
+	r := w.router.FindRoom(rq.Rid)
+	if r == nil || !r.IsHost(rq.Id) {
+		return api.ErrPacket
+	}
+	if err := room.WithEmulator(r.App()).SetSpeed(rq.Speed); err != nil {
+		c.log.Error().Err(err).Msgf("cannot change the speed [%v]", rq.Speed)
+		return api.ErrPacket
+	}
+	setSpeed(r, rq.Speed)
+	return api.Out{Payload: api.SpeedResponse(rq.Speed)}
+}
+
+// HandleFrameAdvance runs one frame of the paused game in the room.
+func (c *coordinator) HandleFrameAdvance(rq api.FrameAdvanceRequest, w *Worker) api.Out {
+	r := w.router.FindRoom(rq.Rid)
+	if r == nil || !r.IsHost(rq.Id) {
+		return api.ErrPacket
+	}
+	if err := room.WithEmulator(r.App()).FrameAdvance(); err != nil {
+		c.log.Error().Err(err).Msg("cannot advance the frame")
+		return api.ErrPacket
+	}
+	return api.OkPacket
+}
+
+// HandleRewind makes the game in the room go back in time.
+func (c *coordinator) HandleRewind(rq api.RewindRequest, w *Worker) api.Out {
+	r := w.router.FindRoom(rq.Rid)
+	if r == nil || !r.IsHost(rq.Id) {
+		return api.ErrPacket
+	}
+	if err := room.WithEmulator(r.App()).Rewind(rq.Seconds); err != nil {
+		c.log.Error().Err(err).Msgf("cannot rewind [%v]", rq.Seconds)
+		return api.ErrPacket
+	}
 	return api.OkPacket
 }
 
+// HandleCoreOptions returns the options of the core of the room.
+func (c *coordinator) HandleCoreOptions(rq api.CoreOptionsRequest, w *Worker) api.Out {
+	r := w.router.FindRoom(rq.Rid)
+	if r == nil {
+		return api.ErrPacket
+	}
+	return api.Out{Payload: coreOptionList(room.WithEmulator(r.App()))}
+}
+
+// HandleSetCoreOption changes the option of the core of the room,
+// the core applies it without a restart.
+func (c *coordinator) HandleSetCoreOption(rq api.SetCoreOptionRequest, w *Worker) api.Out {
+	r := w.router.FindRoom(rq.Rid)
+	if r == nil || !r.IsHost(rq.Id) {
+		return api.ErrPacket
+	}
+	emu := room.WithEmulator(r.App())
+	if err := emu.SetCoreOption(rq.Key, rq.Value); err != nil {
+		c.log.Error().Err(err).Msgf("cannot set the core option [%v=%v]", rq.Key, rq.Value)
+		return api.ErrPacket
+	}
+	return api.Out{Payload: api.SetCoreOptionResponse(coreOptionList(emu))}
+}
+
+func coreOptionList(emu room.Emulator) api.CoreOptionsResponse {
+	list := api.CoreOptionsResponse{}
+	for _, o := range emu.CoreOptions() {
+		list = append(list, api.CoreOptionInfo{
+			Key:     o.Key,
+			Desc:    o.Desc,
+			Info:    o.Info,
+			Values:  o.Values,
+			Default: o.Default,
+			Value:   o.Value,
+		})
+	}
+	return list
+}
+
+// setSpeed tells the users of the room about the new speed of the game.
+func setSpeed(r *room.Room[*room.GameSession], speed float64) {
+	data, err := api.Wrap(api.Out{T: uint8(api.AppSpeed), Payload: api.AppSpeedInfo{Speed: speed}})
+	if err != nil {
+		return
+	}
+	r.Send(data)
+}
+
+// HandleWhepToken returns the WHEP address and token of the room to its host.
+func (c *coordinator) HandleWhepToken(rq api.WhepTokenRequest, w *Worker) api.Out {
+	r := w.router.FindRoom(rq.Rid)
+	if w.whep == nil || r == nil || !r.IsHost(rq.Id) {
+		return api.ErrPacket
+	}
+	addr := w.conf.Worker.GetWhepAddr(w.address, r.Id())
+	return api.Out{Payload: api.WhepTokenResponse{Url: addr.String(), Token: w.whep.roomToken(r.Id())}}
+}
+
+// reassignHost passes the host role to some other player of the room
+// when the current host has left it.
+func (c *coordinator) reassignHost(r *room.Room[*room.GameSession]) {
+	if r == nil || r.Users().Find(r.Host()) != nil {
+		return
+	}
+	host := ""
+	for u := range r.Users().Values() {
+		if !u.Spectator() {
+			host = u.Id().String()
+			break
+		}
+	}
+	r.SetHost(host)
+	if host != "" {
+		c.HostChanged(host, r.Id())
+	}
+}
+
+// adaptMedia periodically adapts the media of the room to the network of its users
+// until the room is closed: picks the video layers of the users,
+// adapts the main layer and the audio FEC to the worst network among its users.
+func adaptMedia(r *room.Room[*room.GameSession], m *media.WebrtcMediaPipe, closed <-chan struct{}) {
+	t := time.NewTicker(media.AdaptInterval)
+	defer t.Stop()
+	layered := m.Layers() > 1
+	for {
+		select {
+		case <-closed:
+			return
+		case <-t.C:
+		}
+		bandwidth, loss, audioLoss := 0, 0.0, 0.0
+		for u := range r.Users().Values() {
+			peer := room.WithWebRTC(u.Session)
+			fb := peer.Feedback()
+			audioLoss = max(audioLoss, fb.AudioLoss)
+			cur, _ := peer.VideoLayer()
+			if layered && !u.FixedLayer {
+				peer.SetVideoLayer(m.PickLayer(cur, fb.Bitrate, fb.Loss))
+			}
+			if cur > 0 {
+				continue
+			}
+			if fb.Bitrate > 0 && (bandwidth == 0 || fb.Bitrate < bandwidth) {
+				bandwidth = fb.Bitrate
+			}
+			loss = max(loss, fb.Loss)
+		}
+		m.Adapt(bandwidth, loss)
+		m.AdaptAudio(audioLoss)
+	}
+}
+
+// hasPlace checks if the room can take the user with the role
+// according to the player and spectator limits.
+func hasPlace(r *room.Room[*room.GameSession], user *room.GameSession, spectator bool, conf config.Worker) bool {
+	limit := conf.Room.MaxPlayers
+	if spectator {
+		limit = conf.Room.MaxSpectators
+	}
+	if limit <= 0 {
+		return true
+	}
+	n := 0
+	for u := range r.Users().Values() {
+		if u.Id() != user.Id() && u.Spectator() == spectator {
+			n++
+		}
+	}
+	return n < limit
+}
+
 func toJson(data any) (string, error) {
 	if data == nil {
 		return "", nil
@@ -0,0 +1,80 @@
+package media
+
+import "time"
+
+// AdaptInterval is how often the video adapts to the network of the users.
+const AdaptInterval = time.Second
+
+const (
+	abrHeadroom  = 0.85 // of the estimated bandwidth, leaves some for audio and RTP
+	abrIncrease  = 1.08 // max bitrate increase per step
+	abrHighLoss  = 0.1
+	abrLowLoss   = 0.02
+	abrLevelHold = 5 // min steps between the level changes
+)
+
+// degradeLevels are the quality levels of the video
+// used when the bandwidth is lower than the min bitrate.
+var degradeLevels = []struct {
+	skip  int     // skip every n of n+1 frames
+	scale float64 // of the video size
+}{
+	{skip: 0, scale: 1},
+	{skip: 1, scale: 1},
+	{skip: 1, scale: 0.5},
+}
+
+// abr is an adaptive bitrate controller.
+// It picks the bitrate (Kbit/s) of the video encoder by the estimated
+// bandwidth and packet loss of the network, and degrades the quality
+// level of the video when the min bitrate is not enough.
+type abr struct {
+	lo, hi  int // the bitrate range
+	bitrate int
+	degrade bool
+	level   int
+	hold    int
+}
+
+func newAbr(lo, hi, start int, degrade bool) *abr {
+	hi = max(lo, hi)
+	if start <= 0 {
+		start = hi
+	}
+	return &abr{lo: lo, hi: hi, bitrate: clamp(start, lo, hi), degrade: degrade}
+}
+
+// next returns the bitrate and quality level for the estimated
+// bandwidth (bit/s, 0 if unknown) and the fraction of lost packets.
+func (a *abr) next(bandwidth int, loss float64) (bitrate int, level int) {
+	want := a.bitrate
+	if bandwidth > 0 {
+		// drop at once, but rise slowly
+		est := int(float64(bandwidth) * abrHeadroom / 1000)
+		want = min(est, int(float64(a.bitrate)*abrIncrease))
+	} else if loss < abrLowLoss {
+		want = int(float64(a.bitrate) * abrIncrease)
+	}
+	if loss > abrHighLoss {
+		want = min(want, int(float64(a.bitrate)*(1-loss/2)))
+	}
+
+	if a.hold > 0 {
+		a.hold--
+	}
+	if a.degrade && a.hold == 0 {
+		switch {
+		case want < a.lo && a.level < len(degradeLevels)-1:
+			a.level++
+			a.hold = abrLevelHold
+		case want >= 2*a.lo && a.level > 0:
+			a.level--
+			a.hold = abrLevelHold
+		}
+	}
+
+	a.bitrate = clamp(want, a.lo, a.hi)
+	return a.bitrate, a.level
+}
+
+func clamp[T int | float64](x, lo, hi T) T { return min(max(x, lo), hi) }
@@ -0,0 +1,60 @@
+package media
+
+import "testing"
+
+func TestAbr(t *testing.T) {
+	tests := []struct {
+		name      string
+		start     int
+		bandwidth int
+		loss      float64
+		steps     int
+		bitrate   int
+		level     int
+	}{
+		{name: "hold on some loss", start: 1000, loss: 0.05, steps: 10, bitrate: 1000},
+		{name: "rise without loss", start: 1000, steps: 100, bitrate: 4000},
+		{name: "drop on high loss", start: 1000, loss: 0.2, steps: 1, bitrate: 900},
+		{name: "follow the bandwidth", start: 1000, bandwidth: 2_000_000, steps: 100, bitrate: 1700},
+		{name: "drop to the bandwidth", start: 4000, bandwidth: 1_000_000, steps: 1, bitrate: 850},
+		{name: "degrade", start: 1000, bandwidth: 100_000, steps: 20, bitrate: 300, level: 2},
+		{name: "no lower than min", start: 1000, loss: 0.5, steps: 20, bitrate: 300, level: 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a := newAbr(300, 4000, test.start, true)
+			var bitrate, level int
+			for range test.steps {
+				bitrate, level = a.next(test.bandwidth, test.loss)
+			}
+			if bitrate != test.bitrate || level != test.level {
+				t.Errorf("got %v/%v, want %v/%v", bitrate, level, test.bitrate, test.level)
+			}
+		})
+	}
+}
+
+func TestAbrRestore(t *testing.T) {
+	a := newAbr(300, 4000, 1000, true)
+	for range 20 {
+		a.next(100_000, 0)
+	}
+	if a.level == 0 {
+		t.Fatalf("should degrade")
+	}
+	for range 20 {
+		a.next(1_000_000, 0)
+	}
+	if a.level != 0 {
+		t.Errorf("should restore the quality, level: %v", a.level)
+	}
+
+	a = newAbr(300, 4000, 1000, false)
+	for range 20 {
+		a.next(100_000, 0)
+	}
+	if a.level != 0 {
+		t.Errorf("shouldn't degrade, level: %v", a.level)
+	}
+}
@@ -0,0 +1,170 @@
+package media
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+const (
+	// limiterCeiling is the max level of the limited audio (~ -0.5 dBFS).
+	limiterCeiling = 0.944 * math.MaxInt16
+	// limiterRelease is the time (s) of the limiter to release the gain.
+	limiterRelease = 0.1
+
+	// loudnessWindow is the time (s) of the loudness measurement (EBU R128 short-term).
+	loudnessWindow = 3.0
+	// loudnessGate is the loudness (LUFS) below which the audio is silence.
+	loudnessGate = -70.0
+	// normalizeRange is the max change (dB) of the loudness normalization.
+	normalizeRange = 20.0
+	// normalizeSpeed is the change rate (dB/s) of the normalization gain.
+	normalizeSpeed = 3.0
+
+	minGain, maxGain = -60.0, 24.0 // dB
+)
+
+// AudioSettings are the runtime settings of the audio processing of a room.
+type AudioSettings struct {
+	Gain      float64 // dB
+	Mute      bool
+	Normalize bool
+}
+
+// audioFx processes the audio of a room (48KHz stereo) before the encoder:
+// the gain, mute, loudness normalization and a peak limiter against clipping.
+type audioFx struct {
+	gain      atomic.Uint64 // float64 bits of the linear gain
+	gainDb    atomic.Uint64 // float64 bits
+	mute      atomic.Bool
+	normalize atomic.Bool
+	target    float64 // LUFS
+
+	meter loudnessMeter
+	norm  float64 // the current normalization gain (dB)
+	lim   float64 // the current limiter gain
+}
+
+func newAudioFx(gain float64, normalize bool, target float64) *audioFx {
+	fx := audioFx{target: target, lim: 1}
+	fx.setGain(gain)
+	fx.normalize.Store(normalize)
+	return &fx
+}
+
+func (fx *audioFx) setGain(db float64) {
+	db = clamp(db, minGain, maxGain)
+	fx.gainDb.Store(math.Float64bits(db))
+	fx.gain.Store(math.Float64bits(math.Pow(10, db/20)))
+}
+
+func (fx *audioFx) set(s AudioSettings) {
+	fx.setGain(s.Gain)
+	fx.mute.Store(s.Mute)
+	fx.normalize.Store(s.Normalize)
+}
+
+func (fx *audioFx) settings() AudioSettings {
+	return AudioSettings{
+		Gain:      math.Float64frombits(fx.gainDb.Load()),
+		Mute:      fx.mute.Load(),
+		Normalize: fx.normalize.Load(),
+	}
+}
+
+// process changes the interleaved stereo samples in place.
+func (fx *audioFx) process(pcm samples) {
+	if fx.mute.Load() {
+		clear(pcm)
+		return
+	}
+	g := math.Float64frombits(fx.gain.Load())
+	if fx.normalize.Load() {
+		if l, ok := fx.meter.measure(pcm); ok {
+			want := clamp(fx.target-l, -normalizeRange, normalizeRange)
+			step := normalizeSpeed * float64(len(pcm)/2) / audioHz
+			fx.norm += clamp(want-fx.norm, -step, step)
+		}
+		g *= math.Pow(10, fx.norm/20)
+	}
+	if g == 1 && fx.lim == 1 {
+		return
+	}
+	release := 1 - math.Exp(-1/(limiterRelease*audioHz))
+	for i := 0; i+1 < len(pcm); i += 2 {
+		l, r := float64(pcm[i])*g, float64(pcm[i+1])*g
+		fx.lim += (1 - fx.lim) * release
+		if peak := max(math.Abs(l), math.Abs(r)); peak*fx.lim > limiterCeiling {
+			fx.lim = limiterCeiling / peak
+		}
+		pcm[i], pcm[i+1] = int16(l*fx.lim), int16(r*fx.lim)
+	}
+}
+
+// biquad is a second order IIR filter.
+type biquad struct {
+	b0, b1, b2, a1, a2 float64
+	x1, x2, y1, y2     float64
+}
+
+func (f *biquad) next(x float64) float64 {
+	y := f.b0*x + f.b1*f.x1 + f.b2*f.x2 - f.a1*f.y1 - f.a2*f.y2
+	f.x2, f.x1 = f.x1, x
+	f.y2, f.y1 = f.y1, y
+	return y
+}
+
+// kWeighting returns the K-weighting filter (48KHz) of the loudness measurement.
+// See: ITU-R BS.1770-4
+func kWeighting() [2]biquad {
+	return [2]biquad{
+		// the high shelf of the head
+		{b0: 1.53512485958697, b1: -2.69169618940638, b2: 1.19839281085285, a1: -1.69065929318241, a2: 0.73248077421585},
+		// the high-pass (RLB)
+		{b0: 1, b1: -2, b2: 1, a1: -1.99004745483398, a2: 0.99007225036621},
+	}
+}
+
+// loudnessMeter measures the short-term loudness (LUFS) of the stereo audio
+// with the exponential average of the K-weighted power.
+type loudnessMeter struct {
+	ch    [2][2]biquad
+	power float64
+	init  bool
+}
+
+// measure returns the loudness after the samples or false for the silence.
+func (m *loudnessMeter) measure(pcm samples) (float64, bool) {
+	if !m.init {
+		m.ch = [2][2]biquad{kWeighting(), kWeighting()}
+		m.init = true
+	}
+	n := len(pcm) / 2
+	if n == 0 {
+		return 0, false
+	}
+	sum := 0.0
+	for i := 0; i < n; i++ {
+		for c := range 2 {
+			x := float64(pcm[2*i+c]) / math.MaxInt16
+			x = m.ch[c][1].next(m.ch[c][0].next(x))
+			sum += x * x
+		}
+	}
+	power := sum / float64(n)
+	if lufs(power) < loudnessGate {
+		return 0, false
+	}
+	a := 1 - math.Exp(-float64(n)/(loudnessWindow*audioHz))
+	if m.power == 0 {
+		a = 1
+	}
+	m.power += (power - m.power) * a
+	return lufs(m.power), true
+}
+
+func lufs(power float64) float64 {
+	if power <= 0 {
+		return math.Inf(-1)
+	}
+	return -0.691 + 10*math.Log10(power)
+}
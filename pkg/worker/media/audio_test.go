@@ -0,0 +1,86 @@
+package media
+
+import (
+	"math"
+	"testing"
+)
+
+// sine returns 10ms of the stereo 1KHz sine with the level (dBFS).
+func sine(db float64, from int) samples {
+	n := audioHz / 100
+	amp := math.Pow(10, db/20) * math.MaxInt16
+	s := make(samples, 2*n)
+	for i := range n {
+		v := int16(amp * math.Sin(2*math.Pi*1000*float64(from+i)/audioHz))
+		s[2*i], s[2*i+1] = v, v
+	}
+	return s
+}
+
+func peak(s samples) (p int) {
+	for _, x := range s {
+		p = max(p, int(math.Abs(float64(x))))
+	}
+	return
+}
+
+func TestLoudnessMeter(t *testing.T) {
+	var m loudnessMeter
+	var l float64
+	for i := range 300 {
+		l, _ = m.measure(sine(-20, i*audioHz/100))
+	}
+	if math.Abs(l+20) > 0.5 {
+		t.Errorf("wrong loudness: %.2f LUFS, want ~-20", l)
+	}
+	var silent loudnessMeter
+	if _, ok := silent.measure(make(samples, 960)); ok {
+		t.Errorf("silence should be gated")
+	}
+}
+
+func TestAudioFx(t *testing.T) {
+	fx := newAudioFx(0, false, -16)
+	s := sine(-6, 0)
+	want := peak(s)
+	fx.process(s)
+	if peak(s) != want {
+		t.Errorf("should not change the audio without the gain")
+	}
+
+	fx.set(AudioSettings{Gain: 12})
+	for i := range 10 {
+		s = sine(-6, i*audioHz/100)
+		fx.process(s)
+		if p := peak(s); float64(p) > limiterCeiling {
+			t.Fatalf("should limit the audio, peak: %v", p)
+		}
+	}
+
+	fx.set(AudioSettings{Gain: 100, Mute: true})
+	if g := fx.settings().Gain; g != maxGain {
+		t.Errorf("wrong gain: %v", g)
+	}
+	s = sine(-6, 0)
+	fx.process(s)
+	if peak(s) != 0 {
+		t.Errorf("should mute the audio")
+	}
+}
+
+func TestAudioNormalize(t *testing.T) {
+	fx := newAudioFx(0, true, -16)
+	var out samples
+	for i := range 1000 { // 10s
+		out = sine(-30, i*audioHz/100)
+		fx.process(out)
+	}
+	var m loudnessMeter
+	var l float64
+	for range 300 {
+		l, _ = m.measure(out)
+	}
+	if math.Abs(l+16) > 1 {
+		t.Errorf("wrong normalized loudness: %.2f LUFS, want ~-16", l)
+	}
+}
@@ -0,0 +1,79 @@
+package media
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/giongto35/cloud-game/v3/pkg/encoder"
+)
+
+// videoCodecs are the codecs of the video streams of a room,
+// each user gets the stream of the codec negotiated with its browser.
+var videoCodecs = []encoder.VideoCodec{encoder.H264, encoder.VP8, encoder.VP9, encoder.AV1}
+
+// codecIndex returns the index of the codec in videoCodecs or -1.
+func codecIndex(codec string) int {
+	c := encoder.VideoCodec(strings.ToLower(codec))
+	if c == encoder.VPX {
+		c = encoder.VP8
+	}
+	return slices.Index(videoCodecs, c)
+}
+
+// Codecs returns the number of the video codecs,
+// the video streams are the layers of each codec.
+func (wmp *WebrtcMediaPipe) Codecs() int { return len(videoCodecs) }
+
+// VideoCodec returns the index of the codec of the video streams,
+// the main codec is used for the unknown ones.
+func (wmp *WebrtcMediaPipe) VideoCodec(codec string) int {
+	if i := codecIndex(codec); i >= 0 {
+		return i
+	}
+	return wmp.main
+}
+
+// newStream creates the encoder of the video stream i (the layer i%Layers
+// of the codec i/Layers) with the current settings of the video.
+func (wmp *WebrtcMediaPipe) newStream(i int) (*encoder.Video, error) {
+	n := wmp.Layers()
+	c, ls := i/n, wmp.layerScales()[i%n]
+	conf := wmp.vConf
+	if c != wmp.main {
+		conf.Codec = string(videoCodecs[c])
+	}
+	scale := wmp.VideoScale
+	if wmp.scale > 0 {
+		scale *= wmp.scale
+	}
+	enc, err := wmp.newVideo(wmp.VideoW, wmp.VideoH, scale*ls, layerConf(conf, ls))
+	if err != nil {
+		return nil, err
+	}
+	if wmp.abr != nil {
+		enc.SetBitrate(layerBitrate(int(wmp.bitrate.Load()), ls))
+	}
+	return enc, nil
+}
+
+// addStream creates the encoder of the video stream
+// when some user needs it for the first time.
+func (wmp *WebrtcMediaPipe) addStream(i int) *encoder.Video {
+	if wmp.broken[i] {
+		return nil
+	}
+	enc, err := wmp.newStream(i)
+	if err != nil {
+		wmp.broken[i] = true
+		wmp.log.Error().Err(err).Msgf("no %v video encoder", videoCodecs[i/wmp.Layers()])
+		return nil
+	}
+	enc.SetPixFormat(wmp.oldPf)
+	enc.SetRot(wmp.oldRot)
+	wmp.log.Debug().Msgf("%v", enc.Info())
+
+	wmp.muv.Lock()
+	wmp.lv[i] = enc
+	wmp.muv.Unlock()
+	return enc
+}
@@ -0,0 +1,149 @@
+package media
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/giongto35/cloud-game/v3/pkg/config"
+	"github.com/giongto35/cloud-game/v3/pkg/encoder"
+	"github.com/giongto35/cloud-game/v3/pkg/worker/caged/app"
+)
+
+// layerUpMargin makes it harder to switch to a better layer
+// than to keep the current one.
+const layerUpMargin = 0.8
+
+// keyframe is a rate limited keyframe request of a video layer.
+type keyframe struct {
+	req  atomic.Bool
+	last time.Time
+}
+
+// Layers returns the number of the video layers,
+// the layer 0 is the main one with the best quality.
+func (wmp *WebrtcMediaPipe) Layers() int { return 1 + len(wmp.vConf.Layers) }
+
+func (wmp *WebrtcMediaPipe) layerScales() []float64 { return append([]float64{1}, wmp.vConf.Layers...) }
+
+// ProcessVideoLayers returns the encoded frames of the used video streams
+// and whether the frames are the requested keyframes.
+// The streams of the codec c are [c*Layers, (c+1)*Layers).
+func (wmp *WebrtcMediaPipe) ProcessVideoLayers(v app.Video, used []bool) ([][]byte, []bool) {
+	n := len(wmp.kf)
+	if len(wmp.frames) != n {
+		wmp.frames, wmp.keys = make([][]byte, n), make([]bool, n)
+	}
+	clear(wmp.frames)
+	clear(wmp.keys)
+
+	wmp.applyFilter()
+
+	if wmp.abr != nil && !wmp.adaptFrame() {
+		return wmp.frames, wmp.keys
+	}
+	for i, enc := range wmp.VideoLayers() {
+		if i >= len(used) || !used[i] {
+			continue
+		}
+		if enc == nil {
+			if enc = wmp.addStream(i); enc == nil {
+				continue
+			}
+		}
+		wmp.frames[i], wmp.keys[i] = wmp.encode(i, enc, v)
+	}
+	return wmp.frames, wmp.keys
+}
+
+// encode encodes the frame with the encoder of the layer,
+// it reports only the keyframes made by the requests.
+func (wmp *WebrtcMediaPipe) encode(layer int, enc *encoder.Video, v app.Video) ([]byte, bool) {
+	key := false
+	if kf := &wmp.kf[layer]; kf.req.Load() && time.Since(kf.last) >= keyframeMinInterval {
+		kf.req.Store(false)
+		kf.last = time.Now()
+		enc.KeyFrame()
+		key = true
+	}
+	data := enc.Encode(encoder.InFrame(v.Frame))
+	return data, key && len(data) > 0
+}
+
+// KeyFrameLayer requests a keyframe of the video stream.
+func (wmp *WebrtcMediaPipe) KeyFrameLayer(layer int) {
+	if layer >= 0 && layer < len(wmp.kf) {
+		wmp.kf[layer].req.Store(true)
+	}
+}
+
+// PickLayer returns the best video layer for the network with the estimated
+// bandwidth (bit/s, 0 if unknown) and the fraction of lost packets.
+// Without the bandwidth estimation, the layer is changed only on high loss.
+func (wmp *WebrtcMediaPipe) PickLayer(cur int, bandwidth int, loss float64) int {
+	base := int(wmp.bitrate.Load())
+	if base == 0 {
+		base = startBitrate(wmp.vConf)
+	}
+	var bitrates []int
+	for _, s := range wmp.layerScales() {
+		bitrates = append(bitrates, layerBitrate(base, s))
+	}
+	return pickLayer(cur, bitrates, bandwidth, loss)
+}
+
+func pickLayer(cur int, bitrates []int, bandwidth int, loss float64) int {
+	last := len(bitrates) - 1
+	cur = clamp(cur, 0, last)
+	if loss > abrHighLoss {
+		return min(cur+1, last)
+	}
+	if bandwidth <= 0 || bitrates[0] <= 0 {
+		return cur
+	}
+	est := float64(bandwidth) * abrHeadroom / 1000
+	for i, b := range bitrates {
+		limit := est
+		if i < cur {
+			limit *= layerUpMargin
+		}
+		if float64(b) <= limit {
+			return i
+		}
+	}
+	return last
+}
+
+// startBitrate returns the configured bitrate (Kbit/s) of the video, 0 if unknown.
+func startBitrate(conf config.Video) int {
+	switch encoder.VideoCodec(conf.Codec) {
+	case encoder.H264:
+		return conf.H264.MaxRate
+	case encoder.AV1:
+		return int(conf.Av1.Bitrate)
+	}
+	return int(conf.Vpx.Bitrate)
+}
+
+// layerBitrate returns the bitrate for the layer with the scale of the video size.
+func layerBitrate(kbps int, scale float64) int {
+	if kbps <= 0 {
+		return 0
+	}
+	return max(int(float64(kbps)*scale*scale), 1)
+}
+
+// layerConf returns the encoder config for the layer with the scale of the video size.
+func layerConf(conf config.Video, scale float64) config.Video {
+	if scale == 1 {
+		return conf
+	}
+	conf.Vpx.Bitrate = uint(layerBitrate(int(conf.Vpx.Bitrate), scale))
+	conf.Av1.Bitrate = uint(layerBitrate(int(conf.Av1.Bitrate), scale))
+	conf.H264.MaxRate = layerBitrate(conf.H264.MaxRate, scale)
+	conf.H264.BufSize = layerBitrate(conf.H264.BufSize, scale)
+	if conf.Filter.Width > 0 && conf.Filter.Height > 0 {
+		conf.Filter.Width = round(conf.Filter.Width, scale)
+		conf.Filter.Height = round(conf.Filter.Height, scale)
+	}
+	return conf
+}
@@ -0,0 +1,40 @@
+package media
+
+import "testing"
+
+func TestPickLayer(t *testing.T) {
+	bitrates := []int{2000, 500}
+	tests := []struct {
+		name      string
+		cur       int
+		bandwidth int
+		loss      float64
+		want      int
+	}{
+		{name: "unknown bandwidth", cur: 1, want: 1},
+		{name: "high loss", cur: 0, loss: 0.2, want: 1},
+		{name: "high loss on the last", cur: 1, loss: 0.2, want: 1},
+		{name: "enough bandwidth", cur: 0, bandwidth: 3_000_000, want: 0},
+		{name: "low bandwidth", cur: 0, bandwidth: 1_000_000, want: 1},
+		{name: "too low bandwidth", cur: 0, bandwidth: 100_000, want: 1},
+		{name: "up with a margin", cur: 1, bandwidth: 3_000_000, want: 0},
+		{name: "not up without a margin", cur: 1, bandwidth: 2_500_000, want: 1},
+		{name: "bad current", cur: 5, want: 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := pickLayer(test.cur, bitrates, test.bandwidth, test.loss); got != test.want {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestLayerBitrate(t *testing.T) {
+	if b := layerBitrate(2000, 0.5); b != 500 {
+		t.Errorf("got %v, want 500", b)
+	}
+	if b := layerBitrate(0, 0.5); b != 0 {
+		t.Errorf("got %v, want 0", b)
+	}
+}
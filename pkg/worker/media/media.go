@@ -2,7 +2,9 @@ package media
 
 import (
 	"fmt"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/giongto35/cloud-game/v3/pkg/config"
@@ -14,30 +16,24 @@ import (
 
 const audioHz = 48000
 
-type samples []int16
+// keyframeMinInterval limits how often the keyframes can be requested,
+// the requests in between are merged into one.
+const keyframeMinInterval = 500 * time.Millisecond
 
-var (
-	encoderOnce = sync.Once{}
-	opusCoder   *opus.Encoder
-)
+// maxAudioLoss is the max expected packet loss percentage of the audio.
+const maxAudioLoss = 50
 
-func DefaultOpus() (*opus.Encoder, error) {
-	var err error
-	encoderOnce.Do(func() { opusCoder, err = opus.NewEncoder(audioHz) })
-	if err != nil {
-		return nil, err
-	}
-	if err = opusCoder.Reset(); err != nil {
-		return nil, err
-	}
-	return opusCoder, nil
-}
+type samples []int16
 
 type WebrtcMediaPipe struct {
 	a        *opus.Encoder
-	v        *encoder.Video
+	v        *encoder.Video   // the main layer of the main codec
+	lv       []*encoder.Video // all the video streams (codec x layer), nil if unused yet
+	broken   []bool           // the streams without the encoder
+	main     int              // the index of the main codec
 	onAudio  func([]byte, float32)
 	audioBuf *buffer
+	audioFx  *audioFx
 	log      *logger.Logger
 
 	mua sync.RWMutex
@@ -56,10 +52,43 @@ type WebrtcMediaPipe struct {
 	// keep the old settings for reinit
 	oldPf  uint32
 	oldRot uint
+
+	filter atomic.Pointer[config.VideoFilter] // a new video filter for the next frame
+
+	kf     []keyframe // of each stream
+	frames [][]byte
+	keys   []bool
+
+	// adaptive bitrate
+	abr     *abr
+	bitrate atomic.Int64
+	level   atomic.Int32
+	scale   float64 // of the current level
+	frame   int
+
+	// the expected packet loss percentage of the audio (FEC)
+	audioLoss    atomic.Int32
+	audioLossSet int32 // in the encoder
 }
 
 func NewWebRtcMediaPipe(ac config.Audio, vc config.Video, log *logger.Logger) *WebrtcMediaPipe {
-	return &WebrtcMediaPipe{log: log, aConf: ac, vConf: vc}
+	wmp := &WebrtcMediaPipe{log: log, aConf: ac, vConf: vc, scale: 1, main: codecIndex(vc.Codec)}
+	wmp.audioFx = newAudioFx(ac.Gain, ac.Normalize.Enabled, ac.Normalize.Target)
+	wmp.kf = make([]keyframe, wmp.Codecs()*wmp.Layers())
+	wmp.broken = make([]bool, len(wmp.kf))
+	if ad := vc.Adaptive; ad.Enabled {
+		// x264 changes the bitrate only with VBV,
+		// any of the codec streams can be h264
+		if vc.H264.MaxRate == 0 {
+			wmp.vConf.H264.MaxRate = ad.MaxBitrate
+		}
+		if vc.H264.BufSize == 0 {
+			wmp.vConf.H264.BufSize = wmp.vConf.H264.MaxRate
+		}
+		wmp.abr = newAbr(ad.MinBitrate, ad.MaxBitrate, startBitrate(wmp.vConf), ad.Degrade)
+		wmp.bitrate.Store(int64(wmp.abr.bitrate))
+	}
+	return wmp
 }
 
 func (wmp *WebrtcMediaPipe) SetAudioCb(cb func([]byte, int32)) {
@@ -68,20 +97,17 @@ func (wmp *WebrtcMediaPipe) SetAudioCb(cb func([]byte, int32)) {
 	}
 }
 func (wmp *WebrtcMediaPipe) Destroy() {
-	v := wmp.Video()
-	if v != nil {
+	for _, v := range wmp.VideoLayers() {
 		v.Stop()
 	}
 }
-func (wmp *WebrtcMediaPipe) PushAudio(audio []int16) {
-	wmp.audioBuf.write(audio, wmp.encodeAudio)
-}
+func (wmp *WebrtcMediaPipe) PushAudio(audio []int16) { wmp.audioBuf.write(audio, wmp.encodeAudio) }
 
 func (wmp *WebrtcMediaPipe) Init() error {
 	if err := wmp.initAudio(wmp.AudioSrcHz, wmp.AudioFrames); err != nil {
 		return err
 	}
-	if err := wmp.initVideo(wmp.VideoW, wmp.VideoH, wmp.VideoScale, wmp.vConf); err != nil {
+	if err := wmp.initVideo(); err != nil {
 		return err
 	}
 
@@ -98,12 +124,15 @@ func (wmp *WebrtcMediaPipe) Init() error {
 }
 
 func (wmp *WebrtcMediaPipe) initAudio(srcHz int, frameSizes []float32) error {
-	au, err := DefaultOpus()
+	opts := opus.Options(wmp.aConf.Opus)
+	au, err := opus.NewEncoder(audioHz, &opts)
 	if err != nil {
 		return fmt.Errorf("opus fail: %w", err)
 	}
 	wmp.log.Debug().Msgf("Opus: %v", au.GetInfo())
 	wmp.SetAudio(au)
+	wmp.audioLoss.Store(int32(opts.PacketLoss))
+	wmp.audioLossSet = int32(opts.PacketLoss)
 	buf, err := newBuffer(frameSizes, srcHz)
 	if err != nil {
 		return err
@@ -119,7 +148,15 @@ func (wmp *WebrtcMediaPipe) initAudio(srcHz int, frameSizes []float32) error {
 }
 
 func (wmp *WebrtcMediaPipe) encodeAudio(pcm samples, ms float32) {
-	data, err := wmp.Audio().Encode(pcm)
+	wmp.audioFx.process(pcm)
+	enc := wmp.Audio()
+	if loss := wmp.audioLoss.Load(); loss != wmp.audioLossSet {
+		wmp.audioLossSet = loss
+		if err := enc.SetPacketLossPerc(int(loss)); err != nil {
+			wmp.log.Error().Err(err).Msgf("opus loss fail")
+		}
+	}
+	data, err := enc.Encode(pcm)
 	if err != nil {
 		wmp.log.Error().Err(err).Msgf("opus encode fail")
 		return
@@ -127,24 +164,128 @@ func (wmp *WebrtcMediaPipe) encodeAudio(pcm samples, ms float32) {
 	wmp.onAudio(data, ms)
 }
 
-func (wmp *WebrtcMediaPipe) initVideo(w, h int, scale float64, conf config.Video) (err error) {
+// AudioSettings returns the current settings of the audio processing.
+func (wmp *WebrtcMediaPipe) AudioSettings() AudioSettings { return wmp.audioFx.settings() }
+
+// SetAudioSettings changes the audio processing on the fly.
+func (wmp *WebrtcMediaPipe) SetAudioSettings(s AudioSettings) { wmp.audioFx.set(s) }
+
+// initVideo creates the encoders of all the video layers of the main codec,
+// the streams of other codecs are created when needed.
+func (wmp *WebrtcMediaPipe) initVideo() error {
+	if wmp.main < 0 {
+		return fmt.Errorf("unsupported codec: %v", wmp.vConf.Codec)
+	}
+	n := wmp.Layers()
+	streams := make([]*encoder.Video, wmp.Codecs()*n)
+	for i := wmp.main * n; i < (wmp.main+1)*n; i++ {
+		enc, err := wmp.newStream(i)
+		if err != nil {
+			for _, s := range streams {
+				s.Stop()
+			}
+			return err
+		}
+		streams[i] = enc
+	}
+	wmp.muv.Lock()
+	wmp.v = streams[wmp.main*n]
+	wmp.lv = streams
+	wmp.muv.Unlock()
+	return nil
+}
+
+func (wmp *WebrtcMediaPipe) newVideo(w, h int, scale float64, conf config.Video) (*encoder.Video, error) {
 	sw, sh := round(w, scale), round(h, scale)
 	enc, err := encoder.NewVideoEncoder(w, h, sw, sh, scale, conf, wmp.log)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if enc == nil {
-		return fmt.Errorf("broken video encoder init")
+		return nil, fmt.Errorf("broken video encoder init")
 	}
-	wmp.SetVideo(enc)
 	wmp.log.Debug().Msgf("media scale: %vx%v -> %vx%v", w, h, sw, sh)
-	return err
+	return enc, nil
 }
 
 func round(x int, scale float64) int { return (int(float64(x)*scale) + 1) & ^1 }
 
 func (wmp *WebrtcMediaPipe) ProcessVideo(v app.Video) []byte {
-	return wmp.Video().Encode(encoder.InFrame(v.Frame))
+	wmp.applyFilter()
+	if wmp.abr != nil && !wmp.adaptFrame() {
+		return nil
+	}
+	data, _ := wmp.encode(wmp.main*wmp.Layers(), wmp.Video(), v)
+	return data
+}
+
+// SetVideoFilter changes the filter of the video,
+// the encoders are recreated before the next frame.
+func (wmp *WebrtcMediaPipe) SetVideoFilter(f config.VideoFilter) { wmp.filter.Store(&f) }
+
+// applyFilter applies the new video filter in the encoder thread.
+func (wmp *WebrtcMediaPipe) applyFilter() {
+	f := wmp.filter.Swap(nil)
+	if f == nil || *f == wmp.vConf.Filter {
+		return
+	}
+	wmp.vConf.Filter = *f
+	if err := wmp.Reinit(); err != nil {
+		wmp.log.Error().Err(err).Msgf("video filter fail")
+	}
+}
+
+// KeyFrame requests a keyframe of all the video streams, i.e. for new users
+// or after a packet loss (PLI/FIR), no more often than keyframeMinInterval.
+func (wmp *WebrtcMediaPipe) KeyFrame() {
+	for i := range wmp.kf {
+		wmp.KeyFrameLayer(i)
+	}
+}
+
+// Adapt changes the video quality for the network with the estimated
+// bandwidth (bit/s, 0 if unknown) and the fraction of lost packets.
+func (wmp *WebrtcMediaPipe) Adapt(bandwidth int, loss float64) {
+	if wmp.abr == nil {
+		return
+	}
+	bitrate, level := wmp.abr.next(bandwidth, loss)
+	if old := wmp.bitrate.Swap(int64(bitrate)); old != int64(bitrate) {
+		scales := wmp.layerScales()
+		for i, v := range wmp.VideoLayers() {
+			v.SetBitrate(layerBitrate(bitrate, scales[i%len(scales)]))
+		}
+	}
+	if old := wmp.level.Swap(int32(level)); old != int32(level) {
+		wmp.log.Debug().Msgf("video quality level: %v -> %v (%vKbit/s)", old, level, bitrate)
+	}
+}
+
+// AdaptAudio sets the expected packet loss of the audio encoder (FEC)
+// from the fraction of lost audio packets, the expected loss goes down slowly.
+func (wmp *WebrtcMediaPipe) AdaptAudio(loss float64) {
+	if !wmp.aConf.Opus.FEC {
+		return
+	}
+	perc := clamp(int(loss*100+0.5), wmp.aConf.Opus.PacketLoss, maxAudioLoss)
+	if old := int(wmp.audioLoss.Load()); perc < old {
+		perc = max(perc, old-1)
+	}
+	wmp.audioLoss.Store(int32(perc))
+}
+
+// adaptFrame applies the quality level of the video in the encoder thread,
+// it returns false if the frame should be skipped.
+func (wmp *WebrtcMediaPipe) adaptFrame() bool {
+	l := degradeLevels[wmp.level.Load()]
+	if l.scale != wmp.scale {
+		wmp.scale = l.scale
+		if err := wmp.Reinit(); err != nil {
+			wmp.log.Error().Err(err).Msgf("reinit fail")
+		}
+	}
+	wmp.frame++
+	return wmp.frame%(l.skip+1) == 0
 }
 
 func (wmp *WebrtcMediaPipe) Reinit() error {
@@ -152,8 +293,10 @@ func (wmp *WebrtcMediaPipe) Reinit() error {
 		return nil
 	}
 
-	wmp.Video().Stop()
-	if err := wmp.initVideo(wmp.VideoW, wmp.VideoH, wmp.VideoScale, wmp.vConf); err != nil {
+	for _, v := range wmp.VideoLayers() {
+		v.Stop()
+	}
+	if err := wmp.initVideo(); err != nil {
 		return err
 	}
 	// restore old
@@ -163,8 +306,20 @@ func (wmp *WebrtcMediaPipe) Reinit() error {
 }
 
 func (wmp *WebrtcMediaPipe) IsInitialized() bool { return wmp.initialized }
-func (wmp *WebrtcMediaPipe) SetPixFmt(f uint32)  { wmp.oldPf = f; wmp.v.SetPixFormat(f) }
-func (wmp *WebrtcMediaPipe) SetRot(r uint)       { wmp.oldRot = r; wmp.v.SetRot(r) }
+
+func (wmp *WebrtcMediaPipe) SetPixFmt(f uint32) {
+	wmp.oldPf = f
+	for _, v := range wmp.VideoLayers() {
+		v.SetPixFormat(f)
+	}
+}
+
+func (wmp *WebrtcMediaPipe) SetRot(r uint) {
+	wmp.oldRot = r
+	for _, v := range wmp.VideoLayers() {
+		v.SetRot(r)
+	}
+}
 
 func (wmp *WebrtcMediaPipe) Video() *encoder.Video {
 	wmp.muv.RLock()
@@ -172,6 +327,17 @@ func (wmp *WebrtcMediaPipe) Video() *encoder.Video {
 	return wmp.v
 }
 
+// VideoLayers returns the encoders of all the video streams (codec x layer),
+// the encoders of the unused streams are nil.
+func (wmp *WebrtcMediaPipe) VideoLayers() []*encoder.Video {
+	wmp.muv.RLock()
+	defer wmp.muv.RUnlock()
+	if wmp.v == nil {
+		return nil
+	}
+	return slices.Clone(wmp.lv)
+}
+
 func (wmp *WebrtcMediaPipe) SetVideo(e *encoder.Video) {
 	wmp.muv.Lock()
 	wmp.v = e
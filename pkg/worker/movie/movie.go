@@ -0,0 +1,243 @@
+// Package movie implements input movies of the games.
+//
+// A movie is the initial save state of a game followed
+// by all the input of its players stamped with the frame numbers,
+// so the game could be replayed (and re-rendered) deterministically
+// with the same game and emulator core.
+//
+// File format (gzip compressed), numbers are little-endian:
+//
+//	magic    "CGMV"
+//	version  uint16
+//	header   uvarint length + JSON Header
+//	state    uvarint length + state data
+//	events   until EOF:
+//	  frame  uvarint, the number of frames since the previous event
+//	  port   byte
+//	  device byte
+//	  data   uvarint length + input data
+package movie
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+const (
+	magic   = "CGMV"
+	Version = 1
+
+	// Ext is the file extension of the movies.
+	Ext = ".cgm"
+
+	// maxChunk limits the size of the file chunks (64 MiB).
+	maxChunk = 64 << 20
+)
+
+var (
+	ErrFormat  = errors.New("not a movie file")
+	ErrVersion = errors.New("unsupported movie version")
+)
+
+// Header contains the info needed to replay the movie.
+type Header struct {
+	Game    string  `json:"game"`
+	System  string  `json:"system"`
+	Fps     float64 `json:"fps"`
+	Created int64   `json:"created"`
+}
+
+// Event is the input of one port made before the frame.
+type Event struct {
+	Frame  uint64
+	Port   uint8
+	Device uint8
+	Data   []byte
+}
+
+type Writer struct {
+	w     *bufio.Writer
+	last  uint64
+	close []io.Closer
+}
+
+// NewWriter writes the movie header and initial state into w.
+func NewWriter(w io.Writer, h Header, state []byte) (*Writer, error) {
+	mw := &Writer{w: bufio.NewWriter(w)}
+
+	hdr, err := json.Marshal(h)
+	if err != nil {
+		return nil, err
+	}
+	var ver [2]byte
+	binary.LittleEndian.PutUint16(ver[:], Version)
+	if _, err := mw.w.WriteString(magic); err != nil {
+		return nil, err
+	}
+	if _, err := mw.w.Write(ver[:]); err != nil {
+		return nil, err
+	}
+	if err := mw.chunk(hdr); err != nil {
+		return nil, err
+	}
+	if err := mw.chunk(state); err != nil {
+		return nil, err
+	}
+	return mw, nil
+}
+
+// Create creates a new movie file.
+func Create(path string, h Header, state []byte) (*Writer, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	gz := gzip.NewWriter(f)
+	w, err := NewWriter(gz, h, state)
+	if err != nil {
+		_, _ = gz.Close(), f.Close()
+		return nil, err
+	}
+	w.close = []io.Closer{gz, f}
+	return w, nil
+}
+
+// Write adds the event to the movie, the events should be in the frame order.
+func (w *Writer) Write(e Event) error {
+	if e.Frame < w.last {
+		return fmt.Errorf("event of the frame %v after %v", e.Frame, w.last)
+	}
+	var buf [binary.MaxVarintLen64 + 2]byte
+	n := binary.PutUvarint(buf[:], e.Frame-w.last)
+	buf[n], buf[n+1] = e.Port, e.Device
+	if _, err := w.w.Write(buf[:n+2]); err != nil {
+		return err
+	}
+	w.last = e.Frame
+	return w.chunk(e.Data)
+}
+
+// Close flushes the movie and closes the underlying file, if any.
+func (w *Writer) Close() error {
+	err := w.w.Flush()
+	for _, c := range w.close {
+		err = errors.Join(err, c.Close())
+	}
+	return err
+}
+
+func (w *Writer) chunk(data []byte) error {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], uint64(len(data)))
+	if _, err := w.w.Write(buf[:n]); err != nil {
+		return err
+	}
+	_, err := w.w.Write(data)
+	return err
+}
+
+type Reader struct {
+	Header Header
+	State  []byte
+
+	r     *bufio.Reader
+	last  uint64
+	close []io.Closer
+}
+
+// NewReader reads the movie header and initial state from r.
+func NewReader(r io.Reader) (*Reader, error) {
+	mr := &Reader{r: bufio.NewReader(r)}
+
+	var pre [len(magic) + 2]byte
+	if _, err := io.ReadFull(mr.r, pre[:]); err != nil {
+		return nil, ErrFormat
+	}
+	if string(pre[:len(magic)]) != magic {
+		return nil, ErrFormat
+	}
+	if v := binary.LittleEndian.Uint16(pre[len(magic):]); v != Version {
+		return nil, fmt.Errorf("%w: %v", ErrVersion, v)
+	}
+	hdr, err := mr.chunk()
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(hdr, &mr.Header); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFormat, err)
+	}
+	if mr.State, err = mr.chunk(); err != nil {
+		return nil, err
+	}
+	return mr, nil
+}
+
+// Open opens a movie file.
+func Open(path string) (*Reader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		_ = f.Close()
+		return nil, ErrFormat
+	}
+	r, err := NewReader(gz)
+	if err != nil {
+		_, _ = gz.Close(), f.Close()
+		return nil, err
+	}
+	r.close = []io.Closer{gz, f}
+	return r, nil
+}
+
+// Next returns the next event of the movie or io.EOF at the end.
+func (r *Reader) Next() (Event, error) {
+	delta, err := binary.ReadUvarint(r.r)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return Event{}, io.EOF
+		}
+		return Event{}, err
+	}
+	var pd [2]byte
+	if _, err := io.ReadFull(r.r, pd[:]); err != nil {
+		return Event{}, io.ErrUnexpectedEOF
+	}
+	data, err := r.chunk()
+	if err != nil {
+		return Event{}, err
+	}
+	r.last += delta
+	return Event{Frame: r.last, Port: pd[0], Device: pd[1], Data: data}, nil
+}
+
+// Close closes the underlying file, if any.
+func (r *Reader) Close() (err error) {
+	for _, c := range r.close {
+		err = errors.Join(err, c.Close())
+	}
+	return
+}
+
+func (r *Reader) chunk() ([]byte, error) {
+	n, err := binary.ReadUvarint(r.r)
+	if err != nil {
+		return nil, io.ErrUnexpectedEOF
+	}
+	if n > maxChunk {
+		return nil, fmt.Errorf("%w: chunk size %v", ErrFormat, n)
+	}
+	data := make([]byte, n)
+	if _, err := io.ReadFull(r.r, data); err != nil {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return data, nil
+}
@@ -0,0 +1,110 @@
+package movie
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var (
+	header = Header{Game: "game.nes", System: "nes", Fps: 60, Created: 1}
+	state  = []byte{1, 2, 3, 4}
+	events = []Event{
+		{Frame: 0, Port: 0, Device: 0, Data: []byte{1, 0}},
+		{Frame: 0, Port: 1, Device: 0, Data: []byte{2, 0}},
+		{Frame: 300, Port: 0, Device: 1, Data: []byte{0, 0, 0, 13, 1, 0, 0}},
+		{Frame: 100_000, Port: 3, Device: 2, Data: []byte{}},
+	}
+)
+
+func TestMovie(t *testing.T) {
+	var buf bytes.Buffer
+
+	w, err := NewWriter(&buf, header, state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range events {
+		if err := w.Write(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(t, r)
+}
+
+func TestMovieFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test"+Ext)
+
+	w, err := Create(path, header, state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range events {
+		if err := w.Write(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = r.Close() }()
+	check(t, r)
+}
+
+func TestMovieOrder(t *testing.T) {
+	w, err := NewWriter(io.Discard, header, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = w.Write(Event{Frame: 10})
+	if err := w.Write(Event{Frame: 9}); err == nil {
+		t.Errorf("no error for the past event")
+	}
+}
+
+func TestMovieBadFile(t *testing.T) {
+	if _, err := NewReader(bytes.NewReader([]byte("CGMX\x01\x00"))); !errors.Is(err, ErrFormat) {
+		t.Errorf("wrong error: %v", err)
+	}
+	if _, err := NewReader(bytes.NewReader([]byte("CGMV\x09\x00"))); !errors.Is(err, ErrVersion) {
+		t.Errorf("wrong error: %v", err)
+	}
+}
+
+func check(t *testing.T, r *Reader) {
+	t.Helper()
+	if r.Header != header {
+		t.Errorf("wrong header: %+v", r.Header)
+	}
+	if !bytes.Equal(r.State, state) {
+		t.Errorf("wrong state: %v", r.State)
+	}
+	for _, want := range events {
+		e, err := r.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(e, want) {
+			t.Errorf("wrong event: %+v != %+v", e, want)
+		}
+	}
+	if _, err := r.Next(); !errors.Is(err, io.EOF) {
+		t.Errorf("expected EOF, got: %v", err)
+	}
+}
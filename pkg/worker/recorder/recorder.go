@@ -178,6 +178,13 @@ func (r *Recording) SetPixFormat(fmt uint32) {
 	r.opts.Pix = pix
 }
 
+// Dir returns the path of the current recording.
+func (r *Recording) Dir() string {
+	r.Lock()
+	defer r.Unlock()
+	return filepath.Join(r.dir, r.saveDir)
+}
+
 func (r *Recording) Enabled() bool {
 	r.Lock()
 	defer r.Unlock()
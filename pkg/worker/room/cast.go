@@ -1,22 +1,73 @@
 package room
 
 import (
+	"github.com/giongto35/cloud-game/v3/pkg/cloud"
 	"github.com/giongto35/cloud-game/v3/pkg/com"
+	"github.com/giongto35/cloud-game/v3/pkg/games"
 	"github.com/giongto35/cloud-game/v3/pkg/network/webrtc"
+	"github.com/giongto35/cloud-game/v3/pkg/worker/caged/app"
 	"github.com/giongto35/cloud-game/v3/pkg/worker/caged/libretro"
+	"github.com/giongto35/cloud-game/v3/pkg/worker/caged/libretro/sandbox"
 )
 
 type GameRouter struct {
 	Router[*GameSession]
 }
 
-func NewGameRouter() *GameRouter {
+// NewGameRouter returns a new router that allows up to
+// capacity number of concurrently running game rooms.
+func NewGameRouter(capacity int) *GameRouter {
+	return &GameRouter{Router: Router[*GameSession]{capacity: capacity, users: NewGameSessions()}}
+}
+
+// NewGameSessions returns a new empty list of game sessions.
+func NewGameSessions() SessionManager[*GameSession] {
 	u := com.NewNetMap[SessionKey, *GameSession]()
-	return &GameRouter{Router: Router[*GameSession]{users: &u}}
+	return &u
+}
+
+// Emulator is a set of emulator app controls used by the rooms,
+// both in-process and sandboxed emulators implement it.
+type Emulator interface {
+	app.App
+	Cheats() []games.Cheat
+	CoreOptions() []libretro.CoreOption
+	Disks() (libretro.Disks, error)
+	EjectDisk(ejected bool) error
+	EnableCloudStorage(uid string, storage cloud.Storage)
+	EnableRecording(nowait bool, user string, game string)
+	FrameAdvance() error
+	Load(game games.GameMetadata, path string) error
+	LoadSlot(slot string) error
+	PixFormat() uint32
+	ReloadFrontend()
+	Reset()
+	RestoreGameState() error
+	Rewind(seconds float64) error
+	Rotation() uint
+	SaveGameState() error
+	SaveSlot(slot string) error
+	SetCheat(index int, enabled bool) error
+	SetCoreOption(key, value string) error
+	SetDisk(index int) error
+	SetSaveOnClose(v bool)
+	SetSessionId(name string)
+	SetSpeed(speed float64) error
+	Slots() []libretro.SaveSlot
+	Speed() float64
+	ToggleRecording(active bool, user string)
+	VideoChangeCb(fn func())
+	ViewportRecalculate()
 }
 
-func WithEmulator(wtf any) *libretro.Caged { return wtf.(*libretro.Caged) }
+func WithEmulator(wtf any) Emulator { return wtf.(Emulator) }
 func WithRecorder(wtf any) *libretro.RecordingFrontend {
-	return (WithEmulator(wtf).Emulator).(*libretro.RecordingFrontend)
+	return (wtf.(*libretro.Caged).Emulator).(*libretro.RecordingFrontend)
+}
+
+// WithSandbox returns sandboxed emulator or nil.
+func WithSandbox(wtf any) *sandbox.Caged {
+	s, _ := wtf.(*sandbox.Caged)
+	return s
 }
 func WithWebRTC(wtf Session) *webrtc.Peer { return wtf.(*webrtc.Peer) }
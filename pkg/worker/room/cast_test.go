@@ -3,8 +3,10 @@ package room
 import (
 	"testing"
 
+	"github.com/giongto35/cloud-game/v3/pkg/logger"
 	"github.com/giongto35/cloud-game/v3/pkg/network/webrtc"
 	"github.com/giongto35/cloud-game/v3/pkg/worker/caged/libretro"
+	"github.com/giongto35/cloud-game/v3/pkg/worker/caged/libretro/sandbox"
 )
 
 func TestGoodWithRecorder(t *testing.T) {
@@ -25,6 +27,15 @@ func TestBadWithEmulator(t *testing.T) {
 	t.Errorf("no panic")
 }
 
+func TestWithSandbox(t *testing.T) {
+	if WithSandbox(&libretro.Caged{}) != nil {
+		t.Errorf("not a sandbox")
+	}
+	if WithSandbox(sandbox.New(sandbox.Conf{}, logger.Default())) == nil {
+		t.Errorf("should be a sandbox")
+	}
+}
+
 func TestGoodWithWebRTCCast(t *testing.T) {
 	WithWebRTC(GameSession{AppSession: AppSession{Session: &webrtc.Peer{}}}.Session)
 }
@@ -3,6 +3,8 @@ package room
 import (
 	"iter"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/giongto35/cloud-game/v3/pkg/worker/caged/app"
 )
@@ -20,12 +22,33 @@ type MediaPipe interface {
 	PushAudio([]int16)
 	// ProcessVideo returns encoded video frame.
 	ProcessVideo(app.Video) []byte
+	// KeyFrame requests a keyframe of the video.
+	KeyFrame()
 	// SetAudioCb sets a callback for encoded audio data with its frame duration (ns).
 	SetAudioCb(func(data []byte, duration int32))
 }
 
+// LayeredMediaPipe is a media pipe with several encodings (layers)
+// of the video, from the best quality to the worst, for each video codec.
+// The video streams of the codec c are the layers [c*Layers, (c+1)*Layers).
+type LayeredMediaPipe interface {
+	MediaPipe
+	// Codecs returns the number of the video codecs.
+	Codecs() int
+	// Layers returns the number of the video layers of a codec.
+	Layers() int
+	// VideoCodec returns the index of the video codec.
+	VideoCodec(codec string) int
+	// ProcessVideoLayers returns the encoded frames of the used video streams
+	// and whether the frames are the requested keyframes.
+	ProcessVideoLayers(v app.Video, used []bool) ([][]byte, []bool)
+	// KeyFrameLayer requests a keyframe of the video stream.
+	KeyFrameLayer(stream int)
+}
+
 type SessionManager[T Session] interface {
 	Add(T) bool
+	Contains(T) bool
 	Empty() bool
 	Find(string) T
 	RemoveL(T) int
@@ -39,6 +62,12 @@ type Session interface {
 	SendAudio([]byte, int32)
 	SendVideo([]byte, int32)
 	SendData([]byte)
+	// SendVideoLayers sends a frame of one of the video layers with their keyframe flags.
+	SendVideoLayers(frames [][]byte, keys []bool, duration int32)
+	// VideoLayer returns the current and the wanted video layers of the session.
+	VideoLayer() (cur, want int)
+	// VideoCodec returns the video codec of the session.
+	VideoCodec() string
 }
 
 type SessionKey string
@@ -52,6 +81,11 @@ type Room[T Session] struct {
 	media MediaPipe
 	users SessionManager[T]
 
+	host   string              // the id of the user who controls the room
+	locked bool                // locked rooms don't accept new users
+	kicked map[string]struct{} // the ids of the users who can't join the room
+	mu     sync.Mutex
+
 	closed      bool
 	HandleClose func()
 }
@@ -75,6 +109,10 @@ func (r *Room[T]) InitAudio() {
 }
 
 func (r *Room[T]) InitVideo() {
+	if lm, ok := r.media.(LayeredMediaPipe); ok {
+		r.initVideoLayers(lm)
+		return
+	}
 	r.app.SetVideoCb(func(v app.Video) {
 		data := r.media.ProcessVideo(v)
 		for u := range r.users.Values() {
@@ -83,12 +121,70 @@ func (r *Room[T]) InitVideo() {
 	})
 }
 
-func (r *Room[T]) App() app.App         { return r.app }
-func (r *Room[T]) BindAppMedia()        { r.InitAudio(); r.InitVideo() }
-func (r *Room[T]) Id() string           { return r.id }
-func (r *Room[T]) SetApp(app app.App)   { r.app = app }
-func (r *Room[T]) SetMedia(m MediaPipe) { r.media = m }
-func (r *Room[T]) StartApp()            { r.app.Start() }
+// initVideoLayers makes the room encode only the video layers of the codecs
+// of its users, the switching users get a keyframe of their new layer.
+func (r *Room[T]) initVideoLayers(lm LayeredMediaPipe) {
+	n := lm.Layers()
+	used := make([]bool, lm.Codecs()*n)
+	r.app.SetVideoCb(func(v app.Video) {
+		clear(used)
+		for u := range r.users.Values() {
+			c := lm.VideoCodec(u.VideoCodec()) * n
+			cur, want := u.VideoLayer()
+			cur, want = c+min(cur, n-1), c+min(want, n-1)
+			used[cur], used[want] = true, true
+			if cur != want {
+				lm.KeyFrameLayer(want)
+			}
+		}
+		frames, keys := lm.ProcessVideoLayers(v, used)
+		for u := range r.users.Values() {
+			c := lm.VideoCodec(u.VideoCodec()) * n
+			u.SendVideoLayers(frames[c:c+n], keys[c:c+n], v.Duration)
+		}
+	})
+}
+
+// KeyFrame requests a keyframe of the room video, i.e. for a new user.
+func (r *Room[T]) KeyFrame() {
+	if r.media != nil {
+		r.media.KeyFrame()
+	}
+}
+
+func (r *Room[T]) App() app.App             { return r.app }
+func (r *Room[T]) BindAppMedia()            { r.InitAudio(); r.InitVideo() }
+func (r *Room[T]) Id() string               { return r.id }
+func (r *Room[T]) SetApp(app app.App)       { r.app = app }
+func (r *Room[T]) Media() MediaPipe         { return r.media }
+func (r *Room[T]) SetMedia(m MediaPipe)     { r.media = m }
+func (r *Room[T]) StartApp()                { r.app.Start() }
+func (r *Room[T]) Users() SessionManager[T] { return r.users }
+
+func (r *Room[T]) Host() string          { r.mu.Lock(); defer r.mu.Unlock(); return r.host }
+func (r *Room[T]) IsHost(id string) bool { return id != "" && r.Host() == id }
+func (r *Room[T]) SetHost(id string)     { r.mu.Lock(); r.host = id; r.mu.Unlock() }
+func (r *Room[T]) IsLocked() bool        { r.mu.Lock(); defer r.mu.Unlock(); return r.locked }
+func (r *Room[T]) SetLocked(v bool)      { r.mu.Lock(); r.locked = v; r.mu.Unlock() }
+
+// Kick bans the user from the room, the user should leave it separately.
+func (r *Room[T]) Kick(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.kicked == nil {
+		r.kicked = make(map[string]struct{})
+	}
+	r.kicked[id] = struct{}{}
+}
+
+// IsKicked checks if the user has been kicked out of the room.
+func (r *Room[T]) IsKicked(id string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.kicked[id]
+	return ok
+}
+
 func (r *Room[T]) Send(data []byte) {
 	for u := range r.users.Values() {
 		u.SendData(data)
@@ -112,43 +208,172 @@ func (r *Room[T]) Close() {
 	}
 }
 
-// Router tracks and routes freshly connected users to an app room.
-// Rooms and users has 1-to-n relationship.
+// Router tracks and routes freshly connected users to app rooms.
+// Rooms and users has 1-to-n relationship and the number of
+// concurrently running rooms is limited by the router capacity.
 type Router[T Session] struct {
-	room  *Room[T]
-	users SessionManager[T]
-	mu    sync.Mutex
+	capacity int
+	rooms    map[string]*Room[T]
+	users    SessionManager[T]
+	detached map[string]*time.Timer // the disconnected users waiting for a resume
+	mu       sync.Mutex
+}
+
+// AddRoom adds a new room to the router if there is a free slot for it.
+func (r *Router[T]) AddRoom(room *Room[T]) bool {
+	if room == nil {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.rooms[room.Id()]; ok || !r.hasSlot() {
+		return false
+	}
+	if r.rooms == nil {
+		r.rooms = make(map[string]*Room[T])
+	}
+	r.rooms[room.Id()] = room
+	return true
 }
 
 func (r *Router[T]) FindRoom(id string) *Room[T] {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	if r.room != nil && r.room.Id() == id {
-		return r.room
+	return r.rooms[id]
+}
+
+// FindRoomOf returns the room where the user plays or nil.
+func (r *Router[T]) FindRoomOf(user T) *Room[T] {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, room := range r.rooms {
+		if room.users != nil && room.users.Contains(user) {
+			return room
+		}
 	}
 	return nil
 }
 
+// Join links the user with the room.
+func (r *Router[T]) Join(user T, room *Room[T]) {
+	if room != nil && room.users != nil {
+		room.users.Add(user)
+	}
+}
+
+// Leave unlinks the user from its room and closes
+// the room if it was the last user there.
+func (r *Router[T]) Leave(user T) {
+	room := r.FindRoomOf(user)
+	if room == nil {
+		return
+	}
+	if left := room.users.RemoveL(user); left == 0 {
+		r.CloseRoom(room.Id())
+	}
+}
+
+// Remove removes the user from the router and its room.
 func (r *Router[T]) Remove(user T) {
-	if left := r.users.RemoveL(user); left == 0 {
-		r.Close()
-		r.SetRoom(nil) // !to remove
+	r.users.RemoveL(user)
+	r.Leave(user)
+}
+
+// CloseRoom closes and removes the room with the id.
+func (r *Router[T]) CloseRoom(id string) {
+	r.mu.Lock()
+	room := r.rooms[id]
+	delete(r.rooms, id)
+	r.mu.Unlock()
+	room.Close()
+}
+
+// Detach keeps the disconnected user with the id in its room for the grace
+// period, so the user could reattach a new session to the room.
+// The expire function is called if the user hasn't come back in time.
+func (r *Router[T]) Detach(id string, grace time.Duration, expire func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.detached == nil {
+		r.detached = make(map[string]*time.Timer)
+	}
+	if t := r.detached[id]; t != nil {
+		t.Stop()
+	}
+	var t *time.Timer
+	t = time.AfterFunc(grace, func() {
+		r.mu.Lock()
+		expired := r.detached[id] == t
+		if expired {
+			delete(r.detached, id)
+		}
+		r.mu.Unlock()
+		if expired {
+			expire()
+		}
+	})
+	r.detached[id] = t
+}
+
+// Reattach replaces the detached user with the id with its new session.
+// It returns the room of the user or nil if there is no such detached user.
+func (r *Router[T]) Reattach(id string, user T) *Room[T] {
+	r.mu.Lock()
+	t, ok := r.detached[id]
+	if ok {
+		t.Stop()
+		delete(r.detached, id)
+	}
+	r.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	room := r.FindRoomOf(r.users.Find(id))
+	r.users.Add(user)
+	if room != nil && room.users != nil {
+		room.users.Add(user)
 	}
+	return room
+}
+
+// Replace puts the new session of the user in place of the old one
+// that isn't detached (i.e. the second connection of the same user).
+// The old session leaves its room, which is returned.
+func (r *Router[T]) Replace(old T, user T) *Room[T] {
+	room := r.FindRoomOf(old)
+	r.users.Add(user)
+	if room != nil {
+		if left := room.users.RemoveL(old); left == 0 {
+			r.CloseRoom(room.Id())
+		}
+	}
+	return room
 }
 
+// HasSlot checks if a new room can be added to the router.
+func (r *Router[T]) HasSlot() bool { r.mu.Lock(); defer r.mu.Unlock(); return r.hasSlot() }
+func (r *Router[T]) hasSlot() bool { return len(r.rooms) < max(r.capacity, 1) }
+
 func (r *Router[T]) AddUser(user T)           { r.users.Add(user) }
-func (r *Router[T]) Close()                   { r.mu.Lock(); r.room.Close(); r.room = nil; r.mu.Unlock() }
+func (r *Router[T]) Capacity() int            { return max(r.capacity, 1) }
 func (r *Router[T]) FindUser(uid string) T    { return r.users.Find(uid) }
-func (r *Router[T]) Room() *Room[T]           { r.mu.Lock(); defer r.mu.Unlock(); return r.room }
-func (r *Router[T]) SetRoom(room *Room[T])    { r.mu.Lock(); r.room = room; r.mu.Unlock() }
-func (r *Router[T]) HasRoom() bool            { r.mu.Lock(); defer r.mu.Unlock(); return r.room != nil }
+func (r *Router[T]) RoomsLen() int            { r.mu.Lock(); defer r.mu.Unlock(); return len(r.rooms) }
 func (r *Router[T]) Users() SessionManager[T] { return r.users }
-func (r *Router[T]) Reset() {
+
+// Close closes all the rooms.
+func (r *Router[T]) Close() {
 	r.mu.Lock()
-	if r.room != nil {
-		r.room.Close()
-		r.room = nil
+	rooms := r.rooms
+	r.rooms = nil
+	r.mu.Unlock()
+	for _, room := range rooms {
+		room.Close()
 	}
+}
+
+func (r *Router[T]) Reset() {
+	r.Close()
+	r.mu.Lock()
 	for u := range r.users.Values() {
 		u.Disconnect()
 	}
@@ -165,9 +390,19 @@ func (p AppSession) Id() SessionKey { return p.uid }
 
 type GameSession struct {
 	AppSession
-	Index int // track user Index (i.e. player 1,2,3,4 select)
+	FixedLayer bool // the video layer is selected by the user, not by the network
+
+	// the role of the user is read by the input callbacks
+	// while the room handlers may change it
+	index     atomic.Int32 // track user Index (i.e. player 1,2,3,4 select)
+	spectator atomic.Bool  // spectators only watch the game without any input
 }
 
 func NewGameSession(id string, s Session) *GameSession {
 	return &GameSession{AppSession: AppSession{uid: SessionKey(id), Session: s}}
 }
+
+func (s *GameSession) Index() int          { return int(s.index.Load()) }
+func (s *GameSession) SetIndex(i int)      { s.index.Store(int32(i)) }
+func (s *GameSession) Spectator() bool     { return s.spectator.Load() }
+func (s *GameSession) SetSpectator(v bool) { s.spectator.Store(v) }
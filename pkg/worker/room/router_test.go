@@ -2,6 +2,7 @@ package room
 
 import (
 	"testing"
+	"time"
 
 	"github.com/giongto35/cloud-game/v3/pkg/com"
 )
@@ -15,12 +16,15 @@ type tSession struct {
 	connected bool
 }
 
-func (t *tSession) SendAudio([]byte, int32) {}
-func (t *tSession) SendVideo([]byte, int32) {}
-func (t *tSession) SendData([]byte)         {}
-func (t *tSession) Connect()                { t.connected = true }
-func (t *tSession) Disconnect()             { t.connected = false }
-func (t *tSession) Id() sKey                { return t.id }
+func (t *tSession) SendAudio([]byte, int32)                 {}
+func (t *tSession) SendVideo([]byte, int32)                 {}
+func (t *tSession) SendData([]byte)                         {}
+func (t *tSession) SendVideoLayers([][]byte, []bool, int32) {}
+func (t *tSession) VideoLayer() (int, int)                  { return 0, 0 }
+func (t *tSession) VideoCodec() string                      { return "" }
+func (t *tSession) Connect()                                { t.connected = true }
+func (t *tSession) Disconnect()                             { t.connected = false }
+func (t *tSession) Id() sKey                                { return t.id }
 
 type lookMap struct {
 	com.NetMap[sKey, *tSession]
@@ -36,24 +40,149 @@ func (l *lookMap) Reset() {
 }
 
 func TestRouter(t *testing.T) {
-	router := newTestRouter()
+	router := newTestRouter(1)
 
-	var r *Room[*tSession]
-
-	router.SetRoom(&Room[*tSession]{id: "test001"})
+	if !router.AddRoom(&Room[*tSession]{id: "test001"}) {
+		t.Errorf("couldn't add a room, but should")
+	}
 	room := router.FindRoom("test001")
 	if room == nil {
 		t.Errorf("no room, but should be")
 	}
-	router.SetRoom(r)
 	room = router.FindRoom("x")
 	if room != nil {
 		t.Errorf("a room, but should not be")
 	}
-	router.SetRoom(nil)
+	router.CloseRoom("test001")
+	if router.FindRoom("test001") != nil {
+		t.Errorf("a closed room, but should not be")
+	}
 	router.Close()
 }
 
+func TestRouterCapacity(t *testing.T) {
+	router := newTestRouter(2)
+
+	if !router.AddRoom(newTestRoom("1")) || !router.AddRoom(newTestRoom("2")) {
+		t.Fatalf("couldn't add rooms, but should")
+	}
+	if router.AddRoom(newTestRoom("3")) {
+		t.Errorf("added a room over the capacity, but should not")
+	}
+	if router.HasSlot() {
+		t.Errorf("has a free slot, but should not")
+	}
+	router.CloseRoom("1")
+	if !router.HasSlot() {
+		t.Errorf("no free slots, but should be")
+	}
+	if router.AddRoom(newTestRoom("2")) {
+		t.Errorf("added a duplicate room, but should not")
+	}
+	router.Close()
+	if router.RoomsLen() != 0 {
+		t.Errorf("has rooms after close, but should not")
+	}
+}
+
+func TestRouterRemove(t *testing.T) {
+	router := newTestRouter(2)
+
+	r1, r2 := newTestRoom("1"), newTestRoom("2")
+	router.AddRoom(r1)
+	router.AddRoom(r2)
+
+	u1, u2, u3 := &tSession{id: "1"}, &tSession{id: "2"}, &tSession{id: "3"}
+	for _, u := range []*tSession{u1, u2, u3} {
+		router.AddUser(u)
+	}
+	router.Join(u1, r1)
+	router.Join(u2, r1)
+	router.Join(u3, r2)
+
+	if router.FindRoomOf(u2) != r1 || router.FindRoomOf(u3) != r2 {
+		t.Errorf("wrong user rooms")
+	}
+
+	router.Remove(u1)
+	if router.FindRoom("1") == nil {
+		t.Errorf("the room was closed with users in it")
+	}
+	router.Remove(u2)
+	if router.FindRoom("1") != nil {
+		t.Errorf("the room wasn't closed without users")
+	}
+	if router.FindRoom("2") == nil {
+		t.Errorf("another room was closed, but should not")
+	}
+}
+
+func TestRouterDetach(t *testing.T) {
+	router := newTestRouter(1)
+	r := newTestRoom("1")
+	router.AddRoom(r)
+
+	u := &tSession{id: "1"}
+	router.AddUser(u)
+	router.Join(u, r)
+
+	router.Detach("1", time.Hour, func() { t.Errorf("expired, but should not") })
+	u2 := &tSession{id: "1", connected: true}
+	if router.Reattach("1", u2) != r {
+		t.Fatalf("couldn't reattach the user")
+	}
+	if found := r.users.Find("1"); found != u2 || router.FindUser("1") != u2 {
+		t.Errorf("the old session is in use")
+	}
+	if router.Reattach("1", u2) != nil {
+		t.Errorf("reattached not detached user")
+	}
+
+	expired := make(chan struct{})
+	router.Detach("1", time.Millisecond, func() { router.Remove(u2); close(expired) })
+	select {
+	case <-expired:
+	case <-time.After(time.Second):
+		t.Fatalf("not expired, but should")
+	}
+	if router.Reattach("1", u) != nil || router.FindRoom("1") != nil {
+		t.Errorf("the user is still in the room")
+	}
+}
+
+func TestRouterReplace(t *testing.T) {
+	router := newTestRouter(1)
+	r := newTestRoom("1")
+	router.AddRoom(r)
+
+	u, u3 := &tSession{id: "1"}, &tSession{id: "3"}
+	for _, x := range []*tSession{u, u3} {
+		router.AddUser(x)
+		router.Join(x, r)
+	}
+
+	// no detach, i.e. the second connection of the user
+	u2 := &tSession{id: "1", connected: true}
+	if router.Reattach("1", u2) != nil {
+		t.Fatalf("reattached not detached user")
+	}
+	if router.Replace(u, u2) != r {
+		t.Errorf("wrong room of the old session")
+	}
+	if router.FindUser("1") != u2 {
+		t.Errorf("the old session is in use")
+	}
+	if r.users.Find("1") != nil || r.users.Find("3") != u3 {
+		t.Errorf("the old session is still in the room")
+	}
+
+	router.Join(u2, r)
+	router.Remove(u3)
+	if router.Replace(u2, &tSession{id: "1"}) != r || router.FindRoom("1") != nil {
+		t.Errorf("the room of the old session wasn't closed without users")
+	}
+}
+
 func TestRouterReset(t *testing.T) {
 	u := lookMap{NetMap: com.NewNetMap[sKey, *tSession]()}
 	router := Router[*tSession]{users: &u}
@@ -76,7 +205,40 @@ func TestRouterReset(t *testing.T) {
 	}
 }
 
-func newTestRouter() *Router[*tSession] {
+func newTestRouter(capacity int) *Router[*tSession] {
 	u := com.NewNetMap[sKey, *tSession]()
-	return &Router[*tSession]{users: &u}
+	return &Router[*tSession]{capacity: capacity, users: &u}
+}
+
+func newTestRoom(id string) *Room[*tSession] {
+	u := com.NewNetMap[sKey, *tSession]()
+	return &Room[*tSession]{id: id, users: &u}
+}
+
+func TestRoomHost(t *testing.T) {
+	room := &Room[*tSession]{id: "test001"}
+
+	if room.IsHost("") {
+		t.Errorf("an empty host, but should not be")
+	}
+	room.SetHost("a")
+	if !room.IsHost("a") || room.IsHost("b") {
+		t.Errorf("wrong host: %v", room.Host())
+	}
+	room.SetLocked(true)
+	if !room.IsLocked() {
+		t.Errorf("the room is not locked, but should be")
+	}
+}
+
+func TestRoomKick(t *testing.T) {
+	room := &Room[*tSession]{id: "test001"}
+
+	if room.IsKicked("a") {
+		t.Errorf("the user is kicked, but should not be")
+	}
+	room.Kick("a")
+	if !room.IsKicked("a") || room.IsKicked("b") {
+		t.Errorf("wrong kicked users")
+	}
 }
@@ -0,0 +1,207 @@
+package worker
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/giongto35/cloud-game/v3/pkg/com"
+	"github.com/giongto35/cloud-game/v3/pkg/logger"
+	"github.com/giongto35/cloud-game/v3/pkg/network/httpx"
+	"github.com/giongto35/cloud-game/v3/pkg/network/webrtc"
+	"github.com/giongto35/cloud-game/v3/pkg/worker/room"
+)
+
+// WHEP (WebRTC-HTTP Egress Protocol) signalling of the room viewers without a browser,
+// i.e. OBS, GStreamer or ffmpeg.
+// See: https://www.rfc-editor.org/rfc/rfc9725.html
+//
+//	POST   /whep/{room}           (the SDP offer)  -> 201 (the SDP answer, Location of the session)
+//	DELETE /whep/{room}/{session}                  -> 200
+//
+// The requests are authenticated with the room token as the bearer token.
+// The viewers are the spectators of the room and the trickle ICE is not supported.
+
+const (
+	whepPath     = "/whep/"
+	whepPrefix   = "whep."
+	maxOfferSize = 64 << 10
+)
+
+type whep struct {
+	api    *webrtc.ApiFactory
+	log    *logger.Logger
+	secret []byte
+	w      *Worker
+}
+
+func newWhep(w *Worker, secret string) (*whep, error) {
+	api, err := webrtc.NewApiFactory(w.conf.Webrtc, w.log, nil)
+	if err != nil {
+		return nil, err
+	}
+	key := []byte(secret)
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		_, _ = rand.Read(key)
+	}
+	return &whep{api: api, log: w.log, secret: key, w: w}, nil
+}
+
+// roomToken returns the token of the room viewers.
+func (h *whep) roomToken(rid string) string {
+	mac := hmac.New(sha256.New, h.secret)
+	mac.Write([]byte(rid))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (h *whep) authorized(r *httpx.Request, rid string) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && hmac.Equal([]byte(token), []byte(h.roomToken(rid)))
+}
+
+func (h *whep) handle(mux *httpx.Mux) *httpx.Mux {
+	return mux.
+		HandleFunc(whepPath+"{room}", h.room).
+		HandleFunc(whepPath+"{room}/{session}", h.session)
+}
+
+func (h *whep) room(w httpx.ResponseWriter, r *httpx.Request) {
+	cors(w)
+	switch r.Method {
+	case http.MethodOptions:
+		w.Header().Set("Accept-Post", "application/sdp")
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodPost:
+		h.watch(w, r)
+	default:
+		w.Header().Set("Allow", "OPTIONS, POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *whep) session(w httpx.ResponseWriter, r *httpx.Request) {
+	cors(w)
+	switch r.Method {
+	case http.MethodOptions:
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodDelete:
+		h.stop(w, r)
+	default:
+		// no trickle ICE and ICE restarts (PATCH)
+		w.Header().Set("Allow", "OPTIONS, DELETE")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// watch adds a new viewer of the room with the SDP offer.
+func (h *whep) watch(w httpx.ResponseWriter, r *httpx.Request) {
+	rid := r.PathValue("room")
+	if !h.authorized(r, rid) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), "application/sdp") {
+		http.Error(w, "not an SDP offer", http.StatusUnsupportedMediaType)
+		return
+	}
+	offer, err := io.ReadAll(io.LimitReader(r.Body, maxOfferSize))
+	if err != nil || len(offer) == 0 {
+		http.Error(w, "no SDP offer", http.StatusBadRequest)
+		return
+	}
+
+	rm := h.w.router.FindRoom(rid)
+	if rm == nil {
+		http.Error(w, "no room", http.StatusNotFound)
+		return
+	}
+
+	id := whepPrefix + com.NewUid().String()
+	peer := webrtc.New(h.log, h.api)
+	user := room.NewGameSession(id, peer)
+	user.SetSpectator(true)
+	if !hasPlace(rm, user, true, h.w.conf.Worker) {
+		http.Error(w, "the room is full", http.StatusServiceUnavailable)
+		return
+	}
+
+	vc := h.w.conf.Encoder.Video
+	prefs := vc.Codecs
+	if len(prefs) == 0 {
+		prefs = []string{vc.Codec}
+	}
+	codec := webrtc.PickVideoCodec(prefs, "", webrtc.SdpVideoCodecs(string(offer)))
+	if codec == "" {
+		codec = vc.Codec
+	}
+
+	var answer string
+	if err = peer.NewConnection(codec, "opus", func(*string) {}); err == nil {
+		answer, err = peer.AnswerSDP(string(offer))
+	}
+	if err != nil {
+		h.log.Error().Err(err).Str("room", rid).Msg("whep")
+		peer.Disconnect()
+		http.Error(w, "bad SDP offer", http.StatusBadRequest)
+		return
+	}
+
+	peer.OnMessage(func([]byte) {})
+	peer.OnKeyframeRequest(rm.KeyFrame)
+	peer.OnDisconnect(func() { h.w.router.Remove(user) })
+	h.w.router.AddUser(user)
+	h.w.router.Join(user, rm)
+	rm.KeyFrame()
+	h.log.Info().Str("room", rid).Msgf("WHEP viewer [%v], video codec: %v", id, codec)
+
+	w.Header().Set("Content-Type", "application/sdp")
+	w.Header().Set("Location", whepPath+rid+"/"+id)
+	w.WriteHeader(http.StatusCreated)
+	_, _ = w.Write([]byte(answer))
+}
+
+// stop removes the viewer of the room.
+func (h *whep) stop(w httpx.ResponseWriter, r *httpx.Request) {
+	rid, id := r.PathValue("room"), r.PathValue("session")
+	if !h.authorized(r, rid) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var user *room.GameSession
+	if rm := h.w.router.FindRoom(rid); rm != nil && strings.HasPrefix(id, whepPrefix) {
+		user = rm.Users().Find(id)
+	}
+	if user == nil {
+		http.Error(w, "no session", http.StatusNotFound)
+		return
+	}
+	h.w.router.Remove(user)
+	user.Disconnect()
+	h.log.Info().Str("room", rid).Msgf("WHEP viewer [%v] has left", id)
+	w.WriteHeader(http.StatusOK)
+}
+
+// closeViewers disconnects the WHEP viewers of the closed room.
+func closeViewers(r *room.Room[*room.GameSession]) {
+	var viewers []*room.GameSession
+	for u := range r.Users().Values() {
+		if strings.HasPrefix(u.Id().String(), whepPrefix) {
+			viewers = append(viewers, u)
+		}
+	}
+	for _, u := range viewers {
+		u.Disconnect()
+	}
+}
+
+func cors(w httpx.ResponseWriter) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "OPTIONS, POST, DELETE")
+	w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
+	w.Header().Set("Access-Control-Expose-Headers", "Location")
+}
@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/giongto35/cloud-game/v3/pkg/api"
+	"github.com/giongto35/cloud-game/v3/pkg/cloud"
 	"github.com/giongto35/cloud-game/v3/pkg/config"
 	"github.com/giongto35/cloud-game/v3/pkg/games"
 	"github.com/giongto35/cloud-game/v3/pkg/logger"
@@ -11,7 +13,6 @@ import (
 	"github.com/giongto35/cloud-game/v3/pkg/network"
 	"github.com/giongto35/cloud-game/v3/pkg/network/httpx"
 	"github.com/giongto35/cloud-game/v3/pkg/worker/caged"
-	"github.com/giongto35/cloud-game/v3/pkg/worker/cloud"
 	"github.com/giongto35/cloud-game/v3/pkg/worker/room"
 )
 
@@ -29,6 +30,7 @@ type Worker struct {
 		Stop() error
 	}
 	storage cloud.Storage
+	whep    *whep
 }
 
 func New(conf config.WorkerConfig, log *logger.Logger) (*Worker, error) {
@@ -40,22 +42,44 @@ func New(conf config.WorkerConfig, log *logger.Logger) (*Worker, error) {
 	library := games.NewLib(conf.Library, conf.Emulator, log)
 	library.Scan()
 
+	// the in-process Libretro frontend is backed by a single global
+	// core instance, so it can run only one game at a time,
+	// the sandboxed one runs each game in a separate process
+	slots := max(conf.Worker.Slots, 1)
+	if slots > 1 && !conf.Emulator.Sandbox.Enabled {
+		log.Warn().Msgf("in-process Libretro supports only 1 room, slots: %v -> 1", slots)
+		slots = 1
+	}
+	conf.Worker.Slots = slots
+
 	worker := &Worker{
 		conf:     conf,
 		lib:      library,
 		launcher: games.NewGameLauncher(library),
 		log:      log,
 		mana:     manager,
-		router:   room.NewGameRouter(),
+		router:   room.NewGameRouter(slots),
+	}
+
+	if conf.Worker.Whep.Enabled {
+		wh, err := newWhep(worker, conf.Worker.Whep.Secret)
+		if err != nil {
+			return nil, fmt.Errorf("whep init fail: %w", err)
+		}
+		worker.whep = wh
 	}
 
 	h, err := httpx.NewServer(
 		conf.Worker.GetAddr(),
 		func(s *httpx.Server) httpx.Handler {
-			return s.Mux().HandleW(conf.Worker.Network.PingEndpoint, func(w httpx.ResponseWriter) {
+			mux := s.Mux().HandleW(conf.Worker.Network.PingEndpoint, func(w httpx.ResponseWriter) {
 				w.Header().Set("Access-Control-Allow-Origin", "*")
 				_, _ = w.Write([]byte{0x65, 0x63, 0x68, 0x6f}) // echo
 			})
+			if worker.whep != nil {
+				mux = worker.whep.handle(mux)
+			}
+			return mux
 		},
 		httpx.WithServerConfig(conf.Worker.Server),
 		httpx.HttpsRedirect(false),
@@ -71,7 +95,7 @@ func New(conf config.WorkerConfig, log *logger.Logger) (*Worker, error) {
 	if conf.Worker.Monitoring.IsEnabled() {
 		worker.services[1] = monitoring.New(conf.Worker.Monitoring, h.GetHost(), log)
 	}
-	st, err := cloud.Store(conf.Storage, log)
+	st, err := cloud.Store(conf.Storage, worker.sendCoordinator, log)
 	if err != nil {
 		log.Warn().Err(err).Msgf("cloud storage fail, using no storage")
 	}
@@ -82,6 +106,15 @@ func New(conf config.WorkerConfig, log *logger.Logger) (*Worker, error) {
 
 func (w *Worker) Reset() { w.router.Reset() }
 
+// sendCoordinator makes a call to the current coordinator.
+func (w *Worker) sendCoordinator(t api.PT, data any) ([]byte, error) {
+	cord := w.cord
+	if cord == nil {
+		return nil, errors.New("no coordinator connection")
+	}
+	return cord.Send(t, data)
+}
+
 func (w *Worker) Start(done chan struct{}) {
 	for _, s := range w.services {
 		if s != nil {
@@ -14,8 +14,35 @@ const endpoints = {
     GET_WORKER_LIST: 111,
     GAME_ERROR_NO_FREE_SLOTS: 112,
     GAME_RESET: 113,
+    GAME_USERS: 114,
+    GAME_ERROR_ROOM_FULL: 115,
+    GAME_KICK_USER: 116,
+    GAME_TRANSFER_HOST: 117,
+    GAME_LOCK_ROOM: 118,
+    GAME_HOST_CHANGED: 119,
+    GAME_KICKED: 120,
+    GAME_SLOTS: 121,
+    GAME_SAVE_SLOT: 122,
+    GAME_LOAD_SLOT: 123,
+    GAME_VIDEO_LAYER: 124,
+    GAME_WHEP_TOKEN: 125,
+    GAME_ROOM_AUDIO: 126,
+    GAME_VIDEO_FILTER: 127,
+    GAME_CHEATS: 128,
+    GAME_SET_CHEAT: 129,
+    GAME_DISKS: 130,
+    GAME_EJECT_DISK: 131,
+    GAME_SET_DISK: 132,
+    GAME_SPEED: 133,
+    GAME_FRAME_ADVANCE: 134,
+    GAME_REWIND: 135,
+    GAME_CORE_OPTIONS: 136,
+    GAME_SET_CORE_OPTION: 137,
 
     APP_VIDEO_CHANGE: 150,
+    APP_CRASH: 151,
+    APP_FRAME: 152,
+    APP_SPEED: 153,
 };
 
 const endpointName = Object.fromEntries(
@@ -338,15 +365,22 @@ export const api = {
         /** Initializes the stream with the given config.
          * @property {boolean} initiator - whether the user is an initiator or not.
          * @property {string} sdpOffer - optional SDP offer, just to make negotiation a bit faster.
+         * @property {string[]} codecs - the video codecs (mime types) supported by the browser.
          */
-        initWebrtcStream: ({ initiator = false, sdpOffer = "" } = {}) =>
+        initWebrtcStream: ({
+            initiator = false,
+            sdpOffer = "",
+            codecs = [],
+        } = {}) =>
             packet(endpoints.INIT_WEBRTC_STREAM, {
                 initiator,
                 ...(sdpOffer && { sdp: toJson(sdpOffer) }),
+                ...(codecs.length && { codecs }),
             }),
         sendIceCandidate: (candidate) =>
             packet(endpoints.WEBRTC_SIGNAL, { ice: toJson(candidate) }),
         sendSdp: (sdp) => packet(endpoints.WEBRTC_SIGNAL, { sdp: toJson(sdp) }),
+        restartIce: () => packet(endpoints.WEBRTC_SIGNAL, { restart: true }),
         latencyCheck: (id, list) => packet(endpoints.LATENCY_CHECK, list, id),
         getWorkerList: () => packet(endpoints.GET_WORKER_LIST),
     },
@@ -360,23 +394,70 @@ export const api = {
                 press: mousePress,
             },
         },
+        kick: (userId) => packet(endpoints.GAME_KICK_USER, userId),
         load: () => packet(endpoints.GAME_LOAD),
+        loadSlot: (slot = "") => packet(endpoints.GAME_LOAD_SLOT, slot),
+        lock: (locked = true) => packet(endpoints.GAME_LOCK_ROOM, locked),
         reset: (roomId) => packet(endpoints.GAME_RESET, { room_id: roomId }),
         save: () => packet(endpoints.GAME_SAVE),
+        saveSlot: (slot = "") => packet(endpoints.GAME_SAVE_SLOT, slot),
         setPlayerIndex: (i) => packet(endpoints.GAME_SET_PLAYER_INDEX, i),
-        start: (game, roomId, record, recordUser, player) =>
+        slots: () => packet(endpoints.GAME_SLOTS),
+        start: (game, roomId, record, recordUser, player, spectator = false) =>
             packet(endpoints.GAME_START, {
                 game_name: game,
                 room_id: roomId,
                 player_index: player,
                 record: record,
                 record_user: recordUser,
+                ...(spectator && { spectator }),
             }),
         toggleRecording: (active = false, userName = "") =>
             packet(endpoints.GAME_RECORDING, {
                 active: active,
                 user: userName,
             }),
+        transferHost: (userId) => packet(endpoints.GAME_TRANSFER_HOST, userId),
         quit: (roomId) => packet(endpoints.GAME_QUIT, { room_id: roomId }),
+        users: () => packet(endpoints.GAME_USERS),
+        /** Sets the video layer to watch: 0 is the best quality, -1 is auto. */
+        videoLayer: (layer = -1) => packet(endpoints.GAME_VIDEO_LAYER, layer),
+        /** Requests the WHEP address and token of the room (host only). */
+        whepToken: () => packet(endpoints.GAME_WHEP_TOKEN),
+        /**
+         * Changes the audio of the room (host only), the omitted options keep their values.
+         * @param {{gain?: number, mute?: boolean, normalize?: boolean}} options gain in dB
+         */
+        audio: (options = {}) => packet(endpoints.GAME_ROOM_AUDIO, options),
+        /**
+         * Changes the video filter of the room (host only), the empty filter is off.
+         * @param {{scale?: number, scanlines?: number, sharpen?: number, width?: number, height?: number}} filter
+         */
+        videoFilter: (filter = {}) => packet(endpoints.GAME_VIDEO_FILTER, filter),
+        /** Requests the cheats of the game. */
+        cheats: () => packet(endpoints.GAME_CHEATS),
+        /** Enables or disables the cheat with the index (host only). */
+        setCheat: (index, enabled = true) =>
+            packet(endpoints.GAME_SET_CHEAT, { index, enabled }),
+        /** Requests the disks of a multi-disc game. */
+        disks: () => packet(endpoints.GAME_DISKS),
+        /** Opens (true) or closes (false) the disk tray (host only). */
+        ejectDisk: (ejected = true) => packet(endpoints.GAME_EJECT_DISK, ejected),
+        /** Inserts the disk with the index (host only). */
+        setDisk: (index) => packet(endpoints.GAME_SET_DISK, index),
+        /** Changes the speed of the game: 0 (pause), 0.5, 1, 2 or 4 (host only). */
+        speed: (speed = 1) => packet(endpoints.GAME_SPEED, speed),
+        /** Runs one frame of the paused game (host only). */
+        frameAdvance: () => packet(endpoints.GAME_FRAME_ADVANCE),
+        /**
+         * Rewinds the game by the seconds while the rewind button is held,
+         * 0 stops it when the button is released (host only).
+         */
+        rewind: (seconds = 60) => packet(endpoints.GAME_REWIND, seconds),
+        /** Requests the options of the core (keys, descriptions and allowed values). */
+        coreOptions: () => packet(endpoints.GAME_CORE_OPTIONS),
+        /** Changes the core option during the play (host only). */
+        setCoreOption: (key, value) =>
+            packet(endpoints.GAME_SET_CORE_OPTION, { key, value }),
     },
 };
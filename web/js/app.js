@@ -61,6 +61,12 @@ let lastState;
 // first user interaction
 let interacted = false;
 
+// the last known frame of the game (netplay),
+// the frames shown to the user are counted from it
+let frameSync = null;
+const currentFrame = () =>
+    frameSync.frame + Math.floor(((performance.now() - frameSync.t) * frameSync.fps) / 1000);
+
 const helpOverlay = document.getElementById("help-overlay");
 const playerIndex = document.getElementById("playeridx");
 
@@ -164,6 +170,7 @@ const startGame = () => {
         recording.isActive(),
         recording.getUser(),
         +playerIndex.value - 1,
+        room.spectator,
     );
 
     gameList.disable();
@@ -182,6 +189,9 @@ const onMessage = (m) => {
     switch (t) {
         case api.endpoint.INIT:
             const initiator = !options.webrtcWaitOffer;
+            socket.setResume(payload.resume);
+            // the resumed session needs a new connection
+            webrtc.stop();
             handleWebrtcStart({ data: payload, initiator });
             break;
         case api.endpoint.WEBRTC_SIGNAL:
@@ -199,6 +209,9 @@ const onMessage = (m) => {
         case api.endpoint.GAME_START:
             if (payload.av) pub(APP_VIDEO_CHANGED, payload.av);
             if (payload.kb_mouse) pub(KB_MOUSE_FLAG);
+            if (payload.spectator) message.show("Spectator mode");
+            if (payload.host) log.info("[room] you are the host");
+            if (payload.layers) log.info(`[room] video layers: ${payload.layers}`);
             pub(GAME_ROOM_AVAILABLE, { roomId: payload.roomId });
             break;
         case api.endpoint.GAME_SAVE:
@@ -206,6 +219,43 @@ const onMessage = (m) => {
             break;
         case api.endpoint.GAME_LOAD:
             break;
+        case api.endpoint.GAME_SAVE_SLOT:
+            pub(GAME_SAVED);
+            break;
+        case api.endpoint.GAME_LOAD_SLOT:
+            break;
+        case api.endpoint.GAME_SLOTS:
+            log.info("[room] saves", payload);
+            break;
+        case api.endpoint.GAME_VIDEO_LAYER:
+            log.info(`[room] video layer: ${payload < 0 ? "auto" : payload}`);
+            break;
+        case api.endpoint.GAME_VIDEO_FILTER:
+            log.info("[room] video filter", payload);
+            break;
+        case api.endpoint.GAME_CHEATS:
+        case api.endpoint.GAME_SET_CHEAT:
+            log.info("[room] cheats", payload);
+            break;
+        case api.endpoint.GAME_DISKS:
+        case api.endpoint.GAME_EJECT_DISK:
+        case api.endpoint.GAME_SET_DISK:
+            log.info("[room] disks", payload);
+            break;
+        case api.endpoint.GAME_SPEED:
+        case api.endpoint.GAME_FRAME_ADVANCE:
+        case api.endpoint.GAME_REWIND:
+            break;
+        case api.endpoint.GAME_CORE_OPTIONS:
+        case api.endpoint.GAME_SET_CORE_OPTION:
+            log.info("[room] core options", payload);
+            break;
+        case api.endpoint.GAME_ROOM_AUDIO:
+            log.info("[room] audio", payload);
+            break;
+        case api.endpoint.GAME_WHEP_TOKEN:
+            log.info(`[room] WHEP: ${payload.url}, token: ${payload.token}`);
+            break;
         case api.endpoint.GAME_SET_PLAYER_INDEX:
             pub(GAME_PLAYER_IDX_SET, payload);
             break;
@@ -221,9 +271,30 @@ const onMessage = (m) => {
         case api.endpoint.GAME_ERROR_NO_FREE_SLOTS:
             pub(GAME_ERROR_NO_FREE_SLOTS);
             break;
+        case api.endpoint.GAME_ERROR_ROOM_FULL:
+            message.show("The room is full or locked :(", 2500);
+            break;
+        case api.endpoint.GAME_USERS:
+            log.info("[room] users", payload);
+            break;
+        case api.endpoint.GAME_HOST_CHANGED:
+            message.show("You are the host now");
+            break;
+        case api.endpoint.GAME_KICKED:
+            message.show("You have been kicked out of the room", 2500);
+            break;
         case api.endpoint.APP_VIDEO_CHANGE:
             pub(APP_VIDEO_CHANGED, { ...payload });
             break;
+        case api.endpoint.APP_FRAME:
+            frameSync = { ...payload, t: performance.now() };
+            break;
+        case api.endpoint.APP_SPEED:
+            message.show(payload?.speed ? `Speed: ${payload.speed}x` : "Paused");
+            break;
+        case api.endpoint.APP_CRASH:
+            message.show(payload?.restarting ? "The game has crashed, restarting..." : "The game has crashed ):");
+            break;
     }
 };
 
@@ -505,6 +576,7 @@ function handleWebrtcStart({ data, initiator }) {
         },
         onConnect: onConnectionReady,
         onDisconnect: () => {
+            frameSync = null;
             input.retropad.toggle(false);
             webrtc.stop();
         },
@@ -512,6 +584,7 @@ function handleWebrtcStart({ data, initiator }) {
             init: api.server.initWebrtcStream,
             sendIceCandidate: api.server.sendIceCandidate,
             sendSdp: api.server.sendSdp,
+            restartIce: api.server.restartIce,
         },
     });
 
@@ -546,7 +619,17 @@ sub(KEY_RELEASED, onKeyRelease);
 
 sub(SETTINGS_CHANGED, () => message.show("Settings have been updated"));
 sub(AXIS_CHANGED, onAxisChanged);
-sub(CONTROLLER_UPDATED, (data) => webrtc.send("data", data));
+sub(CONTROLLER_UPDATED, (data) => {
+    if (!frameSync) {
+        webrtc.send("data", data);
+        return;
+    }
+    // [FRAME:8][RETROPAD DATA]
+    const buf = new Uint8Array(8 + data.byteLength);
+    new DataView(buf.buffer).setBigUint64(0, BigInt(currentFrame()));
+    buf.set(new Uint8Array(data.buffer, data.byteOffset, data.byteLength), 8);
+    webrtc.send("netplay", buf);
+});
 sub(RECORDING_TOGGLED, handleRecording);
 sub(RECORDING_STATUS_CHANGED, handleRecordingStatus);
 
@@ -2,6 +2,13 @@ import { pub, MESSAGE } from "event";
 import { log } from "log";
 
 let conn;
+let params = {};
+// the token to resume the session after a disconnect
+let resume = "";
+let retries = 0;
+
+const RESUME_RETRIES = 5;
+const RESUME_DELAY_MS = 2000;
 
 const buildUrl = (params = {}) => {
     const url = new URL(window.location);
@@ -14,17 +21,42 @@ const buildUrl = (params = {}) => {
 };
 
 const init = (roomId, wid, zone) => {
-    let objParams = { room_id: roomId, zone: zone };
-    if (wid) objParams.wid = wid;
+    params = { room_id: roomId, zone: zone };
+    if (wid) params.wid = wid;
+    connect(params);
+};
+
+const connect = (objParams) => {
     const url = buildUrl(objParams);
     log.debug(`[ws] connecting to ${url}`);
     conn = new WebSocket(url.toString());
-    conn.onopen = () => log.debug("[ws] opened");
+    conn.onopen = () => {
+        log.debug("[ws] opened");
+        retries = 0;
+    };
     conn.onerror = () => log.error("[ws] error");
-    conn.onclose = (event) => log.debug(`[ws] closed (${event.code})`);
+    conn.onclose = (event) => {
+        log.debug(`[ws] closed (${event.code})`);
+        reconnect();
+    };
     conn.onmessage = (response) => pub(MESSAGE, JSON.parse(response.data));
 };
 
+// reconnect tries to continue the session with the server
+// while it keeps the session after a disconnect
+const reconnect = () => {
+    if (!resume || retries >= RESUME_RETRIES) return;
+    retries++;
+    setTimeout(() => {
+        log.info(`[ws] resuming the session (${retries}/${RESUME_RETRIES})`);
+        connect({ ...params, resume });
+    }, RESUME_DELAY_MS);
+};
+
+// setResume sets the token to resume the session, the new connection
+// gets a new token, so the old one is no longer valid
+const setResume = (token = "") => (resume = token);
+
 const send = (data) => {
     if (conn.readyState === 1) conn.send(JSON.stringify(data));
 };
@@ -37,4 +69,5 @@ const send = (data) => {
 export const socket = {
     init,
     send,
+    setResume,
 };
@@ -39,8 +39,9 @@ const ice = ((signaller) => {
         log.debug(`[rtc] [ice] connection state: ${pc.iceConnectionState}`);
         switch (pc.iceConnectionState) {
             case "failed":
+                // the server makes a new offer with ICE restart
                 log.error("[rtc] [ice] failed establish connection, retry...");
-                pc.restartIce();
+                signaller()?.restartIce?.();
                 break;
         }
     };
@@ -90,6 +91,15 @@ const mung = (sdp) =>
 
 const stub = () => {};
 
+// the video codecs the browser can decode (mime types)
+const videoCodecs = () => [
+    ...new Set(
+        (RTCRtpReceiver.getCapabilities?.("video")?.codecs || []).map(
+            (c) => c.mimeType,
+        ),
+    ),
+];
+
 const offer = async () => {
     if (!pc || !caller) return;
 
@@ -198,10 +208,14 @@ export const webrtc = {
         if (initiator) {
             offer().then((offer) => {
                 if (!offer) return;
-                signalling.init({ initiator, sdpOffer: offer });
+                signalling.init({
+                    initiator,
+                    sdpOffer: offer,
+                    codecs: videoCodecs(),
+                });
             });
         } else {
-            signalling.init();
+            signalling.init({ codecs: videoCodecs() });
         }
     },
     offer,
@@ -218,7 +232,8 @@ export const webrtc = {
             return;
         }
 
-        if (caller) return;
+        // answer the offers, including the ICE restart ones
+        if (sdp.type !== "offer") return;
 
         try {
             const answer = await pc.createAnswer();
@@ -4,6 +4,7 @@ import {
 } from 'event';
 
 let id = '';
+let spectator = false;
 
 // UI
 const roomLabel = document.getElementById('room-txt');
@@ -26,6 +27,9 @@ const parseURLForRoom = () => {
         room = decodeURIComponent(queryDict.id);
     }
 
+    // watch-only mode for deep links, i.e. ?id=xxx&spectate
+    spectator = room !== null && Object.hasOwn(queryDict, 'spectate');
+
     return [room, zone];
 };
 
@@ -45,8 +49,12 @@ export const room = {
         id = id_;
         roomLabel.value = id;
     },
+    get spectator() {
+        return spectator
+    },
     reset: () => {
         id = '';
+        spectator = false;
         roomLabel.value = id;
     },
     save: (roomIndex) => {
@@ -112,11 +112,13 @@ function renderServerChangeEl(server) {
 }
 
 function renderStateEl(server) {
-    const state = server?.is_busy === true ? 'R' : ''
-    if (server.room) {
+    const total = (server?.free_slots || 0) + (server?.used_slots || 0)
+    const state = (server?.is_busy === true ? 'R' : '') + (total > 1 ? ` ${server.used_slots}/${total}` : '')
+    const room = server?.rooms?.[0]
+    if (room) {
         return gui.create('a', (el) => {
             el.innerText = state;
-            el.href = "/?id=" + server.room;
+            el.href = "/?id=" + room;
         })
     }
     return state